@@ -0,0 +1,322 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/messages"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// deletedPlaceholder is who deleted accounts' posts and comments are credited
+// to
+const deletedPlaceholder = "[deleted]"
+
+// Username rules
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 20
+)
+
+// reservedUsernames can't be registered in any case, so nobody passes for
+// staff or for AutoModerator
+var reservedUsernames = map[string]bool{
+	strings.ToLower(autoModeratorName): true,
+	"admin":                            true,
+	"administrator":                    true,
+	"moderator":                        true,
+	"mod":                              true,
+	"deleted":                          true,
+	"removed":                          true,
+	"reddit":                           true,
+	"root":                             true,
+	"system":                           true,
+	"support":                          true,
+}
+
+// checkUsername holds a new username to the rules. Uniqueness, regardless of
+// case, is up to claimUsername.
+func checkUsername(username string) error {
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return fmt.Errorf("Username must be %d to %d characters", minUsernameLength, maxUsernameLength)
+	}
+	for _, char := range username {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9', char == '_', char == '-':
+		default:
+			return fmt.Errorf("Username may only contain letters, digits, _ and -")
+		}
+	}
+	if reservedUsernames[strings.ToLower(username)] {
+		return fmt.Errorf("Username is reserved")
+	}
+	return nil
+}
+
+// checkLogin says why a user whose password matched still may not log in
+func checkLogin(profile *UserProfile, now time.Time) error {
+	if profile == nil || !profile.Suspension.activeAt(now) {
+		return nil
+	}
+	if profile.Suspension.ExpiresAt == 0 {
+		return fmt.Errorf("Account is suspended")
+	}
+	return fmt.Errorf("Account is suspended until %s", time.Unix(profile.Suspension.ExpiresAt, 0).UTC().Format(time.RFC3339))
+}
+
+// updateProfile saves a changed copy of the user's profile
+func (state *UserActor) updateProfile(username string, change func(profile *UserProfile)) error {
+	profile := &UserProfile{}
+	if current, exists := state.store.Profiles.Get(username); exists {
+		copied := *current
+		profile = &copied
+	}
+	change(profile)
+	return state.store.Profiles.Put(username, profile)
+}
+
+func (state *UserActor) handleDeactivate(msg *messages.DeactivateAccount) *messages.DeactivateAccountResponse {
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.DeactivateAccountResponse{Success: false, Error: "User not found"}
+	}
+	if err := state.updateProfile(msg.Username, func(profile *UserProfile) { profile.Deactivated = true }); err != nil {
+		return &messages.DeactivateAccountResponse{Success: false, Error: "Failed to save profile"}
+	}
+	revokeTokens(state.store, msg.Username, func(record *authToken) bool { return true })
+	return &messages.DeactivateAccountResponse{Success: true}
+}
+
+// handleDeleteAccount credits everything the user wrote to "[deleted]" and
+// drops them from their subreddits before removing the account, answering
+// through context when it's done, so a deletion that fails part way leaves
+// the user able to try again
+func (state *UserActor) handleDeleteAccount(context actor.Context, msg *messages.DeleteAccount) {
+	storedPassword, exists := state.store.Users.Get(msg.Username)
+	if !exists {
+		context.Respond(&messages.DeleteAccountResponse{Success: false, Error: "User not found"})
+		return
+	}
+	if match, _ := checkPassword(storedPassword, msg.Password); !match {
+		context.Respond(&messages.DeleteAccountResponse{Success: false, Error: "Invalid credentials"})
+		return
+	}
+
+	state.anonymizeContent(context, msg.Username, 0, func(err error) {
+		if err != nil {
+			context.Respond(&messages.DeleteAccountResponse{Success: false, Error: err.Error() + "; try again"})
+			return
+		}
+		state.leaveSubreddits(context, msg.Username, func(err error) {
+			if err != nil {
+				context.Respond(&messages.DeleteAccountResponse{Success: false, Error: err.Error() + "; try again"})
+				return
+			}
+			// Another deletion may have finished while this one waited
+			if !state.store.Users.Has(msg.Username) {
+				context.Respond(&messages.DeleteAccountResponse{Success: false, Error: "User not found"})
+				return
+			}
+			state.deleteAccount(msg.Username)
+			context.Respond(&messages.DeleteAccountResponse{Success: true})
+		})
+	})
+}
+
+// deleteAccount removes everything kept about the user. The name stays
+// claimed in Usernames, so nobody can pose as them.
+func (state *UserActor) deleteAccount(username string) {
+	revokeTokens(state.store, username, func(record *authToken) bool { return true })
+	state.store.Users.Delete(username)
+	state.store.Profiles.Delete(username)
+	state.store.Karma.Delete(username)
+	state.store.UserPosts.Delete(username)
+	state.store.UserComments.Delete(username)
+	notificationIds, _ := state.store.UserNotifications.Get(username)
+	for _, notificationId := range notificationIds {
+		state.store.Notifications.Delete(notificationId)
+	}
+	state.store.UserNotifications.Delete(username)
+	state.store.UserMessages.Delete(username)
+	state.store.UserIndex.Remove(username)
+}
+
+// leaveSubreddits has the owner of every subreddit the user belongs to, or
+// moderates or asked to join, drop them, then calls done
+func (state *UserActor) leaveSubreddits(context actor.Context, username string, done func(err error)) {
+	var requests []interface{}
+	state.store.Subreddits.Range(func(name string, subreddit *Subreddit) bool {
+		if subreddit.mentions(username) {
+			requests = append(requests, &messages.DropSubredditUser{SubredditName: name, Username: username})
+		}
+		return true
+	})
+	if context.Parent() == nil {
+		done(nil)
+		return
+	}
+
+	requestInTurn(context, requests, func(request, response interface{}) error {
+		if dropped, ok := response.(*messages.DropSubredditUserResponse); !ok || !dropped.Success {
+			return fmt.Errorf("Failed to leave subreddit %s", request.(*messages.DropSubredditUser).SubredditName)
+		}
+		return nil
+	}, done)
+}
+
+// anonymizeContent has the owner of each of the user's posts and comments
+// credit it to "[deleted]", going round again for any written meanwhile,
+// then calls done
+func (state *UserActor) anonymizeContent(context actor.Context, username string, round int, done func(err error)) {
+	postIds, _ := state.store.UserPosts.Get(username)
+	commentIds, _ := state.store.UserComments.Get(username)
+	if context.Parent() == nil || len(postIds) == 0 && len(commentIds) == 0 {
+		done(nil)
+		return
+	}
+	if round == archiveRounds {
+		done(fmt.Errorf("Content kept arriving while deleting"))
+		return
+	}
+
+	requests := make([]interface{}, 0, len(postIds)+len(commentIds))
+	for _, postId := range postIds {
+		requests = append(requests, &messages.AnonymizePost{PostId: postId, Username: username})
+	}
+	for _, commentId := range commentIds {
+		requests = append(requests, &messages.AnonymizeComment{CommentId: commentId, Username: username})
+	}
+	requestInTurn(context, requests, func(request, response interface{}) error {
+		switch request := request.(type) {
+		case *messages.AnonymizePost:
+			if anonymized, ok := response.(*messages.AnonymizePostResponse); !ok || !anonymized.Success {
+				return fmt.Errorf("Failed to anonymize post %s", request.PostId)
+			}
+		case *messages.AnonymizeComment:
+			if anonymized, ok := response.(*messages.AnonymizeCommentResponse); !ok || !anonymized.Success {
+				return fmt.Errorf("Failed to anonymize comment %s", request.CommentId)
+			}
+		}
+		return nil
+	}, func(err error) {
+		if err != nil {
+			done(err)
+			return
+		}
+		state.anonymizeContent(context, username, round+1, done)
+	})
+}
+
+func (state *UserActor) handleSuspend(msg *messages.SuspendUser) *messages.SuspendUserResponse {
+	if !state.store.isAdmin(msg.AdminId) {
+		return &messages.SuspendUserResponse{Success: false, Error: "Only admins can suspend users"}
+	}
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.SuspendUserResponse{Success: false, Error: "User not found"}
+	}
+	if msg.Days < 0 {
+		return &messages.SuspendUserResponse{Success: false, Error: "Days can't be negative"}
+	}
+
+	now := time.Now()
+	suspension := &Sanction{Reason: msg.Reason, By: msg.AdminId, At: now.Unix()}
+	if msg.Days > 0 {
+		suspension.ExpiresAt = now.Add(time.Duration(msg.Days) * 24 * time.Hour).Unix()
+	}
+	if err := state.updateProfile(msg.Username, func(profile *UserProfile) { profile.Suspension = suspension }); err != nil {
+		return &messages.SuspendUserResponse{Success: false, Error: "Failed to save profile"}
+	}
+	revokeTokens(state.store, msg.Username, func(record *authToken) bool { return true })
+	return &messages.SuspendUserResponse{Success: true}
+}
+
+func (state *UserActor) handleUnsuspend(msg *messages.UnsuspendUser) *messages.UnsuspendUserResponse {
+	if !state.store.isAdmin(msg.AdminId) {
+		return &messages.UnsuspendUserResponse{Success: false, Error: "Only admins can lift suspensions"}
+	}
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.UnsuspendUserResponse{Success: false, Error: "User not found"}
+	}
+	if err := state.updateProfile(msg.Username, func(profile *UserProfile) { profile.Suspension = nil }); err != nil {
+		return &messages.UnsuspendUserResponse{Success: false, Error: "Failed to save profile"}
+	}
+	return &messages.UnsuspendUserResponse{Success: true}
+}
+
+// handleAnonymize credits the post to "[deleted]". A post that is gone or
+// already anonymized only leaves the user's history.
+func (state *PostActor) handleAnonymize(msg *messages.AnonymizePost) *messages.AnonymizePostResponse {
+	if post, exists := state.store.Posts.Get(msg.PostId); exists && post.AuthorId == msg.Username {
+		post = post.clone()
+		post.AuthorId = deletedPlaceholder
+		if err := state.store.Posts.Put(post.PostId, post); err != nil {
+			return &messages.AnonymizePostResponse{Success: false, Error: "Failed to save post"}
+		}
+		state.store.PostIndex.Add(postDocument(post))
+	}
+	removeIDFrom(state.store.UserPosts, msg.Username, msg.PostId)
+	return &messages.AnonymizePostResponse{Success: true}
+}
+
+func (state *CommentActor) handleAnonymize(msg *messages.AnonymizeComment) *messages.AnonymizeCommentResponse {
+	if comment, exists := state.store.Comments.Get(msg.CommentId); exists && comment.AuthorId == msg.Username {
+		comment = comment.clone()
+		comment.AuthorId = deletedPlaceholder
+		if err := state.store.Comments.Put(comment.CommentId, comment); err != nil {
+			return &messages.AnonymizeCommentResponse{Success: false, Error: "Failed to save comment"}
+		}
+		state.store.CommentIndex.Add(commentDocument(comment))
+	}
+	removeIDFrom(state.store.UserComments, msg.Username, msg.CommentId)
+	return &messages.AnonymizeCommentResponse{Success: true}
+}
+
+// mentions reports whether the subreddit keeps username anywhere a deleted
+// account has to leave
+func (subreddit *Subreddit) mentions(username string) bool {
+	_, invited := subreddit.ModInvites[username]
+	_, requested := subreddit.JoinRequests[username]
+	return subreddit.isModerator(username) || subreddit.Members[username] || subreddit.ApprovedSubmitters[username] || invited || requested
+}
+
+// handleDropUser takes a deleted account out of the subreddit. A creator
+// hands the subreddit to the moderator named first among those with all
+// permissions, or failing that among the rest; with nobody left it waits for
+// an admin to delete it.
+func (state *SubredditActor) handleDropUser(msg *messages.DropSubredditUser) *messages.DropSubredditUserResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists || !subreddit.mentions(msg.Username) {
+		return &messages.DropSubredditUserResponse{Success: true}
+	}
+
+	subreddit = subreddit.clone()
+	delete(subreddit.Members, msg.Username)
+	delete(subreddit.Moderators, msg.Username)
+	delete(subreddit.ModInvites, msg.Username)
+	delete(subreddit.ApprovedSubmitters, msg.Username)
+	delete(subreddit.JoinRequests, msg.Username)
+	if subreddit.CreatorId == msg.Username {
+		subreddit.CreatorId = deletedPlaceholder
+		successors := make([]string, 0, len(subreddit.Moderators))
+		for username := range subreddit.Moderators {
+			successors = append(successors, username)
+		}
+		sort.Slice(successors, func(i, j int) bool {
+			iAll, jAll := subreddit.permits(successors[i], messages.ModPermAll), subreddit.permits(successors[j], messages.ModPermAll)
+			if iAll != jAll {
+				return iAll
+			}
+			return successors[i] < successors[j]
+		})
+		if len(successors) > 0 {
+			subreddit.CreatorId = successors[0]
+			subreddit.Moderators[successors[0]] = []string{messages.ModPermAll}
+		}
+	}
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.DropSubredditUserResponse{Success: false, Error: "Failed to save subreddit"}
+	}
+	state.store.SubredditIndex.Add(subredditDocument(subreddit))
+	return &messages.DropSubredditUserResponse{Success: true}
+}
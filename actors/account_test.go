@@ -0,0 +1,76 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestDeleteAccountLeavesSubreddits(t *testing.T) {
+	store := newTestStore(t, "owner", "bob", "carol")
+	ask := newTestEngine(t, store)
+	store.SetAdmins([]string{"admin"})
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Members = map[string]bool{"bob": true, "carol": true}
+		subreddit.Moderators["bob"] = []string{messages.ModPermPosts}
+		subreddit.ApprovedSubmitters["bob"] = true
+	})
+	setupSubreddit(t, store, "secret", func(subreddit *Subreddit) {
+		subreddit.Type = messages.SubredditPrivate
+		subreddit.JoinRequests["bob"] = 1
+	})
+	setupSubreddit(t, store, "abandoned", nil)
+
+	for _, username := range []string{"bob", "owner"} {
+		if response := ask(&messages.DeleteAccount{Username: username, Password: "password123"}).(*messages.DeleteAccountResponse); !response.Success {
+			t.Fatalf("DeleteAccount(%s) = %+v", username, response)
+		}
+	}
+
+	members := ask(&messages.GetSubredditMembers{SubredditName: "golang"}).(*messages.GetSubredditMembersResponse)
+	if len(members.Members) != 1 || members.Members[0] != "carol" {
+		t.Errorf("members = %v, want [carol]", members.Members)
+	}
+	golang, _ := store.Subreddits.Get("golang")
+	if golang.mentions("bob") || golang.mentions("owner") {
+		t.Errorf("golang = %+v, still mentions a deleted account", golang)
+	}
+	if secret, _ := store.Subreddits.Get("secret"); secret.mentions("bob") {
+		t.Errorf("secret = %+v, still has bob's join request", secret)
+	}
+
+	// With no moderator left, only an admin can clear the subreddit away
+	abandoned, _ := store.Subreddits.Get("abandoned")
+	if abandoned.CreatorId != deletedPlaceholder {
+		t.Errorf("abandoned creator = %q, want %q", abandoned.CreatorId, deletedPlaceholder)
+	}
+	if response := ask(&messages.DeleteSubreddit{Name: "abandoned", AuthorId: "carol"}).(*messages.DeleteSubredditResponse); response.Success {
+		t.Errorf("a user deleted an abandoned subreddit")
+	}
+	if response := ask(&messages.DeleteSubreddit{Name: "abandoned", AuthorId: "admin"}).(*messages.DeleteSubredditResponse); !response.Success {
+		t.Errorf("DeleteSubreddit by an admin = %+v", response)
+	}
+}
+
+func TestDropCreatorHandsOverTheSubreddit(t *testing.T) {
+	store := newTestStore(t)
+	state := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Moderators["alice"] = []string{messages.ModPermPosts}
+		subreddit.Moderators["zed"] = []string{messages.ModPermAll}
+	})
+
+	if response := state.handleDropUser(&messages.DropSubredditUser{SubredditName: "golang", Username: "owner"}); !response.Success {
+		t.Fatalf("handleDropUser() error = %s", response.Error)
+	}
+	subreddit, _ := store.Subreddits.Get("golang")
+	if subreddit.CreatorId != "zed" || subreddit.isModerator("owner") {
+		t.Errorf("creator = %q, moderators = %v, want zed to take over", subreddit.CreatorId, subreddit.Moderators)
+	}
+
+	if response := state.handleDropUser(&messages.DropSubredditUser{SubredditName: "golang", Username: "zed"}); !response.Success {
+		t.Fatalf("handleDropUser() error = %s", response.Error)
+	}
+	if subreddit, _ = store.Subreddits.Get("golang"); subreddit.CreatorId != "alice" || !subreddit.permits("alice", messages.ModPermConfig) {
+		t.Errorf("creator = %q, moderators = %v, want alice to take over with all permissions", subreddit.CreatorId, subreddit.Moderators)
+	}
+}
@@ -0,0 +1,341 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/messages"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"gopkg.in/yaml.v3"
+)
+
+// autoModeratorName is who AutoModerator's removals, reports and replies are
+// from. Nobody can register it.
+const autoModeratorName = "AutoModerator"
+
+// maxAutoModRules caps how many rules a subreddit can have, since every rule
+// runs on every post and comment
+const maxAutoModRules = 100
+
+// linkPattern finds the host of every link in a title or body
+var linkPattern = regexp.MustCompile(`(?i)https?://([a-z0-9.-]+)`)
+
+// parseAutoModRules reads a YAML or JSON list of rules, JSON being YAML too,
+// and checks every rule can run
+func parseAutoModRules(document string) ([]*messages.AutoModRule, error) {
+	rules := make([]*messages.AutoModRule, 0)
+	if strings.TrimSpace(document) != "" {
+		if err := yaml.Unmarshal([]byte(document), &rules); err != nil {
+			return nil, fmt.Errorf("Rules must be a YAML or JSON list: %v", err)
+		}
+	}
+	if len(rules) > maxAutoModRules {
+		return nil, fmt.Errorf("At most %d rules are allowed", maxAutoModRules)
+	}
+
+	for i, rule := range rules {
+		if rule == nil {
+			return nil, fmt.Errorf("Rule %d is empty", i+1)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := checkAutoModRule(rule); err != nil {
+			return nil, fmt.Errorf("%s: %v", rule.Name, err)
+		}
+	}
+	return rules, nil
+}
+
+func checkAutoModRule(rule *messages.AutoModRule) error {
+	switch rule.Type {
+	case "", "post", "comment":
+	default:
+		return fmt.Errorf("unknown type %q, want post or comment", rule.Type)
+	}
+	switch rule.Action {
+	case "", messages.AutoModRemove, messages.AutoModFilter, messages.AutoModReport:
+	default:
+		return fmt.Errorf("unknown action %q, want remove, filter or report", rule.Action)
+	}
+	for _, pattern := range []string{rule.Title, rule.Body, rule.Flair} {
+		if _, err := compileRulePattern(pattern); err != nil {
+			return fmt.Errorf("bad pattern %q: %v", pattern, err)
+		}
+	}
+	if rule.Type == "comment" && (rule.Title != "" || rule.SetFlair != "") {
+		return fmt.Errorf("comment rules can't check the title or set flair")
+	}
+	if rule.Action == "" && rule.SetFlair == "" && rule.Reply == "" {
+		return fmt.Errorf("the rule does nothing; give it an action, setFlair or reply")
+	}
+	return nil
+}
+
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// autoModSubject is a post or comment as the rules see it
+type autoModSubject struct {
+	isPost bool
+	title  string // Empty for comments
+	body   string
+	flair  string // The post's, for comments too
+	author string
+}
+
+// rulePatterns are a rule's title, body and flair patterns, nil where the
+// rule has none
+type rulePatterns struct {
+	title, body, flair *regexp.Regexp
+}
+
+// compiledRules are a subreddit's rules with their patterns compiled
+type compiledRules struct {
+	rules    []*messages.AutoModRule // What they were compiled from
+	patterns []rulePatterns
+}
+
+// autoModCache keeps each subreddit's compiled patterns, so a rule's regexps
+// are compiled when the rules are set rather than on every post and comment.
+// Rules are replaced whole, so a different slice means they changed.
+type autoModCache struct {
+	mu         sync.Mutex
+	subreddits map[string]*compiledRules
+}
+
+func newAutoModCache() *autoModCache {
+	return &autoModCache{subreddits: make(map[string]*compiledRules)}
+}
+
+// compiled returns the subreddit's compiled rules, compiling them if they
+// changed or were loaded from storage since
+func (cache *autoModCache) compiled(subreddit *Subreddit) *compiledRules {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	rules := subreddit.AutoModRules
+	if cached, exists := cache.subreddits[subreddit.Name]; exists && sameRules(cached.rules, rules) {
+		return cached
+	}
+
+	compiled := &compiledRules{rules: rules, patterns: make([]rulePatterns, len(rules))}
+	for i, rule := range rules {
+		// Patterns were checked when the rules were set
+		compiled.patterns[i] = rulePatterns{
+			title: mustCompileRulePattern(rule.Title),
+			body:  mustCompileRulePattern(rule.Body),
+			flair: mustCompileRulePattern(rule.Flair),
+		}
+	}
+	cache.subreddits[subreddit.Name] = compiled
+	return compiled
+}
+
+func (cache *autoModCache) forget(subredditName string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	delete(cache.subreddits, subredditName)
+}
+
+func sameRules(a, b []*messages.AutoModRule) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// mustCompileRulePattern compiles a checked pattern; nil means no pattern
+func mustCompileRulePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+// autoModMatches returns the subreddit's rules that match, in order.
+// AutoModerator's own replies are never checked.
+func autoModMatches(store *Store, subredditName string, subject autoModSubject, now time.Time) []*messages.AutoModRule {
+	if subject.author == autoModeratorName {
+		return nil
+	}
+	subreddit, exists := store.Subreddits.Get(subredditName)
+	if !exists || len(subreddit.AutoModRules) == 0 {
+		return nil
+	}
+
+	compiled := store.autoMod.compiled(subreddit)
+	matched := make([]*messages.AutoModRule, 0)
+	for i, rule := range compiled.rules {
+		if ruleMatches(store, rule, compiled.patterns[i], subject, now) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+func ruleMatches(store *Store, rule *messages.AutoModRule, patterns rulePatterns, subject autoModSubject, now time.Time) bool {
+	if rule.Type == "post" && !subject.isPost || rule.Type == "comment" && subject.isPost {
+		return false
+	}
+	if !patternMatches(patterns.title, subject.title) ||
+		!patternMatches(patterns.body, subject.body) ||
+		!patternMatches(patterns.flair, subject.flair) {
+		return false
+	}
+	if len(rule.Domains) > 0 && !linksTo(subject.title+" "+subject.body, rule.Domains) {
+		return false
+	}
+	if rule.KarmaBelow != nil {
+		karma, _ := store.Karma.Get(subject.author)
+		total := 0
+		if karma != nil {
+			total = karma.PostKarma + karma.CommentKarma
+		}
+		if total >= *rule.KarmaBelow {
+			return false
+		}
+	}
+	if rule.AccountAgeBelowDays != nil {
+		// Accounts of unknown age are given the benefit of the doubt
+		profile, exists := store.Profiles.Get(subject.author)
+		if !exists || profile.CreatedAt == 0 {
+			return false
+		}
+		age := now.Sub(time.Unix(profile.CreatedAt, 0))
+		if age >= time.Duration(*rule.AccountAgeBelowDays)*24*time.Hour {
+			return false
+		}
+	}
+	return true
+}
+
+// patternMatches is true for a missing pattern
+func patternMatches(pattern *regexp.Regexp, text string) bool {
+	return pattern == nil || pattern.MatchString(text)
+}
+
+// linksTo reports whether the text links to any of the domains or their
+// subdomains
+func linksTo(text string, domains []string) bool {
+	for _, link := range linkPattern.FindAllStringSubmatch(text, -1) {
+		host := strings.TrimSuffix(strings.ToLower(link[1]), ".")
+		for _, domain := range domains {
+			domain = strings.ToLower(domain)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyAutoMod carries out the matched rules' actions on a post or comment
+// its owner is about to save, and returns the replies AutoModerator should
+// post. flair is nil for comments.
+func applyAutoMod(store *Store, subredditName string, subject autoModSubject, targetId string, moderation *Moderation, flair *string, matched []*messages.AutoModRule, now time.Time) []string {
+	replies := make([]string, 0)
+	for _, rule := range matched {
+		reason := rule.ActionReason
+		if reason == "" {
+			reason = rule.Name
+		}
+
+		switch rule.Action {
+		case messages.AutoModRemove, messages.AutoModFilter:
+			// Edits don't undo a moderator's approval
+			if moderation.Removed || moderation.Approved {
+				break
+			}
+			moderation.Removed = true
+			moderation.Filtered = rule.Action == messages.AutoModFilter
+			action := messages.ModLogRemoveComment
+			if subject.isPost {
+				action = messages.ModLogRemovePost
+			}
+			if moderation.Filtered {
+				reason = "filtered: " + reason
+			}
+			logModAction(store, subredditName, &ModAction{
+				Moderator:  autoModeratorName,
+				Action:     action,
+				TargetUser: subject.author,
+				TargetId:   targetId,
+				Details:    reason,
+			})
+		case messages.AutoModReport:
+			moderation.report(autoModeratorName, messages.ReportBreaksRules, reason, now)
+		}
+
+		if rule.SetFlair != "" && flair != nil {
+			*flair = rule.SetFlair
+		}
+		if rule.Reply != "" {
+			replies = append(replies, rule.Reply)
+		}
+	}
+	return replies
+}
+
+// sendAutoModReplies has AutoModerator answer a new post (parentId empty) or
+// comment through the engine
+func sendAutoModReplies(context actor.Context, postId, parentId string, replies []string) {
+	if context.Parent() == nil {
+		return
+	}
+	for _, reply := range replies {
+		context.Send(context.Parent(), &messages.CreateComment{
+			PostId:    postId,
+			ParentId:  parentId,
+			Content:   reply,
+			AuthorId:  autoModeratorName,
+			Automated: true,
+		})
+	}
+}
+
+// handleSetAutoModRules replaces the rules; moderators need the config
+// permission
+func (state *SubredditActor) handleSetAutoModRules(msg *messages.SetAutoModRules) *messages.SetAutoModRulesResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.SetAutoModRulesResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermConfig) {
+		return &messages.SetAutoModRulesResponse{Success: false, Error: "Not authorized to edit this subreddit"}
+	}
+	rules, err := parseAutoModRules(msg.Rules)
+	if err != nil {
+		return &messages.SetAutoModRulesResponse{Success: false, Error: err.Error()}
+	}
+
+	subreddit = subreddit.clone()
+	subreddit.AutoModRules = rules
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.SetAutoModRulesResponse{Success: false, Error: "Failed to save subreddit"}
+	}
+	state.store.autoMod.compiled(subreddit)
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator: msg.ModeratorId,
+		Action:    messages.ModLogEditSettings,
+		Details:   fmt.Sprintf("automod: %d rules", len(rules)),
+	})
+	return &messages.SetAutoModRulesResponse{Success: true, Count: len(rules)}
+}
+
+func (state *SubredditActor) handleGetAutoModRules(msg *messages.GetAutoModRules) *messages.GetAutoModRulesResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.GetAutoModRulesResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.isModerator(msg.ModeratorId) {
+		return &messages.GetAutoModRulesResponse{Success: false, Error: "Only moderators can see the rules"}
+	}
+	rules := subreddit.AutoModRules
+	if rules == nil {
+		rules = []*messages.AutoModRule{}
+	}
+	return &messages.GetAutoModRulesResponse{Success: true, Rules: rules}
+}
@@ -0,0 +1,116 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+	"time"
+)
+
+func TestAutoMod(t *testing.T) {
+	store := newTestStore(t)
+	state := NewSubredditActor(nil, store)
+	now := time.Now()
+	store.Profiles.Put("newbie", &UserProfile{CreatedAt: now.Add(-24 * time.Hour).Unix()})
+	store.Profiles.Put("veteran", &UserProfile{CreatedAt: now.Add(-400 * 24 * time.Hour).Unix()})
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Moderators["helper"] = []string{messages.ModPermUsers}
+	})
+
+	rules := `
+- name: no shorteners
+  domains: [bit.ly]
+  action: remove
+- name: new accounts
+  type: post
+  accountAgeBelowDays: 7
+  action: filter
+  setFlair: new
+- type: comment
+  body: "buy now"
+  action: report
+  reply: Please don't advertise.
+`
+	if response := state.handleSetAutoModRules(&messages.SetAutoModRules{SubredditName: "golang", ModeratorId: "helper", Rules: rules}); response.Success {
+		t.Fatalf("a moderator without the config permission set the rules")
+	}
+	for _, bad := range []string{"- action: explode", "- body: '('\n  action: remove", "- body: x", "name: not a list"} {
+		if response := state.handleSetAutoModRules(&messages.SetAutoModRules{SubredditName: "golang", ModeratorId: "owner", Rules: bad}); response.Success {
+			t.Errorf("rules %q were accepted", bad)
+		}
+	}
+	if response := state.handleSetAutoModRules(&messages.SetAutoModRules{SubredditName: "golang", ModeratorId: "owner", Rules: rules}); !response.Success || response.Count != 3 {
+		t.Fatalf("handleSetAutoModRules() = %+v, want 3 rules", response)
+	}
+	if response := state.handleGetAutoModRules(&messages.GetAutoModRules{SubredditName: "golang", ModeratorId: "helper"}); !response.Success || response.Rules[2].Name != "rule 3" {
+		t.Errorf("handleGetAutoModRules() = %+v, want the rules with rule 3 named", response)
+	}
+
+	check := func(subject autoModSubject) (Moderation, string, []string) {
+		var moderation Moderation
+		flair := ""
+		matched := autoModMatches(store, "golang", subject, now)
+		replies := applyAutoMod(store, "golang", subject, "id", &moderation, &flair, matched, now)
+		return moderation, flair, replies
+	}
+	if moderation, _, _ := check(autoModSubject{isPost: true, body: "see https://www.Bit.ly/x", author: "veteran"}); !moderation.Removed || moderation.Filtered {
+		t.Errorf("shortened link: %+v, want removed", moderation)
+	}
+	if moderation, _, _ := check(autoModSubject{isPost: true, body: "see https://notbit.ly/x", author: "veteran"}); moderation.Removed {
+		t.Errorf("notbit.ly was taken for bit.ly")
+	}
+	if moderation, flair, _ := check(autoModSubject{isPost: true, title: "hi", author: "newbie"}); !moderation.Filtered || flair != "new" || !moderation.inQueue(messages.QueueReported, true) {
+		t.Errorf("new account: %+v flair %q, want filtered into the queue with flair new", moderation, flair)
+	}
+	if moderation, _, _ := check(autoModSubject{isPost: true, title: "hi", author: "stranger"}); moderation.Removed {
+		t.Errorf("an account of unknown age was filtered")
+	}
+	moderation, _, replies := check(autoModSubject{body: "BUY NOW", author: "veteran"})
+	if moderation.Reports[autoModeratorName] == nil || len(replies) != 1 {
+		t.Errorf("advert: reports %+v replies %v, want reported with one reply", moderation.Reports, replies)
+	}
+	if matched := autoModMatches(store, "golang", autoModSubject{body: "buy now", author: autoModeratorName}, now); len(matched) != 0 {
+		t.Errorf("AutoModerator's own comment matched %d rules", len(matched))
+	}
+
+	log := state.handleGetModLog(&messages.GetModLog{SubredditName: "golang", UserId: "owner", Moderator: autoModeratorName})
+	if len(log.Entries) != 2 {
+		t.Errorf("AutoModerator's log = %+v, want the removal and the filter", log.Entries)
+	}
+}
+
+func TestAutoModEditsAreSilent(t *testing.T) {
+	store := newTestStore(t, "author")
+	ask := newTestEngine(t, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Members["author"] = true
+	})
+	rules := "- body: buy now\n  action: remove\n  reply: No ads."
+	if response := ask(&messages.SetAutoModRules{SubredditName: "golang", ModeratorId: "owner", Rules: rules}).(*messages.SetAutoModRulesResponse); !response.Success {
+		t.Fatalf("SetAutoModRules = %+v", response)
+	}
+	store.Posts.Put("post", &StoredPost{PostId: "post", SubredditName: "golang", AuthorId: "author", Content: "hello", Votes: map[string]bool{}})
+	store.Comments.Put("comment", &StoredComment{CommentId: "comment", PostId: "post", AuthorId: "author", Content: "hello", Votes: map[string]bool{}})
+	appendID(store.PostComments, "post", "comment")
+
+	if response := ask(&messages.EditPost{PostId: "post", AuthorId: "author", Content: "buy now"}).(*messages.EditPostResponse); !response.Success {
+		t.Fatalf("EditPost = %+v", response)
+	}
+	if response := ask(&messages.EditComment{CommentId: "comment", AuthorId: "author", Content: "BUY NOW"}).(*messages.EditCommentResponse); !response.Success {
+		t.Fatalf("EditComment = %+v", response)
+	}
+	// Any reply would reach the post's comment actor before this does
+	ask(&messages.ListPostComments{PostId: "post", UserId: "owner"})
+
+	if post, _ := store.Posts.Get("post"); !post.Removed {
+		t.Errorf("the edited post wasn't removed")
+	}
+	if comment, _ := store.Comments.Get("comment"); !comment.Removed {
+		t.Errorf("the edited comment wasn't removed")
+	}
+	store.Comments.Range(func(commentId string, comment *StoredComment) bool {
+		if comment.AuthorId == autoModeratorName {
+			t.Errorf("AutoModerator replied to an edit: %+v", comment)
+		}
+		return true
+	})
+}
@@ -0,0 +1,36 @@
+package actors
+
+import (
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// cascadeTimeout is how long an actor waits on each request it makes of
+// another actor through the engine
+const cascadeTimeout = 5 * time.Second
+
+// requestInTurn sends each request to the engine, one after another, and
+// hands every answer to handle. It never blocks the actor on its parent: the
+// actor goes on with its mailbox while it waits, so handle and done must
+// re-check anything they rely on. done runs once, with nil after the last
+// answer or with the first error; context.Respond in either still answers
+// the message that started the cascade.
+func requestInTurn(context actor.Context, requests []interface{}, handle func(request, response interface{}) error, done func(err error)) {
+	if len(requests) == 0 {
+		done(nil)
+		return
+	}
+
+	future := context.RequestFuture(context.Parent(), requests[0], cascadeTimeout)
+	context.ReenterAfter(future, func(response interface{}, err error) {
+		if err == nil {
+			err = handle(requests[0], response)
+		}
+		if err != nil {
+			done(err)
+			return
+		}
+		requestInTurn(context, requests[1:], handle, done)
+	})
+}
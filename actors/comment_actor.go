@@ -3,7 +3,6 @@ package actors
 import (
 	"reddit/messages"
 	"sort"
-	"sync"
 	"time"
 
 	"fmt"
@@ -20,20 +19,29 @@ type StoredComment struct {
 	AuthorId   string
 	Timestamp  int64
 	Votes     map[string]bool  // username -> isUpvote
+	Moderation
 }
 
-// Global shared state for all comment actors
-var (
-	globalComments = make(map[string]*StoredComment)
-	postComments = make(map[string][]string)     // PostId -> []CommentId
-	commentReplies = make(map[string][]string)   // ParentCommentId -> []CommentId
-	commentMutex sync.RWMutex
-)
+// clone copies the comment so its owner can change it without racing readers
+// that still hold the stored value
+func (comment *StoredComment) clone() *StoredComment {
+	copied := *comment
+	copied.Votes = make(map[string]bool, len(comment.Votes))
+	for userId, isUpvote := range comment.Votes {
+		copied.Votes[userId] = isUpvote
+	}
+	copied.Moderation = comment.Moderation.clone()
+	return &copied
+}
 
-type CommentActor struct {}
+type CommentActor struct {
+	store *Store
+}
 
-func NewCommentActor() *CommentActor {
-	return &CommentActor{}
+func NewCommentActor(store *Store) *CommentActor {
+	return &CommentActor{
+		store: store,
+	}
 }
 
 func (state *CommentActor) Receive(context actor.Context) {
@@ -43,8 +51,23 @@ func (state *CommentActor) Receive(context actor.Context) {
 		commentId := uuid.New().String()
 		
 		fmt.Printf("Creating comment: ParentId=%s, PostId=%s\n", msg.ParentId, msg.PostId)
+
+		// AutoModerator answers wherever its rules run
+		if !msg.Automated {
+			if err := state.checkParticipation(msg.PostId, msg.AuthorId, participateComment); err != nil {
+				response.Success = false
+				response.Error = err.Error()
+				context.Respond(response)
+				return
+			}
+		}
+		if err := state.checkParent(msg); err != nil {
+			response.Success = false
+			response.Error = err.Error()
+			context.Respond(response)
+			return
+		}
 		
-		commentMutex.Lock()
 		comment := &StoredComment{
 			CommentId:  commentId,
 			PostId:     msg.PostId,
@@ -54,16 +77,23 @@ func (state *CommentActor) Receive(context actor.Context) {
 			Timestamp:  time.Now().Unix(),
 			Votes:      make(map[string]bool),
 		}
-		globalComments[commentId] = comment
+		replies := make([]string, 0)
+		if !msg.Automated {
+			replies = state.autoModerate(comment)
+		}
+		state.store.Comments.Put(commentId, comment)
+		state.store.CommentIndex.Add(commentDocument(comment))
 		
 		if msg.ParentId == "" {
 			fmt.Printf("Adding top-level comment to post %s\n", msg.PostId)
-			postComments[msg.PostId] = append(postComments[msg.PostId], commentId)
+			appendID(state.store.PostComments, msg.PostId, commentId)
 		} else {
 			fmt.Printf("Adding reply to comment %s\n", msg.ParentId)
-			commentReplies[msg.ParentId] = append(commentReplies[msg.ParentId], commentId)
+			appendID(state.store.CommentReplies, msg.ParentId, commentId)
 		}
-		commentMutex.Unlock()
+		appendID(state.store.UserComments, msg.AuthorId, commentId)
+		sendAutoModReplies(context, msg.PostId, commentId, replies)
+		state.notifyReply(context, comment)
 		
 		response.Success = true
 		response.CommentId = commentId
@@ -72,17 +102,15 @@ func (state *CommentActor) Receive(context actor.Context) {
 
 	case *messages.ListPostComments:
 		fmt.Printf("\nListing comments for post: %s\n", msg.PostId)
-		fmt.Printf("Current postComments map: %+v\n", postComments)
-		fmt.Printf("Current commentReplies map: %+v\n", commentReplies)
-		fmt.Printf("Current globalComments map: %+v\n", globalComments)
-		
-		response := &messages.ListPostCommentsResponse{}
-		response.Comments = state.buildCommentTree(msg.PostId)
-		response.Success = true
+		response := state.handleListPostComments(msg)
+		context.Respond(response)
+
+	case *messages.ListCommentReplies:
+		response := state.handleListReplies(msg)
 		context.Respond(response)
 
 	case *messages.Vote:
-		response := state.handleVote(msg)
+		response := state.handleVote(context, msg)
 		context.Respond(response)
 
 	case *messages.EditComment:
@@ -90,60 +118,144 @@ func (state *CommentActor) Receive(context actor.Context) {
 		context.Respond(response)
 
 	case *messages.DeleteComment:
-		response := state.handleDelete(msg)
+		response := state.handleDelete(context, msg)
+		context.Respond(response)
+
+	case *messages.SearchComments:
+		response := state.handleSearch(msg)
+		context.Respond(response)
+
+	case *messages.GetUserComments:
+		response := state.handleUserComments(msg)
+		context.Respond(response)
+
+	case *messages.ReportComment:
+		response := state.handleReport(msg)
+		context.Respond(response)
+
+	case *messages.ModerateComment:
+		response := state.handleModerate(msg)
+		context.Respond(response)
+
+	case *messages.AnonymizeComment:
+		response := state.handleAnonymize(msg)
 		context.Respond(response)
 
 	case *messages.DeletePostComments:
 		response := &messages.DeletePostCommentsResponse{}
 		
-		commentMutex.Lock()
 		// Get all comments for this post
-		if comments, exists := postComments[msg.PostId]; exists {
+		if comments, exists := state.store.PostComments.Get(msg.PostId); exists {
 			// Delete each comment and its replies
 			for _, commentId := range comments {
-				state.deleteCommentRecursive(commentId)
+				state.deleteCommentRecursive(context, commentId, msg.Archive)
 			}
-			delete(postComments, msg.PostId)
+			state.store.PostComments.Delete(msg.PostId)
 			response.Success = true
 		} else {
 			response.Success = true  // No comments to delete is still a success
 		}
-		commentMutex.Unlock()
 		
 		context.Respond(response)
 	}
 }
 
-// Builds a nested comment tree
-func (state *CommentActor) buildCommentTree(postId string) []*messages.Comment {
-	commentMutex.RLock()
-	defer commentMutex.RUnlock()
+// defaultCommentDepth is how many levels of comments a listing shows when
+// no depth is asked for
+const defaultCommentDepth = 8
 
-	var result []*messages.Comment
-	
-	// Get top-level comments first
-	for _, commentId := range postComments[postId] {
-		if comment, exists := globalComments[commentId]; exists {
-			commentTree := state.buildCommentWithReplies(comment)
-			result = append(result, commentTree)
+// Replies shown under each comment when no number is asked for, and at most
+const (
+	defaultRepliesLimit = 10
+	maxRepliesLimit     = 50
+)
+
+// commentView is how a listing asked for its comments to be laid out
+type commentView struct {
+	sortBy     string
+	depth      int
+	limit      int    // Replies shown under each comment
+	postAuthor string // For qa
+	at         int64  // Ranking time of the listing, for the stubs' cursors
+}
+
+// key places a comment among its siblings
+func (state *CommentActor) key(view *commentView, comment *StoredComment) pageKey {
+	answered := false
+	if view.sortBy == SortQA {
+		replies, _ := state.store.CommentReplies.Get(comment.CommentId)
+		for _, replyId := range replies {
+			if reply, exists := state.store.Comments.Get(replyId); exists && reply.AuthorId == view.postAuthor {
+				answered = true
+				break
+			}
 		}
 	}
-	
-	return result
+	return commentKey(comment, view.sortBy, answered)
 }
 
-// Recursively builds a comment with its replies
-func (state *CommentActor) buildCommentWithReplies(stored *StoredComment) *messages.Comment {
-	// Calculate vote count
-	voteCount := 0
-	for _, isUpvote := range stored.Votes {
-		if isUpvote {
-			voteCount++
-		} else {
-			voteCount--
+// listComments lays out one page of sibling comments with their replies. The
+// top-level comments of a post are listed as "comments/<PostId>" and the
+// replies to a comment as "replies/<CommentId>", each per sort.
+func (state *CommentActor) listComments(listing string, commentIds []string, postId, sortBy string, depth, replies int, page messages.Page) ([]*messages.Comment, messages.PageLinks, error) {
+	sortBy, err := checkCommentSort(sortBy)
+	if err != nil {
+		return nil, messages.PageLinks{}, err
+	}
+	if depth < 0 {
+		return nil, messages.PageLinks{}, fmt.Errorf("Depth must not be negative")
+	}
+	if depth == 0 {
+		depth = defaultCommentDepth
+	}
+	if replies < 0 || replies > maxRepliesLimit {
+		return nil, messages.PageLinks{}, fmt.Errorf("Replies must be between 1 and %d", maxRepliesLimit)
+	}
+	if replies == 0 {
+		replies = defaultRepliesLimit
+	}
+	pager, err := newPager(listing+"/"+sortBy, page, time.Now().Unix())
+	if err != nil {
+		return nil, messages.PageLinks{}, err
+	}
+
+	view := &commentView{sortBy: sortBy, depth: depth, limit: replies, at: pager.at}
+	if post, exists := state.store.Posts.Get(postId); exists {
+		view.postAuthor = post.AuthorId
+	}
+
+	key := func(comment *StoredComment) pageKey { return state.key(view, comment) }
+	paged, links := paginate(pager, state.sortedComments(commentIds, view), key, commentOrder(sortBy))
+
+	result := make([]*messages.Comment, 0, len(paged))
+	for _, comment := range paged {
+		result = append(result, state.buildCommentWithReplies(comment, view, 1))
+	}
+	return result, links, nil
+}
+
+// sortedComments loads the comments and orders them for the view
+func (state *CommentActor) sortedComments(commentIds []string, view *commentView) []*StoredComment {
+	comments := make([]*StoredComment, 0, len(commentIds))
+	keys := make(map[string]pageKey, len(commentIds))
+	for _, commentId := range commentIds {
+		if comment, exists := state.store.Comments.Get(commentId); exists {
+			comments = append(comments, comment)
+			keys[commentId] = state.key(view, comment)
 		}
 	}
 
+	before := commentOrder(view.sortBy)
+	sort.Slice(comments, func(i, j int) bool {
+		return before(keys[comments[i].CommentId], keys[comments[j].CommentId])
+	})
+	return comments
+}
+
+// Recursively builds a comment with its replies, down to the view's depth.
+// Replies past the depth or the per-level limit are left as a MoreReplies stub.
+// Removed comments stay in place, as "[removed]", so their replies do too.
+func (state *CommentActor) buildCommentWithReplies(stored *StoredComment, view *commentView, level int) *messages.Comment {
 	comment := &messages.Comment{
 		CommentId:  stored.CommentId,
 		PostId:     stored.PostId,
@@ -152,54 +264,121 @@ func (state *CommentActor) buildCommentWithReplies(stored *StoredComment) *messa
 		AuthorId:   stored.AuthorId,
 		Timestamp:  stored.Timestamp,
 		Replies:    make([]*messages.Comment, 0),
-		VoteCount:  voteCount,
+		VoteCount:  calculateVotes(stored.Votes),
 	}
-	
-	// Add debug logs
-	fmt.Printf("Building comment tree for comment: %s\n", stored.CommentId)
-	
-	// Check if this comment has any replies
-	if replies, exists := commentReplies[stored.CommentId]; exists && len(replies) > 0 {
-		fmt.Printf("Found %d replies for comment %s\n", len(replies), stored.CommentId)
-		// Add replies recursively
-		for _, replyId := range replies {
-			if reply, exists := globalComments[replyId]; exists {
-				fmt.Printf("Adding reply %s to comment %s\n", replyId, stored.CommentId)
-				comment.Replies = append(comment.Replies, state.buildCommentWithReplies(reply))
-			}
+	if stored.Removed {
+		comment.Content = removedPlaceholder
+		comment.Removed = true
+	}
+
+	replyIds, _ := state.store.CommentReplies.Get(stored.CommentId)
+	if len(replyIds) == 0 {
+		return comment
+	}
+	if level >= view.depth {
+		fmt.Printf("Cutting off %d replies to comment %s at depth %d\n", len(replyIds), stored.CommentId, level)
+		comment.More = &messages.MoreReplies{
+			CommentId: stored.CommentId,
+			Count:     countComments(state.store, replyIds),
+		}
+		return comment
+	}
+
+	replies := state.sortedComments(replyIds, view)
+	shown := replies
+	if len(shown) > view.limit {
+		shown = shown[:view.limit]
+	}
+	for _, reply := range shown {
+		comment.Replies = append(comment.Replies, state.buildCommentWithReplies(reply, view, level+1))
+	}
+
+	if hidden := replies[len(shown):]; len(hidden) > 0 {
+		hiddenIds := make([]string, len(hidden))
+		for i, reply := range hidden {
+			hiddenIds[i] = reply.CommentId
+		}
+		comment.More = &messages.MoreReplies{
+			CommentId: stored.CommentId,
+			Count:     countComments(state.store, hiddenIds),
+		}
+		if len(shown) > 0 {
+			comment.More.After = encodeCursor(pageCursor{
+				Listing: "replies/" + stored.CommentId + "/" + view.sortBy,
+				At:      view.at,
+				Key:     state.key(view, shown[len(shown)-1]),
+			})
 		}
-	} else {
-		fmt.Printf("No replies found for comment %s\n", stored.CommentId)
 	}
-	
 	return comment
 }
 
-func (state *CommentActor) handleVote(msg *messages.Vote) *messages.VoteResponse {
-	commentMutex.Lock()
-	defer commentMutex.Unlock()
+// countComments counts the comments and all their replies
+func countComments(store *Store, commentIds []string) int {
+	count := 0
+	pending := append([]string(nil), commentIds...)
+	for len(pending) > 0 {
+		commentId := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		count++
+		if replies, exists := store.CommentReplies.Get(commentId); exists {
+			pending = append(pending, replies...)
+		}
+	}
+	return count
+}
 
+// threadComments loads the comments and all their replies
+func threadComments(store *Store, commentIds []string) []*StoredComment {
+	comments := make([]*StoredComment, 0, len(commentIds))
+	pending := append([]string(nil), commentIds...)
+	for len(pending) > 0 {
+		commentId := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if comment, exists := store.Comments.Get(commentId); exists {
+			comments = append(comments, comment)
+		}
+		replies, _ := store.CommentReplies.Get(commentId)
+		pending = append(pending, replies...)
+	}
+	return comments
+}
+
+func (state *CommentActor) handleVote(context actor.Context, msg *messages.Vote) *messages.VoteResponse {
 	fmt.Printf("Handling vote for comment %s by user %s (upvote: %v)\n", 
 		msg.TargetID, msg.UserID, msg.IsUpvote)
 
-	if comment, exists := globalComments[msg.TargetID]; exists {
-		if comment.Votes == nil {
-			comment.Votes = make(map[string]bool)
+	if comment, exists := state.store.Comments.Get(msg.TargetID); exists {
+		if err := state.checkParticipation(comment.PostId, msg.UserID, participateVote); err != nil {
+			return &messages.VoteResponse{Success: false, Error: err.Error()}
 		}
+		// Removed comments earn nothing more
+		if comment.Removed {
+			return &messages.VoteResponse{Success: false, Error: "Can't vote on a removed comment"}
+		}
+		comment = comment.clone()
 
 		// Handle vote change
+		change := 0
 		if previousVote, hasVoted := comment.Votes[msg.UserID]; hasVoted {
 			if previousVote == msg.IsUpvote {
 				fmt.Printf("Removing vote from user %s\n", msg.UserID)
 				delete(comment.Votes, msg.UserID)
+				change = -voteValue(previousVote)
 			} else {
 				fmt.Printf("Changing vote from user %s\n", msg.UserID)
 				comment.Votes[msg.UserID] = msg.IsUpvote
+				change = voteValue(msg.IsUpvote) - voteValue(previousVote)
 			}
 		} else {
 			fmt.Printf("Adding new vote from user %s\n", msg.UserID)
 			comment.Votes[msg.UserID] = msg.IsUpvote
+			change = voteValue(msg.IsUpvote)
+		}
+		if err := state.store.Comments.Put(comment.CommentId, comment); err != nil {
+			return &messages.VoteResponse{Success: false, Error: "Failed to save vote"}
 		}
+		sendKarmaUpdate(context, comment.AuthorId, "comment", change)
 
 		fmt.Printf("Current votes for comment %s: %+v\n", msg.TargetID, comment.Votes)
 		return &messages.VoteResponse{Success: true}
@@ -207,31 +386,10 @@ func (state *CommentActor) handleVote(msg *messages.Vote) *messages.VoteResponse
 	return &messages.VoteResponse{Success: false, Error: "Comment not found"}
 }
 
-func (state *CommentActor) sortComments(comments []*messages.Comment) {
-	sort.Slice(comments, func(i, j int) bool {
-		// Sort by vote count first
-		iVotes := len(globalComments[comments[i].CommentId].Votes)
-		jVotes := len(globalComments[comments[j].CommentId].Votes)
-		if iVotes != jVotes {
-			return iVotes > jVotes
-		}
-		// Then by timestamp
-		return comments[i].Timestamp > comments[j].Timestamp
-	})
-
-	// Sort replies recursively
-	for _, comment := range comments {
-		state.sortComments(comment.Replies)
-	}
-}
-
 func (state *CommentActor) handleEdit(msg *messages.EditComment) *messages.EditCommentResponse {
-	commentMutex.Lock()
-	defer commentMutex.Unlock()
-
 	fmt.Printf("Handling edit for comment %s by user %s\n", msg.CommentId, msg.AuthorId)
 
-	if comment, exists := globalComments[msg.CommentId]; exists {
+	if comment, exists := state.store.Comments.Get(msg.CommentId); exists {
 		// Verify ownership
 		if comment.AuthorId != msg.AuthorId {
 			return &messages.EditCommentResponse{
@@ -239,9 +397,22 @@ func (state *CommentActor) handleEdit(msg *messages.EditComment) *messages.EditC
 				Error:   "Not authorized to edit this comment",
 			}
 		}
+		if comment.Removed {
+			return &messages.EditCommentResponse{
+				Success: false,
+				Error:   "Can't edit a removed comment",
+			}
+		}
 
 		// Update content
+		comment = comment.clone()
 		comment.Content = msg.Content
+		// Checked again, silently; AutoModerator only answers new comments
+		state.autoModerate(comment)
+		if err := state.store.Comments.Put(comment.CommentId, comment); err != nil {
+			return &messages.EditCommentResponse{Success: false, Error: "Failed to save comment"}
+		}
+		state.store.CommentIndex.Add(commentDocument(comment))
 		fmt.Printf("Comment %s updated with new content\n", msg.CommentId)
 		
 		return &messages.EditCommentResponse{Success: true}
@@ -249,13 +420,27 @@ func (state *CommentActor) handleEdit(msg *messages.EditComment) *messages.EditC
 	return &messages.EditCommentResponse{Success: false, Error: "Comment not found"}
 }
 
-func (state *CommentActor) handleDelete(msg *messages.DeleteComment) *messages.DeleteCommentResponse {
+// autoModerate runs the subreddit's AutoModerator rules on a comment about to
+// be saved and returns the replies to post
+func (state *CommentActor) autoModerate(comment *StoredComment) []string {
+	post, exists := state.store.Posts.Get(comment.PostId)
+	if !exists {
+		return nil
+	}
+	subject := autoModSubject{
+		body:   comment.Content,
+		flair:  post.Flair,
+		author: comment.AuthorId,
+	}
+	now := time.Now()
+	matched := autoModMatches(state.store, post.SubredditName, subject, now)
+	return applyAutoMod(state.store, post.SubredditName, subject, comment.CommentId, &comment.Moderation, nil, matched, now)
+}
+
+func (state *CommentActor) handleDelete(context actor.Context, msg *messages.DeleteComment) *messages.DeleteCommentResponse {
 	fmt.Printf("CommentActor: Handling delete for comment %s by user %s\n", msg.CommentId, msg.AuthorId)
 	
-	commentMutex.Lock()
-	defer commentMutex.Unlock()
-
-	comment, exists := globalComments[msg.CommentId]
+	comment, exists := state.store.Comments.Get(msg.CommentId)
 	if !exists {
 		fmt.Printf("CommentActor: Comment %s not found\n", msg.CommentId)
 		return &messages.DeleteCommentResponse{
@@ -264,53 +449,197 @@ func (state *CommentActor) handleDelete(msg *messages.DeleteComment) *messages.D
 		}
 	}
 
-	// Verify ownership
-	if comment.AuthorId != msg.AuthorId {
+	// Verify ownership; moderators with the comments permission may remove
+	// anyone's comment
+	if comment.AuthorId != msg.AuthorId && !state.canModerate(comment, msg.AuthorId) {
 		return &messages.DeleteCommentResponse{
 			Success: false,
 			Error:   "Not authorized to delete this comment",
 		}
 	}
 
+	// A moderator removes someone else's comment instead, keeping everyone's
+	// replies under it
+	if comment.AuthorId != msg.AuthorId {
+		response := state.handleModerate(&messages.ModerateComment{CommentId: msg.CommentId, ModeratorId: msg.AuthorId, Action: messages.ModActionRemove})
+		return &messages.DeleteCommentResponse{Success: response.Success, Error: response.Error}
+	}
+
 	// Delete recursively
-	state.deleteCommentRecursive(msg.CommentId)
+	state.deleteCommentRecursive(context, msg.CommentId, false)
 
 	// Remove from parent's replies if it's a reply
 	if comment.ParentId != "" {
-		if replies, exists := commentReplies[comment.ParentId]; exists {
-			for i, replyId := range replies {
-				if replyId == msg.CommentId {
-					commentReplies[comment.ParentId] = append(replies[:i], replies[i+1:]...)
-					break
-				}
-			}
-		}
+		removeIDFrom(state.store.CommentReplies, comment.ParentId, msg.CommentId)
 	}
 
 	// Remove from post's comments if it's a top-level comment
 	if comment.ParentId == "" {
-		if comments, exists := postComments[comment.PostId]; exists {
-			for i, commentId := range comments {
-				if commentId == msg.CommentId {
-					postComments[comment.PostId] = append(comments[:i], comments[i+1:]...)
-					break
-				}
-			}
-		}
+		removeIDFrom(state.store.PostComments, comment.PostId, msg.CommentId)
 	}
 
 	return &messages.DeleteCommentResponse{Success: true}
 }
 
-func (state *CommentActor) deleteCommentRecursive(commentId string) {
+// checkParticipation checks the user against the subreddit the post is in.
+// Outsiders are told a post in a private subreddit isn't there, as GetPost
+// tells them.
+func (state *CommentActor) checkParticipation(postId, username string, action int) error {
+	post, exists := state.store.Posts.Get(postId)
+	if !exists || !canViewSubreddit(state.store, post.SubredditName, username) {
+		return fmt.Errorf("Post not found")
+	}
+	return checkSubredditParticipation(state.store, post.SubredditName, username, action)
+}
+
+// checkParent makes sure a reply answers a comment on the same post that is
+// still up. AutoModerator may answer a comment its own rules just removed.
+func (state *CommentActor) checkParent(msg *messages.CreateComment) error {
+	if msg.ParentId == "" {
+		return nil
+	}
+	parent, exists := state.store.Comments.Get(msg.ParentId)
+	if !exists || parent.PostId != msg.PostId {
+		return fmt.Errorf("Parent comment not found")
+	}
+	if parent.Removed && !msg.Automated {
+		return fmt.Errorf("Can't reply to a removed comment")
+	}
+	return nil
+}
+
+// canModerate reports whether username may remove comments in the subreddit
+// the comment was posted to
+func (state *CommentActor) canModerate(comment *StoredComment, username string) bool {
+	post, exists := state.store.Posts.Get(comment.PostId)
+	return exists && canModerate(state.store, post.SubredditName, username, messages.ModPermComments)
+}
+
+// deleteCommentRecursive deletes the comment and its replies, keeping a copy
+// of each in the archive if asked to
+func (state *CommentActor) deleteCommentRecursive(context actor.Context, commentId string, archive bool) {
 	// Delete all replies first
-	if replies, exists := commentReplies[commentId]; exists {
+	if replies, exists := state.store.CommentReplies.Get(commentId); exists {
 		for _, replyId := range replies {
-			state.deleteCommentRecursive(replyId)
+			state.deleteCommentRecursive(context, replyId, archive)
+		}
+		state.store.CommentReplies.Delete(commentId)
+	}
+
+	// Take back the karma the comment earned, then delete the comment itself
+	if comment, exists := state.store.Comments.Get(commentId); exists {
+		if archive {
+			state.store.ArchivedComments.Put(commentId, comment)
+		}
+		removeIDFrom(state.store.UserComments, comment.AuthorId, commentId)
+		sendKarmaUpdate(context, comment.AuthorId, "comment", -calculateVotes(comment.Votes))
+	}
+	state.store.Comments.Delete(commentId)
+	state.store.CommentIndex.Remove(commentId)
+}
+
+func (state *CommentActor) handleSearch(msg *messages.SearchComments) *messages.SearchCommentsResponse {
+	fmt.Printf("CommentActor: Searching for query: %s\n", msg.Query)
+
+	visible := func(commentId string) bool {
+		comment, exists := state.store.Comments.Get(commentId)
+		if !exists || comment.Removed {
+			return false
+		}
+		post, exists := state.store.Posts.Get(comment.PostId)
+		return !exists || canViewSubreddit(state.store, post.SubredditName, msg.UserId)
+	}
+	hits, links, err := searchIndex(state.store.CommentIndex, "search/comments", msg.Query, msg.Page, visible)
+	if err != nil {
+		return &messages.SearchCommentsResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	results := make([]*messages.Comment, 0, len(hits))
+	for _, hit := range hits {
+		if comment, exists := state.store.Comments.Get(hit.ID); exists {
+			results = append(results, &messages.Comment{
+				CommentId: comment.CommentId,
+				PostId:    comment.PostId,
+				ParentId:  comment.ParentId,
+				Content:   comment.Content,
+				AuthorId:  comment.AuthorId,
+				Timestamp: comment.Timestamp,
+				VoteCount: calculateVotes(comment.Votes),
+			})
+		}
+	}
+	return &messages.SearchCommentsResponse{
+		Success:   true,
+		Comments:  results,
+		PageLinks: links,
+	}
+}
+
+func (state *CommentActor) handleListPostComments(msg *messages.ListPostComments) *messages.ListPostCommentsResponse {
+	// Comments in private subreddits don't exist as far as outsiders know
+	if post, exists := state.store.Posts.Get(msg.PostId); exists && !canViewSubreddit(state.store, post.SubredditName, msg.UserId) {
+		return &messages.ListPostCommentsResponse{Success: false, Error: "Post not found"}
+	}
+
+	commentIds, _ := state.store.PostComments.Get(msg.PostId)
+	comments, links, err := state.listComments("comments/"+msg.PostId, commentIds, msg.PostId, msg.Sort, msg.Depth, msg.Replies, msg.Page)
+	if err != nil {
+		return &messages.ListPostCommentsResponse{Success: false, Error: err.Error()}
+	}
+	return &messages.ListPostCommentsResponse{Success: true, Comments: comments, PageLinks: links}
+}
+
+func (state *CommentActor) handleListReplies(msg *messages.ListCommentReplies) *messages.ListCommentRepliesResponse {
+	parent, exists := state.store.Comments.Get(msg.CommentId)
+	if !exists {
+		return &messages.ListCommentRepliesResponse{Success: false, Error: "Comment not found"}
+	}
+	if post, exists := state.store.Posts.Get(parent.PostId); exists && !canViewSubreddit(state.store, post.SubredditName, msg.UserId) {
+		return &messages.ListCommentRepliesResponse{Success: false, Error: "Comment not found"}
+	}
+
+	replyIds, _ := state.store.CommentReplies.Get(msg.CommentId)
+	comments, links, err := state.listComments("replies/"+msg.CommentId, replyIds, parent.PostId, msg.Sort, msg.Depth, msg.Replies, msg.Page)
+	if err != nil {
+		return &messages.ListCommentRepliesResponse{Success: false, Error: err.Error()}
+	}
+	return &messages.ListCommentRepliesResponse{Success: true, Comments: comments, PageLinks: links}
+}
+
+// notifyReply tells the author of the post or comment replied to, and anyone
+// mentioned, about a new comment. Removed comments tell nobody.
+func (state *CommentActor) notifyReply(context actor.Context, comment *StoredComment) {
+	if comment.Removed {
+		return
+	}
+	post, exists := state.store.Posts.Get(comment.PostId)
+	if !exists {
+		return
+	}
+
+	notification := messages.Notify{
+		From:          comment.AuthorId,
+		SubredditName: post.SubredditName,
+		PostId:        comment.PostId,
+		CommentId:     comment.CommentId,
+		Preview:       notificationPreview(comment.Content),
+	}
+	reply := notification
+	reply.Type = messages.NotifyPostReply
+	reply.Username = post.AuthorId
+	if comment.ParentId != "" {
+		parent, exists := state.store.Comments.Get(comment.ParentId)
+		if !exists {
+			return
 		}
-		delete(commentReplies, commentId)
+		reply.Type = messages.NotifyCommentReply
+		reply.Username = parent.AuthorId
 	}
+	sendNotification(context, &reply)
 
-	// Delete the comment itself
-	delete(globalComments, commentId)
+	// Being replied to already says as much as being mentioned
+	notifyMentions(context, state.store, comment.Content, map[string]bool{reply.Username: true}, notification)
 }
@@ -0,0 +1,181 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestListCommentsTruncates(t *testing.T) {
+	store := NewMemoryStore()
+	state := NewCommentActor(store)
+	store.Posts.Put("post", &StoredPost{PostId: "post", AuthorId: "op", Votes: map[string]bool{}})
+
+	add := func(id, parentId string, timestamp int64, ups int) {
+		votes := make(map[string]bool)
+		for i := 0; i < ups; i++ {
+			votes[string(rune('a'+i))] = true
+		}
+		store.Comments.Put(id, &StoredComment{CommentId: id, PostId: "post", ParentId: parentId, AuthorId: "someone", Timestamp: timestamp, Votes: votes})
+		if parentId == "" {
+			appendID(store.PostComments, "post", id)
+		} else {
+			appendID(store.CommentReplies, parentId, id)
+		}
+	}
+	add("root", "", 1, 0)
+	add("reply-1", "root", 2, 5)
+	add("reply-2", "root", 3, 1)
+	add("reply-3", "root", 4, 3)
+	add("nested", "reply-1", 5, 0)
+
+	commentIds, _ := store.PostComments.Get("post")
+	comments, _, err := state.listComments("comments/post", commentIds, "post", SortTop, 2, 2, messages.Page{Limit: 1})
+	if err != nil {
+		t.Fatalf("listComments() error = %v", err)
+	}
+	root := comments[0]
+	if len(root.Replies) != 2 || root.Replies[0].CommentId != "reply-1" || root.Replies[1].CommentId != "reply-3" {
+		t.Fatalf("root replies = %+v, want reply-1 then reply-3", root.Replies)
+	}
+	if root.More == nil || root.More.Count != 1 || root.More.After == "" {
+		t.Fatalf("root.More = %+v, want one more reply after a cursor", root.More)
+	}
+	if more := root.Replies[0].More; more == nil || more.Count != 1 || more.After != "" {
+		t.Fatalf("reply-1.More = %+v, want the nested reply cut off by depth", more)
+	}
+
+	replyIds, _ := store.CommentReplies.Get("root")
+	rest, links, err := state.listComments("replies/root", replyIds, "post", SortTop, 2, 2, messages.Page{After: root.More.After, Limit: 2})
+	if err != nil {
+		t.Fatalf("listComments(after) error = %v", err)
+	}
+	if len(rest) != 1 || rest[0].CommentId != "reply-2" || links.Next != "" {
+		t.Fatalf("expanded replies = %+v, want just reply-2", rest)
+	}
+
+	if _, _, err := state.listComments("comments/post", commentIds, "post", "loudest", 0, 0, messages.Page{}); err == nil {
+		t.Errorf("listComments() accepted an unknown sort")
+	}
+	if _, _, err := state.listComments("comments/post", commentIds, "post", SortTop, 0, maxRepliesLimit+1, messages.Page{}); err == nil {
+		t.Errorf("listComments() accepted %d replies per comment", maxRepliesLimit+1)
+	}
+}
+
+func TestListCommentsHidesPrivateSubreddits(t *testing.T) {
+	store := newTestStore(t)
+	state := NewCommentActor(store)
+	setupSubreddit(t, store, "secret", func(subreddit *Subreddit) {
+		subreddit.Type = messages.SubredditPrivate
+		subreddit.Members = map[string]bool{"owner": true, "member": true}
+	})
+	store.Posts.Put("post", &StoredPost{PostId: "post", SubredditName: "secret", AuthorId: "owner", Votes: map[string]bool{}})
+	store.Comments.Put("root", &StoredComment{CommentId: "root", PostId: "post", AuthorId: "member", Votes: map[string]bool{}})
+	appendID(store.PostComments, "post", "root")
+
+	if response := state.handleListPostComments(&messages.ListPostComments{PostId: "post", UserId: "outsider"}); response.Success {
+		t.Errorf("an outsider listed the comments: %+v", response)
+	}
+	if response := state.handleListReplies(&messages.ListCommentReplies{CommentId: "root", UserId: "outsider"}); response.Success {
+		t.Errorf("an outsider listed the replies: %+v", response)
+	}
+	if response := state.handleListPostComments(&messages.ListPostComments{PostId: "post", UserId: "member"}); !response.Success || len(response.Comments) != 1 {
+		t.Errorf("a member's listing = %+v, want the comment", response)
+	}
+}
+
+func TestPrivatePostsLookMissing(t *testing.T) {
+	store := newTestStore(t, "owner", "member", "outsider")
+	ask := newTestEngine(t, store)
+	setupSubreddit(t, store, "secret", func(subreddit *Subreddit) {
+		subreddit.Type = messages.SubredditPrivate
+		subreddit.Members = map[string]bool{"member": true}
+	})
+	store.Posts.Put("post", &StoredPost{PostId: "post", SubredditName: "secret", AuthorId: "member", Votes: map[string]bool{}})
+	store.Comments.Put("root", &StoredComment{CommentId: "root", PostId: "post", AuthorId: "member", Votes: map[string]bool{}})
+	appendID(store.PostComments, "post", "root")
+
+	// An outsider can't tell a hidden post from one that isn't there
+	tests := []struct {
+		name string
+		ask  func() string
+	}{
+		{"GetPost", func() string {
+			return ask(&messages.GetPost{PostId: "post", UserId: "outsider"}).(*messages.GetPostResponse).Error
+		}},
+		{"ReportPost", func() string {
+			return ask(&messages.ReportPost{PostId: "post", ReporterId: "outsider", Reason: messages.ReportSpam}).(*messages.ReportPostResponse).Error
+		}},
+		{"Post vote", func() string {
+			return ask(&messages.Vote{UserID: "outsider", TargetID: "post", IsUpvote: true, Type: "post"}).(*messages.VoteResponse).Error
+		}},
+		{"Comment vote", func() string {
+			return ask(&messages.Vote{UserID: "outsider", TargetID: "root", IsUpvote: true, Type: "comment"}).(*messages.VoteResponse).Error
+		}},
+		{"CreateComment", func() string {
+			return ask(&messages.CreateComment{PostId: "post", AuthorId: "outsider", Content: "hello"}).(*messages.CreateCommentResponse).Error
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.ask(); err != "Post not found" {
+				t.Errorf("error = %q, want %q", err, "Post not found")
+			}
+		})
+	}
+}
+
+func TestCreateCommentChecksParent(t *testing.T) {
+	store := NewMemoryStore()
+	state := NewCommentActor(store)
+	store.Posts.Put("post", &StoredPost{PostId: "post", AuthorId: "op", Votes: map[string]bool{}})
+	store.Posts.Put("other", &StoredPost{PostId: "other", AuthorId: "op", Votes: map[string]bool{}})
+	store.Comments.Put("root", &StoredComment{CommentId: "root", PostId: "post", AuthorId: "someone", Votes: map[string]bool{}})
+	store.Comments.Put("removed", &StoredComment{CommentId: "removed", PostId: "post", AuthorId: "someone", Votes: map[string]bool{}, Moderation: Moderation{Removed: true}})
+
+	tests := []struct {
+		name    string
+		msg     *messages.CreateComment
+		wantErr string
+	}{
+		{
+			name: "Top-level comment",
+			msg:  &messages.CreateComment{PostId: "post"},
+		},
+		{
+			name: "Reply on the same post",
+			msg:  &messages.CreateComment{PostId: "post", ParentId: "root"},
+		},
+		{
+			name:    "Missing parent",
+			msg:     &messages.CreateComment{PostId: "post", ParentId: "nobody"},
+			wantErr: "Parent comment not found",
+		},
+		{
+			name:    "Parent on another post",
+			msg:     &messages.CreateComment{PostId: "other", ParentId: "root"},
+			wantErr: "Parent comment not found",
+		},
+		{
+			name:    "Removed parent",
+			msg:     &messages.CreateComment{PostId: "post", ParentId: "removed"},
+			wantErr: "Can't reply to a removed comment",
+		},
+		{
+			name: "AutoModerator explaining a removal",
+			msg:  &messages.CreateComment{PostId: "post", ParentId: "removed", Automated: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := state.checkParent(tt.msg)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("checkParent() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("checkParent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
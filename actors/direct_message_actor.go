@@ -1,15 +1,262 @@
 package actors
 
 import (
+	"fmt"
+	"reddit/messages"
+	"time"
+
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
 )
 
-type DirectMessageActor struct {}
+type StoredDirectMessage struct {
+	MessageID          string
+	FromUserID         string
+	ToUserID           string
+	Content            string
+	Timestamp          int64
+	ParentID           string
+	ThreadID           string // MessageID of the thread's first message
+	Read               bool   // Read by the recipient
+	DeletedBySender    bool
+	DeletedByRecipient bool
+}
+
+type DirectMessageActor struct {
+	store *Store
+}
 
-func NewDirectMessageActor() *DirectMessageActor {
-	return &DirectMessageActor{}
+func NewDirectMessageActor(store *Store) *DirectMessageActor {
+	return &DirectMessageActor{
+		store: store,
+	}
 }
 
 func (state *DirectMessageActor) Receive(context actor.Context) {
-	// Implement direct message handling
+	switch msg := context.Message().(type) {
+	case *messages.SendDirectMessage:
+		response := state.handleSend(context, msg)
+		if response.Success {
+			response.ActorPID = context.Self()
+		}
+		context.Respond(response)
+
+	case *messages.GetUserMessages:
+		response := state.handleGetMessages(msg)
+		context.Respond(response)
+
+	case *messages.GetMessageThread:
+		response := state.handleGetThread(msg)
+		context.Respond(response)
+
+	case *messages.MarkMessageRead:
+		response := state.handleMarkRead(msg)
+		context.Respond(response)
+
+	case *messages.DeleteDirectMessage:
+		response := state.handleDelete(msg)
+		context.Respond(response)
+	}
+}
+
+func (state *DirectMessageActor) handleSend(context actor.Context, msg *messages.SendDirectMessage) *messages.SendDirectMessageResponse {
+	fmt.Printf("DirectMessageActor: Message from %s to %s\n", msg.FromUserID, msg.ToUserID)
+
+	if msg.Content == "" {
+		return &messages.SendDirectMessageResponse{Success: false, Error: "Message content is required"}
+	}
+
+	toUserID := msg.ToUserID
+	threadID := ""
+	if msg.ParentID != "" {
+		parent, exists := state.store.DirectMessages.Get(msg.ParentID)
+		if !exists {
+			return &messages.SendDirectMessageResponse{Success: false, Error: "Parent message not found"}
+		}
+
+		// Replies stay between the two people in the thread
+		var otherUserID string
+		switch msg.FromUserID {
+		case parent.FromUserID:
+			otherUserID = parent.ToUserID
+		case parent.ToUserID:
+			otherUserID = parent.FromUserID
+		default:
+			return &messages.SendDirectMessageResponse{Success: false, Error: "Not a participant in this conversation"}
+		}
+		if toUserID == "" {
+			toUserID = otherUserID
+		} else if toUserID != otherUserID {
+			return &messages.SendDirectMessageResponse{Success: false, Error: "Reply must go to the other participant"}
+		}
+		threadID = parent.ThreadID
+	}
+
+	if toUserID == "" {
+		return &messages.SendDirectMessageResponse{Success: false, Error: "Recipient is required"}
+	}
+	if toUserID == msg.FromUserID {
+		return &messages.SendDirectMessageResponse{Success: false, Error: "Cannot send a message to yourself"}
+	}
+
+	if !state.store.Users.Has(toUserID) {
+		return &messages.SendDirectMessageResponse{Success: false, Error: "Recipient not found"}
+	}
+
+	// The engine assigns the ID so it can route a new thread to its owner
+	messageID := msg.MessageID
+	if messageID == "" {
+		messageID = uuid.New().String()
+	}
+	if threadID == "" {
+		threadID = messageID
+	}
+
+	err := state.store.DirectMessages.Put(messageID, &StoredDirectMessage{
+		MessageID:  messageID,
+		FromUserID: msg.FromUserID,
+		ToUserID:   toUserID,
+		Content:    msg.Content,
+		Timestamp:  time.Now().Unix(),
+		ParentID:   msg.ParentID,
+		ThreadID:   threadID,
+	})
+	if err != nil {
+		return &messages.SendDirectMessageResponse{Success: false, Error: "Failed to save message"}
+	}
+	appendID(state.store.UserMessages, msg.FromUserID, messageID)
+	appendID(state.store.UserMessages, toUserID, messageID)
+	appendID(state.store.ThreadMessages, threadID, messageID)
+	sendNotification(context, &messages.Notify{
+		Username:  toUserID,
+		Type:      messages.NotifyMessage,
+		From:      msg.FromUserID,
+		MessageId: messageID,
+		Preview:   notificationPreview(msg.Content),
+	})
+
+	return &messages.SendDirectMessageResponse{
+		Success:   true,
+		MessageID: messageID,
+	}
+}
+
+func (state *DirectMessageActor) handleGetMessages(msg *messages.GetUserMessages) *messages.GetUserMessagesResponse {
+	if msg.Folder != "" && msg.Folder != "inbox" && msg.Folder != "sent" {
+		return &messages.GetUserMessagesResponse{Success: false, Error: "Folder must be inbox or sent"}
+	}
+
+	response := &messages.GetUserMessagesResponse{
+		Success:  true,
+		Messages: make([]messages.DirectMessage, 0),
+	}
+
+	// Newest first, like a mail client
+	ids, _ := state.store.UserMessages.Get(msg.UserID)
+	for i := len(ids) - 1; i >= 0; i-- {
+		stored, exists := state.store.DirectMessages.Get(ids[i])
+		if !exists || !isVisibleTo(stored, msg.UserID) {
+			continue
+		}
+
+		received := stored.ToUserID == msg.UserID
+		if received && !stored.Read {
+			response.UnreadCount++
+		}
+
+		if msg.Folder == "inbox" && !received || msg.Folder == "sent" && received {
+			continue
+		}
+		if msg.WithUserID != "" && stored.FromUserID != msg.WithUserID && stored.ToUserID != msg.WithUserID {
+			continue
+		}
+		response.Messages = append(response.Messages, toDirectMessage(stored))
+	}
+
+	return response
+}
+
+func (state *DirectMessageActor) handleGetThread(msg *messages.GetMessageThread) *messages.GetMessageThreadResponse {
+	stored, exists := state.store.DirectMessages.Get(msg.MessageID)
+	if !exists || !isVisibleTo(stored, msg.UserID) {
+		return &messages.GetMessageThreadResponse{Success: false, Error: "Message not found"}
+	}
+
+	response := &messages.GetMessageThreadResponse{
+		Success:  true,
+		ThreadID: stored.ThreadID,
+		Messages: make([]messages.DirectMessage, 0),
+	}
+	threadIds, _ := state.store.ThreadMessages.Get(stored.ThreadID)
+	for _, messageID := range threadIds {
+		if threadMessage, exists := state.store.DirectMessages.Get(messageID); exists && isVisibleTo(threadMessage, msg.UserID) {
+			response.Messages = append(response.Messages, toDirectMessage(threadMessage))
+		}
+	}
+
+	return response
+}
+
+func (state *DirectMessageActor) handleMarkRead(msg *messages.MarkMessageRead) *messages.MarkMessageReadResponse {
+	stored, exists := state.store.DirectMessages.Get(msg.MessageID)
+	if !exists || !isVisibleTo(stored, msg.UserID) {
+		return &messages.MarkMessageReadResponse{Success: false, Error: "Message not found"}
+	}
+	if stored.ToUserID != msg.UserID {
+		return &messages.MarkMessageReadResponse{Success: false, Error: "Only the recipient can mark a message read"}
+	}
+
+	updated := *stored
+	updated.Read = msg.Read
+	if err := state.store.DirectMessages.Put(updated.MessageID, &updated); err != nil {
+		return &messages.MarkMessageReadResponse{Success: false, Error: "Failed to save message"}
+	}
+	return &messages.MarkMessageReadResponse{Success: true}
+}
+
+func (state *DirectMessageActor) handleDelete(msg *messages.DeleteDirectMessage) *messages.DeleteDirectMessageResponse {
+	current, exists := state.store.DirectMessages.Get(msg.MessageID)
+	if !exists || !isVisibleTo(current, msg.UserID) {
+		return &messages.DeleteDirectMessageResponse{Success: false, Error: "Message not found"}
+	}
+	stored := *current
+
+	// Deleting only hides the message for one side; the other keeps their copy
+	if stored.FromUserID == msg.UserID {
+		stored.DeletedBySender = true
+		removeIDFrom(state.store.UserMessages, stored.FromUserID, stored.MessageID)
+	}
+	if stored.ToUserID == msg.UserID {
+		stored.DeletedByRecipient = true
+		removeIDFrom(state.store.UserMessages, stored.ToUserID, stored.MessageID)
+	}
+
+	// Once neither side can see it, it can go for good. The thread keeps
+	// working because replies find it through ThreadID, not ParentID.
+	if stored.DeletedBySender && stored.DeletedByRecipient {
+		state.store.DirectMessages.Delete(stored.MessageID)
+		removeIDFrom(state.store.ThreadMessages, stored.ThreadID, stored.MessageID)
+	} else if err := state.store.DirectMessages.Put(stored.MessageID, &stored); err != nil {
+		return &messages.DeleteDirectMessageResponse{Success: false, Error: "Failed to save message"}
+	}
+
+	return &messages.DeleteDirectMessageResponse{Success: true}
+}
+
+func isVisibleTo(stored *StoredDirectMessage, userID string) bool {
+	return stored.FromUserID == userID && !stored.DeletedBySender ||
+		stored.ToUserID == userID && !stored.DeletedByRecipient
+}
+
+func toDirectMessage(stored *StoredDirectMessage) messages.DirectMessage {
+	return messages.DirectMessage{
+		MessageID:  stored.MessageID,
+		FromUserID: stored.FromUserID,
+		ToUserID:   stored.ToUserID,
+		Content:    stored.Content,
+		Timestamp:  stored.Timestamp,
+		ParentID:   stored.ParentID,
+		ThreadID:   stored.ThreadID,
+		Read:       stored.Read,
+	}
 }
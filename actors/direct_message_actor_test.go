@@ -0,0 +1,68 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestDirectMessageVisibility(t *testing.T) {
+	store := newTestStore(t, "alice", "bob", "carol")
+	ask := newTestEngine(t, store)
+
+	first := ask(&messages.SendDirectMessage{FromUserID: "alice", ToUserID: "bob", Content: "hi bob"}).(*messages.SendDirectMessageResponse)
+	reply := ask(&messages.SendDirectMessage{FromUserID: "bob", ParentID: first.MessageID, Content: "hi alice"}).(*messages.SendDirectMessageResponse)
+	if !first.Success || !reply.Success {
+		t.Fatalf("sending = %+v, %+v", first, reply)
+	}
+
+	// Alice deletes her first message; only her copy goes
+	if response := ask(&messages.DeleteDirectMessage{MessageID: first.MessageID, UserID: "alice"}).(*messages.DeleteDirectMessageResponse); !response.Success {
+		t.Fatalf("DeleteDirectMessage = %+v", response)
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		wantList   int  // Messages in their mailbox
+		wantThread int  // Messages in the thread, -1 if they can't open it
+		wantFirst  bool // Can still open the deleted message
+	}{
+		{name: "Sender after deleting", userID: "alice", wantList: 1, wantThread: 1},
+		{name: "Recipient keeps their copy", userID: "bob", wantList: 2, wantThread: 2, wantFirst: true},
+		{name: "Third party", userID: "carol", wantList: 0, wantThread: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := ask(&messages.GetUserMessages{UserID: tt.userID}).(*messages.GetUserMessagesResponse)
+			if len(list.Messages) != tt.wantList {
+				t.Errorf("GetUserMessages = %d messages, want %d", len(list.Messages), tt.wantList)
+			}
+
+			thread := ask(&messages.GetMessageThread{MessageID: reply.MessageID, UserID: tt.userID}).(*messages.GetMessageThreadResponse)
+			if tt.wantThread < 0 {
+				if thread.Success {
+					t.Errorf("GetMessageThread = %+v, want it refused", thread)
+				}
+			} else if !thread.Success || len(thread.Messages) != tt.wantThread {
+				t.Errorf("GetMessageThread = %+v, want %d messages", thread, tt.wantThread)
+			}
+
+			opened := ask(&messages.GetMessageThread{MessageID: first.MessageID, UserID: tt.userID}).(*messages.GetMessageThreadResponse)
+			if opened.Success != tt.wantFirst {
+				t.Errorf("opening the deleted message = %v, want %v", opened.Success, tt.wantFirst)
+			}
+		})
+	}
+
+	// A third party can't join, mark or delete anything in the thread
+	if response := ask(&messages.SendDirectMessage{FromUserID: "carol", ParentID: reply.MessageID, Content: "me too"}).(*messages.SendDirectMessageResponse); response.Success {
+		t.Errorf("a third party replied: %+v", response)
+	}
+	if response := ask(&messages.MarkMessageRead{MessageID: reply.MessageID, UserID: "carol", Read: true}).(*messages.MarkMessageReadResponse); response.Success {
+		t.Errorf("a third party marked a message read: %+v", response)
+	}
+	if response := ask(&messages.DeleteDirectMessage{MessageID: reply.MessageID, UserID: "carol"}).(*messages.DeleteDirectMessageResponse); response.Success {
+		t.Errorf("a third party deleted a message: %+v", response)
+	}
+}
@@ -0,0 +1,109 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/mail"
+	"reddit/messages"
+	"time"
+)
+
+// How long mailed tokens work for
+const (
+	verifyTokenTTL = 48 * time.Hour
+	resetTokenTTL  = time.Hour
+)
+
+// sendVerification mails a token confirming email belongs to the user. A
+// failure is only logged; the user can ask for another.
+func (state *UserActor) sendVerification(username, email string) error {
+	token, err := issueMailedToken(state.store, username, tokenKindVerify, email, verifyTokenTTL, time.Now())
+	if err == nil {
+		err = state.store.mailer.Send(mail.Message{
+			To:      email,
+			Subject: "Verify your email",
+			Body: fmt.Sprintf("Hi %s,\n\nConfirm this is your email by sending this token to POST /verify-email:\n\n%s\n\nIt works for %v.",
+				username, token, verifyTokenTTL),
+		})
+	}
+	if err != nil {
+		fmt.Printf("UserActor: Failed to send verification to %s: %v\n", username, err)
+	}
+	return err
+}
+
+func (state *UserActor) handleVerifyEmail(msg *messages.VerifyEmail) *messages.VerifyEmailResponse {
+	record, err := lookupToken(state.store, msg.Token, tokenKindVerify, time.Now())
+	if err != nil {
+		return &messages.VerifyEmailResponse{Success: false, Error: err.Error()}
+	}
+	profile, exists := state.store.Profiles.Get(record.Username)
+	if !exists || profile.Email != record.Email {
+		return &messages.VerifyEmailResponse{Success: false, Error: "The email has changed since this token was sent"}
+	}
+
+	if err := state.updateProfile(record.Username, func(profile *UserProfile) { profile.EmailVerified = true }); err != nil {
+		return &messages.VerifyEmailResponse{Success: false, Error: "Failed to save profile"}
+	}
+	revokeTokens(state.store, record.Username, func(other *authToken) bool { return other.Kind == tokenKindVerify })
+	return &messages.VerifyEmailResponse{Success: true}
+}
+
+func (state *UserActor) handleResendVerification(msg *messages.ResendVerification) *messages.ResendVerificationResponse {
+	profile, exists := state.store.Profiles.Get(msg.Username)
+	if !exists || profile.Email == "" {
+		return &messages.ResendVerificationResponse{Success: false, Error: "No email to verify"}
+	}
+	if profile.EmailVerified {
+		return &messages.ResendVerificationResponse{Success: false, Error: "Email is already verified"}
+	}
+	if err := state.sendVerification(msg.Username, profile.Email); err != nil {
+		return &messages.ResendVerificationResponse{Success: false, Error: "Failed to send email"}
+	}
+	return &messages.ResendVerificationResponse{Success: true}
+}
+
+// handleRequestPasswordReset only mails verified addresses, so a mistyped
+// email can't be used to take the account over
+func (state *UserActor) handleRequestPasswordReset(msg *messages.RequestPasswordReset) *messages.RequestPasswordResetResponse {
+	profile, exists := state.store.Profiles.Get(msg.Username)
+	if !exists || !state.store.Users.Has(msg.Username) || profile.Email == "" || !profile.EmailVerified {
+		fmt.Printf("UserActor: No verified email to reset %s's password\n", msg.Username)
+		return &messages.RequestPasswordResetResponse{Success: true}
+	}
+
+	token, err := issueMailedToken(state.store, msg.Username, tokenKindReset, profile.Email, resetTokenTTL, time.Now())
+	if err == nil {
+		err = state.store.mailer.Send(mail.Message{
+			To:      profile.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your password. If it was you, send this token and a new password to POST /reset-password:\n\n%s\n\nIt works once, for %v. If it wasn't you, ignore this email.",
+				msg.Username, token, resetTokenTTL),
+		})
+	}
+	if err != nil {
+		return &messages.RequestPasswordResetResponse{Success: false, Error: "Failed to send email"}
+	}
+	return &messages.RequestPasswordResetResponse{Success: true}
+}
+
+func (state *UserActor) handleResetPassword(msg *messages.ResetPassword) *messages.ResetPasswordResponse {
+	record, err := lookupToken(state.store, msg.Token, tokenKindReset, time.Now())
+	if err != nil {
+		return &messages.ResetPasswordResponse{Success: false, Error: err.Error()}
+	}
+	if profile, exists := state.store.Profiles.Get(record.Username); !exists || profile.Email != record.Email {
+		return &messages.ResetPasswordResponse{Success: false, Error: "The email has changed since this token was sent"}
+	}
+
+	passwordHash, err := hashPassword(msg.NewPassword)
+	if err != nil {
+		return &messages.ResetPasswordResponse{Success: false, Error: err.Error()}
+	}
+	if err := state.store.Users.Put(record.Username, passwordHash); err != nil {
+		return &messages.ResetPasswordResponse{Success: false, Error: "Failed to save password"}
+	}
+
+	// Spends this token along with every session the old password opened
+	revokeTokens(state.store, record.Username, func(other *authToken) bool { return other.Kind != tokenKindVerify })
+	return &messages.ResetPasswordResponse{Success: true}
+}
@@ -0,0 +1,79 @@
+package actors
+
+import (
+	"reddit/mail"
+	"reddit/messages"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// outbox keeps what it is sent, for tests
+type outbox struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (box *outbox) Send(message mail.Message) error {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	box.sent = append(box.sent, message)
+	return nil
+}
+
+// lastToken reads the token out of the last message sent
+func (box *outbox) lastToken(t *testing.T) string {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	if len(box.sent) == 0 {
+		t.Fatalf("no mail was sent")
+	}
+	for _, line := range strings.Split(box.sent[len(box.sent)-1].Body, "\n") {
+		if strings.Contains(line, ".") && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+	t.Fatalf("no token in %q", box.sent[len(box.sent)-1].Body)
+	return ""
+}
+
+func TestPasswordReset(t *testing.T) {
+	store := NewMemoryStore()
+	box := &outbox{}
+	store.SetMailer(box)
+	state := NewUserActor(store)
+	passwordHash, _ := hashPassword("old")
+	store.Users.Put("gopher", passwordHash)
+	store.Profiles.Put("gopher", &UserProfile{Email: "g@example.com"})
+
+	// Unverified addresses get nothing
+	state.handleRequestPasswordReset(&messages.RequestPasswordReset{Username: "gopher"})
+	if len(box.sent) != 0 {
+		t.Fatalf("a reset was mailed to an unverified address")
+	}
+
+	state.handleResendVerification(&messages.ResendVerification{Username: "gopher"})
+	if response := state.handleVerifyEmail(&messages.VerifyEmail{Token: box.lastToken(t)}); !response.Success {
+		t.Fatalf("handleVerifyEmail() = %+v", response)
+	}
+
+	state.handleRequestPasswordReset(&messages.RequestPasswordReset{Username: "gopher"})
+	token := box.lastToken(t)
+	if box.sent[len(box.sent)-1].To != "g@example.com" {
+		t.Errorf("reset mailed to %q", box.sent[len(box.sent)-1].To)
+	}
+	if response := state.handleVerifyEmail(&messages.VerifyEmail{Token: token}); response.Success {
+		t.Errorf("a reset token verified the email")
+	}
+	if response := state.handleResetPassword(&messages.ResetPassword{Token: token, NewPassword: "new"}); !response.Success {
+		t.Fatalf("handleResetPassword() = %+v", response)
+	}
+	if response := state.handleResetPassword(&messages.ResetPassword{Token: token, NewPassword: "again"}); response.Success {
+		t.Errorf("a reset token worked twice")
+	}
+
+	stored, _ := store.Users.Get("gopher")
+	if match, _ := checkPassword(stored, "new"); !match {
+		t.Errorf("the new password doesn't match")
+	}
+}
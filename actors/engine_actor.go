@@ -3,101 +3,153 @@ package actors
 import (
 	"fmt"
 	"reddit/messages"
-	"time"
+	"reflect"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/router"
+	"github.com/google/uuid"
 )
 
 type EngineActor struct {
-	// Single actors for direct routing
-	userPID      *actor.PID
-	postPID      *actor.PID
-	subredditPID *actor.PID
-	commentPID   *actor.PID
-	system       *actor.ActorSystem
-
-	// Actor pools for load balancing
-	userActors           []*actor.PID
-	postActors           []*actor.PID
-	subredditActors      []*actor.PID
-	directMessageActors  []*actor.PID
-	commentActors        []*actor.PID
-	currentUserActor     int
-	currentCommentActor  int
+	system *actor.ActorSystem
+	store  *Store
+	config *EngineConfig
+
+	// Actor pools, by pool name
+	pools map[string]*actorPool
+}
+
+// actorPool is a set of identical actors plus one router group per strategy
+// for picking among them
+type actorPool struct {
+	actors  []*actor.PID
+	routers map[string]*actor.PID // strategy -> router group
 }
 
-func NewEngineActor(system *actor.ActorSystem) *EngineActor {
+func NewEngineActor(system *actor.ActorSystem, store *Store, config *EngineConfig) *EngineActor {
 	engine := &EngineActor{
-		system:              system,
-		userActors:         make([]*actor.PID, 0),
-		postActors:         make([]*actor.PID, 0),
-		subredditActors:    make([]*actor.PID, 0),
-		directMessageActors: make([]*actor.PID, 0),
-		commentActors:      make([]*actor.PID, 0),
-		currentUserActor:   0,
-		currentCommentActor: 0,
+		system: system,
+		store:  store,
+		config: config,
+		pools:  make(map[string]*actorPool),
 	}
 
-	// Create main actors
-	props := actor.PropsFromProducer(func() actor.Actor { return NewUserActor() })
-	engine.userPID = system.Root.Spawn(props)
+	return engine
+}
 
-	props = actor.PropsFromProducer(func() actor.Actor { return NewSubredditActor(system) })
-	engine.subredditPID = system.Root.Spawn(props)
+// spawnActors creates the engine's actors as children, so they can reach the
+// engine through context.Parent() for follow-up messages like karma updates
+func (state *EngineActor) spawnActors(context actor.Context) {
+	system := state.system
+	store := state.store
+
+	producers := map[string]actor.Producer{
+		PoolUser:          func() actor.Actor { return NewUserActor(store) },
+		PoolPost:          func() actor.Actor { return NewPostActor(system, store) },
+		PoolSubreddit:     func() actor.Actor { return NewSubredditActor(system, store) },
+		PoolComment:       func() actor.Actor { return NewCommentActor(store) },
+		PoolDirectMessage: func() actor.Actor { return NewDirectMessageActor(store) },
+		PoolNotification:  func() actor.Actor { return NewNotificationActor(store) },
+	}
 
-	props = actor.PropsFromProducer(func() actor.Actor { return NewPostActor(system) })
-	engine.postPID = system.Root.Spawn(props)
+	// Create actor pools
+	for name, producer := range producers {
+		pool := &actorPool{
+			actors:  make([]*actor.PID, 0, state.config.PoolSizes[name]),
+			routers: make(map[string]*actor.PID),
+		}
+		for i := 0; i < state.config.PoolSizes[name]; i++ {
+			pool.actors = append(pool.actors, context.Spawn(actor.PropsFromProducer(producer)))
+		}
 
-	props = actor.PropsFromProducer(func() actor.Actor { return NewCommentActor() })
-	engine.commentPID = system.Root.Spawn(props)
+		pool.routers[RouteRoundRobin] = context.Spawn(router.NewRoundRobinGroup(pool.actors...))
+		pool.routers[RouteRandom] = context.Spawn(router.NewRandomGroup(pool.actors...))
+		pool.routers[RouteConsistentHash] = context.Spawn(router.NewConsistentHashGroup(pool.actors...))
+		pool.routers[RouteBroadcast] = context.Spawn(router.NewBroadcastGroup(pool.actors...))
 
-	// Create actor pools
-	for i := 0; i < 10; i++ {
-		// Create subreddit actor
-		subredditProps := actor.PropsFromProducer(func() actor.Actor { return NewSubredditActor(system) })
-		subredditPID := system.Root.Spawn(subredditProps)
-		engine.subredditActors = append(engine.subredditActors, subredditPID)
-
-		// Create user actor
-		userProps := actor.PropsFromProducer(func() actor.Actor { return NewUserActor() })
-		userPID := system.Root.Spawn(userProps)
-		engine.userActors = append(engine.userActors, userPID)
-
-		// Create post actor
-		postProps := actor.PropsFromProducer(func() actor.Actor { return NewPostActor(system) })
-		postPID := system.Root.Spawn(postProps)
-		engine.postActors = append(engine.postActors, postPID)
-
-		// Create direct message actor
-		dmProps := actor.PropsFromProducer(func() actor.Actor { return NewDirectMessageActor() })
-		dmPID := system.Root.Spawn(dmProps)
-		engine.directMessageActors = append(engine.directMessageActors, dmPID)
-
-		// Create comment actor
-		commentProps := actor.PropsFromProducer(func() actor.Actor { return NewCommentActor() })
-		commentPID := system.Root.Spawn(commentProps)
-		engine.commentActors = append(engine.commentActors, commentPID)
+		state.pools[name] = pool
+		fmt.Printf("Engine: Spawned %d %s actors\n", len(pool.actors), name)
 	}
+}
 
-	return engine
+// router returns the router group the configuration picks for a message
+func (state *EngineActor) router(msg interface{}) *actor.PID {
+	name := routeName(msg)
+	route := messageRoutes[name]
+	return state.pools[route.pool].routers[state.config.Routing[name]]
+}
+
+// routeName is the name a message is configured by, its type name except for
+// votes and autocomplete, which are split by target
+func routeName(msg interface{}) string {
+	if vote, ok := msg.(*messages.Vote); ok {
+		if vote.Type == "comment" {
+			return "CommentVote"
+		}
+		return "PostVote"
+	}
+	if complete, ok := msg.(*messages.Autocomplete); ok {
+		if complete.Type == "user" {
+			return "UserAutocomplete"
+		}
+		return "SubredditAutocomplete"
+	}
+	return reflect.TypeOf(msg).Elem().Name()
+}
+
+// commentPostID finds the post a comment belongs to, which decides the
+// comment's owner. Unknown comments are routed by their own ID, so the
+// caller still gets "not found".
+func (state *EngineActor) commentPostID(commentId string) string {
+	if comment, exists := state.store.Comments.Get(commentId); exists {
+		return comment.PostId
+	}
+	return commentId
+}
+
+// messageThreadID finds the thread a direct message belongs to
+func (state *EngineActor) messageThreadID(messageID string) string {
+	if message, exists := state.store.DirectMessages.Get(messageID); exists {
+		return message.ThreadID
+	}
+	return messageID
 }
 
 func (state *EngineActor) Receive(context actor.Context) {
 	switch msg := context.Message().(type) {
 	case *actor.Started:
 		fmt.Printf("Engine Actor started\n")
+		state.spawnActors(context)
 
 	case *messages.RegisterUser:
-		// Forward to next user actor in round-robin fashion
-		userActor := state.userActors[state.currentUserActor]
-		state.currentUserActor = (state.currentUserActor + 1) % len(state.userActors)
-		context.RequestWithCustomSender(userActor, msg, context.Sender())
+		// Forward to the actor owning this username
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 
 	case *messages.LoginUser:
 		if msg.ActorPID == nil {
-			userActor := state.userActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.userActors)
-			context.RequestWithCustomSender(userActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.RefreshToken:
+		if msg.ActorPID == nil {
+			msg.Username = tokenUsername(msg.RefreshToken)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.Logout:
+		if msg.ActorPID == nil {
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.ChangePassword:
+		if msg.ActorPID == nil {
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -105,19 +157,14 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.CreateSubreddit:
 		fmt.Printf("Engine: Received CreateSubreddit request for %s\n", msg.Name)
 		if msg.ActorPID == nil {
-			subredditActor := state.subredditActors[state.currentUserActor]
-			fmt.Printf("Engine: Routing to subreddit actor %v\n", subredditActor)
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.subredditActors)
-			context.RequestWithCustomSender(subredditActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
 	case *messages.JoinSubreddit:
 		if msg.ActorPID == nil {
-			subredditActor := state.subredditActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.subredditActors)
-			context.RequestWithCustomSender(subredditActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -125,28 +172,25 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.GetSubreddits:
 		fmt.Printf("Engine: Received GetSubreddits request\n")
 		if msg.ActorPID == nil {
-			subredditActor := state.subredditActors[state.currentUserActor]
-			fmt.Printf("Engine: Routing GetSubreddits to actor %v\n", subredditActor)
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.subredditActors)
-			context.RequestWithCustomSender(subredditActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
 	case *messages.LeaveSubreddit:
 		if msg.ActorPID == nil {
-			subredditActor := state.subredditActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.subredditActors)
-			context.RequestWithCustomSender(subredditActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
 	case *messages.Post:
+		// The ID decides the owner, so assign it before routing
+		if msg.PostId == "" {
+			msg.PostId = newPostID()
+		}
 		if msg.ActorPID == nil {
-			postActor := state.postActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.postActors)
-			context.RequestWithCustomSender(postActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -154,9 +198,7 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.CreateComment:
 		fmt.Printf("Engine: Received CreateComment request\n")
 		if msg.ActorPID == nil {
-			commentActor := state.commentActors[state.currentCommentActor]
-			state.currentCommentActor = (state.currentCommentActor + 1) % len(state.commentActors)
-			context.RequestWithCustomSender(commentActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -164,56 +206,113 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.ListPostComments:
 		fmt.Printf("Engine: Received ListPostComments request for post %s\n", msg.PostId)
 		if msg.ActorPID == nil {
-			commentActor := state.commentActors[state.currentCommentActor]
-			state.currentCommentActor = (state.currentCommentActor + 1) % len(state.commentActors)
-			context.RequestWithCustomSender(commentActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.ListCommentReplies:
+		fmt.Printf("Engine: Received ListCommentReplies request for comment %s\n", msg.CommentId)
+		if msg.ActorPID == nil {
+			msg.PostId = state.commentPostID(msg.CommentId)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
 	case *messages.SendDirectMessage:
+		// Replies belong to their parent's thread; a new message starts a
+		// thread named after itself, so its ID has to be known up front
+		if msg.MessageID == "" {
+			msg.MessageID = uuid.New().String()
+		}
+		if msg.ParentID != "" {
+			msg.ThreadID = state.messageThreadID(msg.ParentID)
+		} else {
+			msg.ThreadID = msg.MessageID
+		}
+		if msg.ActorPID == nil {
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.GetUserMessages:
+		if msg.ActorPID == nil {
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.GetMessageThread:
+		if msg.ActorPID == nil {
+			msg.ThreadID = state.messageThreadID(msg.MessageID)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.MarkMessageRead:
+		if msg.ActorPID == nil {
+			msg.ThreadID = state.messageThreadID(msg.MessageID)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
+	case *messages.DeleteDirectMessage:
 		if msg.ActorPID == nil {
-			dmActor := state.directMessageActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.directMessageActors)
-			context.RequestWithCustomSender(dmActor, msg, context.Sender())
+			msg.ThreadID = state.messageThreadID(msg.MessageID)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
+	case *messages.Notify:
+		// Nobody waits on a notification
+		context.Send(state.router(msg), msg)
+
+	case *messages.GetNotifications, *messages.MarkNotificationsRead:
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
 	case *messages.Vote:
 		if msg.ActorPID == nil {
 			switch msg.Type {
 			case "post":
-				postActor := state.postActors[state.currentUserActor]
-				state.currentUserActor = (state.currentUserActor + 1) % len(state.postActors)
-				context.RequestWithCustomSender(postActor, msg, context.Sender())
+				context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 			case "comment":
-				commentActor := state.commentActors[state.currentUserActor]
-				state.currentUserActor = (state.currentUserActor + 1) % len(state.commentActors)
-				context.RequestWithCustomSender(commentActor, msg, context.Sender())
+				msg.PostId = state.commentPostID(msg.TargetID)
+				context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 			}
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
+	case *messages.UpdateKarma:
+		// Karma updates are fire-and-forget, applied by the user's owner
+		context.Send(state.router(msg), msg)
+
+	case *messages.GetKarma:
+		if msg.ActorPID == nil {
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+		} else {
+			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
+		}
+
 	case *messages.GetSubredditMembers:
 		fmt.Printf("Engine: Received GetSubredditMembers request for %s\n", msg.SubredditName)
 		if msg.ActorPID == nil {
-			subredditActor := state.subredditActors[state.currentUserActor]
-			fmt.Printf("Engine: Routing GetMembers to actor %v\n", subredditActor)
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.subredditActors)
-			context.RequestWithCustomSender(subredditActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
 	case *messages.ValidateToken:
-		fmt.Printf("Engine: Received ValidateToken request for token: %s\n", msg.Token)
-		// Forward to user actor for validation
+		fmt.Printf("Engine: Received ValidateToken request\n")
+		// Forward to the owner of the user the token names
 		if msg.ActorPID == nil {
-			userActor := state.userActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.userActors)
-			context.RequestWithCustomSender(userActor, msg, context.Sender())
+			msg.Username = tokenUsername(msg.Token)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -221,10 +320,7 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.ListSubredditPosts:
 		fmt.Printf("Engine: Received ListSubredditPosts request for %s\n", msg.SubredditName)
 		if msg.ActorPID == nil {
-			postActor := state.postActors[state.currentUserActor]
-			fmt.Printf("Engine: Routing to post actor %v\n", postActor)
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.postActors)
-			context.RequestWithCustomSender(postActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -232,10 +328,7 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.GetPost:
 		fmt.Printf("Engine: Received GetPost request for post ID: %s\n", msg.PostId)
 		if msg.ActorPID == nil {
-			postActor := state.postActors[state.currentUserActor]
-			fmt.Printf("Engine: Routing to post actor %v\n", postActor)
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.postActors)
-			context.RequestWithCustomSender(postActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -243,9 +336,8 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.DeleteComment:
 		fmt.Printf("Engine: Received DeleteComment request for comment ID: %s\n", msg.CommentId)
 		if msg.ActorPID == nil {
-			commentActor := state.commentActors[state.currentCommentActor]
-			state.currentCommentActor = (state.currentCommentActor + 1) % len(state.commentActors)
-			context.RequestWithCustomSender(commentActor, msg, context.Sender())
+			msg.PostId = state.commentPostID(msg.CommentId)
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -253,19 +345,33 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.DeletePost:
 		fmt.Printf("Engine: Received DeletePost request for post ID: %s\n", msg.PostId)
 		if msg.ActorPID == nil {
-			postActor := state.postActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.postActors)
-			context.RequestWithCustomSender(postActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
+	case *messages.DeletePostComments:
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.EditSubreddit, *messages.GetSubreddit:
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.ReportPost, *messages.ModeratePost:
+		// The post's owner keeps its reports
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.ReportComment:
+		msg.PostId = state.commentPostID(msg.CommentId)
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.ModerateComment:
+		msg.PostId = state.commentPostID(msg.CommentId)
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
 	case *messages.DeleteSubreddit:
 		fmt.Printf("Engine: Received DeleteSubreddit request for subreddit: %s\n", msg.Name)
 		if msg.ActorPID == nil {
-			subredditActor := state.subredditActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.subredditActors)
-			context.RequestWithCustomSender(subredditActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
@@ -273,50 +379,59 @@ func (state *EngineActor) Receive(context actor.Context) {
 	case *messages.GetFeed:
 		fmt.Printf("Engine: Received GetFeed request for user: %s\n", msg.UserId)
 		if msg.ActorPID == nil {
-			userActor := state.userActors[state.currentUserActor]
-			state.currentUserActor = (state.currentUserActor + 1) % len(state.userActors)
-			context.RequestWithCustomSender(userActor, msg, context.Sender())
+			context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 		} else {
 			context.RequestWithCustomSender(msg.ActorPID, msg, context.Sender())
 		}
 
 	case *messages.SearchPosts:
-		// Forward search request to PostActor
-		response, err := state.system.Root.RequestFuture(state.postPID, msg, 5*time.Second).Result()
-		if err != nil {
-			fmt.Printf("Error searching posts: %v\n", err)
-			context.Respond(&messages.SearchPostsResponse{
-				Success: false,
-				Error:   "Search request timeout",
-			})
-			return
-		}
-		context.Respond(response)
+		// Any actor of the pool can answer from the shared index
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.InviteModerator, *messages.AcceptModeratorInvite, *messages.SetModeratorPermissions,
+		*messages.RemoveModerator, *messages.GetModerators,
+		*messages.BanUser, *messages.UnbanUser, *messages.MuteUser, *messages.UnmuteUser,
+		*messages.SetApprovedSubmitter, *messages.GetSubredditUsers,
+		*messages.GetJoinRequests, *messages.RespondJoinRequest, *messages.GetModQueue,
+		*messages.GetModLog, *messages.SetAutoModRules, *messages.GetAutoModRules:
+		// The subreddit's owner keeps its moderator and user lists
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.SearchComments, *messages.SearchSubreddits, *messages.SearchUsers, *messages.Autocomplete:
+		// Any actor of the pool can answer from the shared index
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.EditUserProfile, *messages.GetUserProfile, *messages.DeactivateAccount,
+		*messages.DeleteAccount, *messages.SuspendUser, *messages.UnsuspendUser,
+		*messages.ResendVerification, *messages.RequestPasswordReset:
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.VerifyEmail:
+		msg.Username = tokenUsername(msg.Token)
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.ResetPassword:
+		msg.Username = tokenUsername(msg.Token)
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.AnonymizePost, *messages.DropSubredditUser:
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.AnonymizeComment:
+		msg.PostId = state.commentPostID(msg.CommentId)
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
+
+	case *messages.GetUserPosts, *messages.GetUserComments:
+		// A user's history spans many owners, so any actor of the pool reads it
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 
 	case *messages.EditPost:
-		// Forward edit request to PostActor
-		response, err := state.system.Root.RequestFuture(state.postPID, msg, 5*time.Second).Result()
-		if err != nil {
-			fmt.Printf("Error editing post: %v\n", err)
-			context.Respond(&messages.EditPostResponse{
-				Success: false,
-				Error:   "Edit request timeout",
-			})
-			return
-		}
-		context.Respond(response)
+		// Forward edit request to the PostActor owning the post
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 
 	case *messages.EditComment:
-		// Forward edit request to CommentActor
-		response, err := state.system.Root.RequestFuture(state.commentPID, msg, 5*time.Second).Result()
-		if err != nil {
-			fmt.Printf("Error editing comment: %v\n", err)
-			context.Respond(&messages.EditCommentResponse{
-				Success: false,
-				Error:   "Edit request timeout",
-			})
-			return
-		}
-		context.Respond(response)
+		// Forward edit request to the CommentActor owning the comment
+		msg.PostId = state.commentPostID(msg.CommentId)
+		context.RequestWithCustomSender(state.router(msg), msg, context.Sender())
 	}
 }
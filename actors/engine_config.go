@@ -0,0 +1,237 @@
+package actors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Actor pools the engine spawns
+const (
+	PoolUser          = "user"
+	PoolPost          = "post"
+	PoolSubreddit     = "subreddit"
+	PoolComment       = "comment"
+	PoolDirectMessage = "directMessage"
+	PoolNotification  = "notification"
+)
+
+// Ways the engine can pick an actor from a pool, one protoactor router each
+const (
+	RouteRoundRobin     = "round-robin"
+	RouteRandom         = "random"
+	RouteConsistentHash = "consistent-hash"
+	RouteBroadcast      = "broadcast"
+)
+
+var routingStrategies = []string{RouteRoundRobin, RouteRandom, RouteConsistentHash, RouteBroadcast}
+
+// messageRoute says which pool handles a message. Messages that change state
+// must reach the entity's owner, so they are pinned to consistent hashing.
+type messageRoute struct {
+	pool         string
+	changesState bool
+	strategy     string // default
+}
+
+// messageRoutes lists every message the engine routes, by the name used in
+// the configuration. Votes and Autocomplete are split by target since they go
+// to different pools.
+var messageRoutes = map[string]messageRoute{
+	"RegisterUser":         {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"LoginUser":            {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"RefreshToken":         {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"Logout":               {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"ChangePassword":       {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"UpdateKarma":          {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"ValidateToken":        {pool: PoolUser, strategy: RouteConsistentHash},
+	"GetKarma":             {pool: PoolUser, strategy: RouteConsistentHash},
+	"GetFeed":              {pool: PoolUser, strategy: RouteConsistentHash},
+	"SearchUsers":          {pool: PoolUser, strategy: RouteRoundRobin},
+	"UserAutocomplete":     {pool: PoolUser, strategy: RouteRoundRobin},
+	"EditUserProfile":      {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"GetUserProfile":       {pool: PoolUser, strategy: RouteConsistentHash},
+	"DeactivateAccount":    {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"DeleteAccount":        {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"SuspendUser":          {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"UnsuspendUser":        {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"VerifyEmail":          {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"ResendVerification":   {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"RequestPasswordReset": {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+	"ResetPassword":        {pool: PoolUser, changesState: true, strategy: RouteConsistentHash},
+
+	"CreateSubreddit":       {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"JoinSubreddit":         {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"LeaveSubreddit":        {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"DeleteSubreddit":       {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"DropSubredditUser":     {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"EditSubreddit":         {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"GetSubreddit":          {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"GetSubredditMembers":   {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"GetSubreddits":         {pool: PoolSubreddit, strategy: RouteRoundRobin},
+	"SearchSubreddits":      {pool: PoolSubreddit, strategy: RouteRoundRobin},
+	"SubredditAutocomplete": {pool: PoolSubreddit, strategy: RouteRoundRobin},
+
+	"InviteModerator":         {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"AcceptModeratorInvite":   {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"SetModeratorPermissions": {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"RemoveModerator":         {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"GetModerators":           {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"BanUser":                 {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"UnbanUser":               {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"MuteUser":                {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"UnmuteUser":              {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"SetApprovedSubmitter":    {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"GetSubredditUsers":       {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"RespondJoinRequest":      {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"GetJoinRequests":         {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"GetModQueue":             {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"GetModLog":               {pool: PoolSubreddit, strategy: RouteConsistentHash},
+	"SetAutoModRules":         {pool: PoolSubreddit, changesState: true, strategy: RouteConsistentHash},
+	"GetAutoModRules":         {pool: PoolSubreddit, strategy: RouteConsistentHash},
+
+	"Post":               {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"EditPost":           {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"DeletePost":         {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"PostVote":           {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"ReportPost":         {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"ModeratePost":       {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"AnonymizePost":      {pool: PoolPost, changesState: true, strategy: RouteConsistentHash},
+	"GetPost":            {pool: PoolPost, strategy: RouteConsistentHash},
+	"ListSubredditPosts": {pool: PoolPost, strategy: RouteConsistentHash},
+	"SearchPosts":        {pool: PoolPost, strategy: RouteRoundRobin},
+	"GetUserPosts":       {pool: PoolPost, strategy: RouteRoundRobin},
+
+	"CreateComment":      {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"EditComment":        {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"DeleteComment":      {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"DeletePostComments": {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"CommentVote":        {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"ReportComment":      {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"ModerateComment":    {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"AnonymizeComment":   {pool: PoolComment, changesState: true, strategy: RouteConsistentHash},
+	"ListPostComments":   {pool: PoolComment, strategy: RouteConsistentHash},
+	"ListCommentReplies": {pool: PoolComment, strategy: RouteConsistentHash},
+	"SearchComments":     {pool: PoolComment, strategy: RouteRoundRobin},
+	"GetUserComments":    {pool: PoolComment, strategy: RouteRoundRobin},
+
+	"SendDirectMessage":   {pool: PoolDirectMessage, changesState: true, strategy: RouteConsistentHash},
+	"MarkMessageRead":     {pool: PoolDirectMessage, changesState: true, strategy: RouteConsistentHash},
+	"DeleteDirectMessage": {pool: PoolDirectMessage, changesState: true, strategy: RouteConsistentHash},
+	"GetUserMessages":     {pool: PoolDirectMessage, strategy: RouteConsistentHash},
+	"GetMessageThread":    {pool: PoolDirectMessage, strategy: RouteConsistentHash},
+
+	"Notify":                {pool: PoolNotification, changesState: true, strategy: RouteConsistentHash},
+	"MarkNotificationsRead": {pool: PoolNotification, changesState: true, strategy: RouteConsistentHash},
+	"GetNotifications":      {pool: PoolNotification, strategy: RouteConsistentHash},
+}
+
+// EngineConfig sets how many actors each pool has and how the engine picks
+// one of them for each message. Anything left out keeps its default.
+//
+// Example file:
+//
+//	{
+//	  "poolSizes": {"user": 4, "post": 16},
+//	  "routing": {"GetPost": "random", "SearchPosts": "round-robin"}
+//	}
+type EngineConfig struct {
+	PoolSizes map[string]int    `json:"poolSizes"` // pool name -> number of actors
+	Routing   map[string]string `json:"routing"`   // message name -> strategy
+}
+
+// DefaultEngineConfig spawns 10 actors per pool, hashes everything keyed to
+// its owner and spreads the listings that read every entity round-robin
+func DefaultEngineConfig() *EngineConfig {
+	config := &EngineConfig{
+		PoolSizes: map[string]int{
+			PoolUser:          10,
+			PoolPost:          10,
+			PoolSubreddit:     10,
+			PoolComment:       10,
+			PoolDirectMessage: 10,
+			PoolNotification:  10,
+		},
+		Routing: make(map[string]string, len(messageRoutes)),
+	}
+	for name, route := range messageRoutes {
+		config.Routing[name] = route.strategy
+	}
+	return config
+}
+
+// LoadEngineConfig reads a JSON config file over the defaults. An empty path
+// returns the defaults.
+func LoadEngineConfig(path string) (*EngineConfig, error) {
+	config := DefaultEngineConfig()
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading engine config: %w", err)
+	}
+
+	overrides := &EngineConfig{}
+	if err := json.Unmarshal(data, overrides); err != nil {
+		return nil, fmt.Errorf("decoding engine config: %w", err)
+	}
+	for pool, size := range overrides.PoolSizes {
+		config.PoolSizes[pool] = size
+	}
+	for name, strategy := range overrides.Routing {
+		config.Routing[name] = strategy
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate rejects unknown pools, messages and strategies, empty pools, and
+// state changes routed anywhere but to the entity's owner
+func (config *EngineConfig) Validate() error {
+	var problems []error
+
+	for pool, size := range config.PoolSizes {
+		if !isPool(pool) {
+			problems = append(problems, fmt.Errorf("unknown pool %q", pool))
+		} else if size < 1 {
+			problems = append(problems, fmt.Errorf("pool %q needs at least one actor, got %d", pool, size))
+		}
+	}
+
+	for name, strategy := range config.Routing {
+		route, known := messageRoutes[name]
+		switch {
+		case !known:
+			problems = append(problems, fmt.Errorf("unknown message %q", name))
+		case !isRoutingStrategy(strategy):
+			problems = append(problems, fmt.Errorf("message %q: unknown strategy %q, want one of %v", name, strategy, routingStrategies))
+		case route.changesState && strategy != RouteConsistentHash:
+			problems = append(problems, fmt.Errorf("message %q changes state and must use %s, got %q", name, RouteConsistentHash, strategy))
+		}
+	}
+
+	// Report problems in a stable order
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Error() < problems[j].Error() })
+	return errors.Join(problems...)
+}
+
+func isPool(pool string) bool {
+	_, known := DefaultEngineConfig().PoolSizes[pool]
+	return known
+}
+
+func isRoutingStrategy(strategy string) bool {
+	for _, known := range routingStrategies {
+		if strategy == known {
+			return true
+		}
+	}
+	return false
+}
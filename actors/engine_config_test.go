@@ -0,0 +1,169 @@
+package actors
+
+import (
+	"os"
+	"path/filepath"
+	"reddit/messages"
+	"strings"
+	"testing"
+
+	"github.com/asynkron/protoactor-go/router"
+)
+
+func TestLoadEngineConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{
+			name: "Overrides pool size and read routing",
+			file: `{"poolSizes": {"post": 3}, "routing": {"GetPost": "random"}}`,
+		},
+		{
+			name:    "State change away from its owner",
+			file:    `{"routing": {"PostVote": "round-robin"}}`,
+			wantErr: `message "PostVote" changes state`,
+		},
+		{
+			name:    "Unknown strategy",
+			file:    `{"routing": {"GetPost": "least-loaded"}}`,
+			wantErr: `unknown strategy "least-loaded"`,
+		},
+		{
+			name: "Searches hashed on their query",
+			file: `{"poolSizes": {"post": 3}, "routing": {"GetPost": "random", "SearchUsers": "consistent-hash", "UserAutocomplete": "consistent-hash", "SearchComments": "consistent-hash"}}`,
+		},
+		{
+			name:    "Empty pool",
+			file:    `{"poolSizes": {"user": 0}}`,
+			wantErr: `pool "user" needs at least one actor`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "engine.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadEngineConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadEngineConfig() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadEngineConfig() error = %v", err)
+			}
+			if config.PoolSizes[PoolPost] != 3 || config.PoolSizes[PoolUser] != 10 {
+				t.Errorf("PoolSizes = %v, want post=3 and the rest defaulted", config.PoolSizes)
+			}
+			if config.Routing["GetPost"] != RouteRandom || config.Routing["Post"] != RouteConsistentHash {
+				t.Errorf("Routing = %v, want GetPost=random and the rest defaulted", config.Routing)
+			}
+		})
+	}
+}
+
+// Any message may be configured for consistent hashing, which drops messages
+// without a Hash method
+func TestRoutedMessagesHash(t *testing.T) {
+	samples := []interface{}{
+		&messages.RegisterUser{},
+		&messages.LoginUser{},
+		&messages.RefreshToken{},
+		&messages.Logout{},
+		&messages.ChangePassword{},
+		&messages.UpdateKarma{},
+		&messages.ValidateToken{},
+		&messages.GetKarma{},
+		&messages.GetFeed{},
+		&messages.SearchUsers{},
+		&messages.Autocomplete{Type: "user"},
+		&messages.EditUserProfile{},
+		&messages.GetUserProfile{},
+		&messages.DeactivateAccount{},
+		&messages.DeleteAccount{},
+		&messages.SuspendUser{},
+		&messages.UnsuspendUser{},
+		&messages.VerifyEmail{},
+		&messages.ResendVerification{},
+		&messages.RequestPasswordReset{},
+		&messages.ResetPassword{},
+		&messages.CreateSubreddit{},
+		&messages.JoinSubreddit{},
+		&messages.LeaveSubreddit{},
+		&messages.DeleteSubreddit{},
+		&messages.EditSubreddit{},
+		&messages.GetSubreddit{},
+		&messages.GetSubredditMembers{},
+		&messages.GetSubreddits{},
+		&messages.SearchSubreddits{},
+		&messages.Autocomplete{Type: "subreddit"},
+		&messages.InviteModerator{},
+		&messages.AcceptModeratorInvite{},
+		&messages.SetModeratorPermissions{},
+		&messages.RemoveModerator{},
+		&messages.GetModerators{},
+		&messages.BanUser{},
+		&messages.UnbanUser{},
+		&messages.MuteUser{},
+		&messages.UnmuteUser{},
+		&messages.SetApprovedSubmitter{},
+		&messages.GetSubredditUsers{},
+		&messages.RespondJoinRequest{},
+		&messages.GetJoinRequests{},
+		&messages.GetModQueue{},
+		&messages.GetModLog{},
+		&messages.SetAutoModRules{},
+		&messages.GetAutoModRules{},
+		&messages.Post{},
+		&messages.EditPost{},
+		&messages.DeletePost{},
+		&messages.Vote{Type: "post"},
+		&messages.ReportPost{},
+		&messages.ModeratePost{},
+		&messages.AnonymizePost{},
+		&messages.DropSubredditUser{},
+		&messages.GetPost{},
+		&messages.ListSubredditPosts{},
+		&messages.SearchPosts{},
+		&messages.GetUserPosts{},
+		&messages.CreateComment{},
+		&messages.EditComment{},
+		&messages.DeleteComment{},
+		&messages.DeletePostComments{},
+		&messages.Vote{Type: "comment"},
+		&messages.ReportComment{},
+		&messages.ModerateComment{},
+		&messages.AnonymizeComment{},
+		&messages.ListPostComments{},
+		&messages.ListCommentReplies{},
+		&messages.SearchComments{},
+		&messages.GetUserComments{},
+		&messages.SendDirectMessage{},
+		&messages.MarkMessageRead{},
+		&messages.DeleteDirectMessage{},
+		&messages.GetUserMessages{},
+		&messages.GetMessageThread{},
+		&messages.Notify{},
+		&messages.MarkNotificationsRead{},
+		&messages.GetNotifications{},
+	}
+
+	routed := make(map[string]bool)
+	for _, msg := range samples {
+		if _, ok := msg.(router.Hasher); !ok {
+			t.Errorf("%T has no Hash method", msg)
+		}
+		routed[routeName(msg)] = true
+	}
+	for name := range messageRoutes {
+		if !routed[name] {
+			t.Errorf("no sample of %s", name)
+		}
+	}
+}
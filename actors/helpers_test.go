@@ -0,0 +1,67 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// newTestStore returns an empty in-memory store with the users registered
+func newTestStore(t *testing.T, usernames ...string) *Store {
+	t.Helper()
+	store := NewMemoryStore()
+	for _, username := range usernames {
+		if err := store.Users.Put(username, "password123"); err != nil {
+			t.Fatalf("registering %s: %v", username, err)
+		}
+	}
+	return store
+}
+
+// setupSubreddit stores a public subreddit the way CreateSubreddit makes one,
+// created by "owner", after configure has had a chance to change it
+func setupSubreddit(t *testing.T, store *Store, name string, configure func(subreddit *Subreddit)) *Subreddit {
+	t.Helper()
+	subreddit := &Subreddit{
+		Name:               name,
+		CreatorId:          "owner",
+		Members:            make(map[string]bool),
+		Moderators:         map[string][]string{"owner": {messages.ModPermAll}},
+		ModInvites:         make(map[string][]string),
+		JoinRequests:       make(map[string]int64),
+		ApprovedSubmitters: make(map[string]bool),
+		Bans:               make(map[string]*Sanction),
+		Mutes:              make(map[string]*Sanction),
+	}
+	if configure != nil {
+		configure(subreddit)
+	}
+	if err := store.Subreddits.Put(name, subreddit); err != nil {
+		t.Fatalf("storing subreddit %s: %v", name, err)
+	}
+	return subreddit
+}
+
+// newTestEngine starts an engine over store and returns a function that asks
+// it something and waits for the answer
+func newTestEngine(t *testing.T, store *Store) func(msg interface{}) interface{} {
+	t.Helper()
+	config := DefaultEngineConfig()
+	for pool := range config.PoolSizes {
+		config.PoolSizes[pool] = 2
+	}
+	system := actor.NewActorSystem()
+	t.Cleanup(system.Shutdown)
+	engine := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor { return NewEngineActor(system, store, config) }))
+
+	return func(msg interface{}) interface{} {
+		t.Helper()
+		response, err := system.Root.RequestFuture(engine, msg, 5*time.Second).Result()
+		if err != nil {
+			t.Fatalf("%T: %v", msg, err)
+		}
+		return response
+	}
+}
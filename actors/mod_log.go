@@ -0,0 +1,93 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/messages"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModAction is one entry in a subreddit's mod log. Entries are only ever
+// appended.
+type ModAction struct {
+	Id         string
+	Moderator  string
+	Action     string // One of the messages.ModLog constants
+	TargetUser string
+	TargetId   string
+	Details    string
+	At         int64
+}
+
+// logModAction appends to the subreddit's mod log. Whichever actor acted
+// writes the entry; the table update keeps concurrent writers apart.
+func logModAction(store *Store, subredditName string, action *ModAction) {
+	action.Id = uuid.New().String()
+	action.At = time.Now().Unix()
+	if err := store.ModLog.Append(subredditName, action); err != nil {
+		fmt.Printf("Failed to log %s in %s: %v\n", action.Action, subredditName, err)
+	}
+}
+
+// handleGetModLog filters the log, then pages through it newest first. The
+// log outlives its subreddit, for whoever moderated it when it was deleted
+// and for admins.
+func (state *SubredditActor) handleGetModLog(msg *messages.GetModLog) *messages.GetModLogResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		archived, wasArchived := state.store.ArchivedSubreddits.Get(msg.SubredditName)
+		if !wasArchived {
+			return &messages.GetModLogResponse{Success: false, Error: "Subreddit not found"}
+		}
+		subreddit = archived.Subreddit
+	}
+	if !subreddit.isModerator(msg.UserId) && !state.store.isAdmin(msg.UserId) {
+		return &messages.GetModLogResponse{Success: false, Error: "Only moderators can read the mod log"}
+	}
+	if msg.Until != 0 && msg.Until <= msg.Since {
+		return &messages.GetModLogResponse{Success: false, Error: "The time range is empty"}
+	}
+
+	// The filters are part of the listing, so a cursor only pages through
+	// the entries it was made for
+	listing := fmt.Sprintf("modlog/%s/%s/%s/%d/%d", msg.SubredditName, msg.Moderator, msg.Action, msg.Since, msg.Until)
+	pager, err := newPager(listing, msg.Page, time.Now().Unix())
+	if err != nil {
+		return &messages.GetModLogResponse{Success: false, Error: err.Error()}
+	}
+
+	actions, _ := state.store.ModLog.Get(msg.SubredditName)
+	matched := make([]*ModAction, 0, len(actions))
+	for _, action := range actions {
+		switch {
+		case msg.Moderator != "" && action.Moderator != msg.Moderator:
+		case msg.Action != "" && action.Action != msg.Action:
+		case action.At < msg.Since:
+		case msg.Until != 0 && action.At >= msg.Until:
+		default:
+			matched = append(matched, action)
+		}
+	}
+
+	key := func(action *ModAction) pageKey {
+		return pageKey{Score: float64(action.At), Timestamp: action.At, ID: action.Id}
+	}
+	sort.Slice(matched, func(i, j int) bool { return rankedBefore(key(matched[i]), key(matched[j])) })
+	paged, links := paginate(pager, matched, key, rankedBefore)
+
+	entries := make([]*messages.ModLogEntry, 0, len(paged))
+	for _, action := range paged {
+		entries = append(entries, &messages.ModLogEntry{
+			Id:         action.Id,
+			Moderator:  action.Moderator,
+			Action:     action.Action,
+			TargetUser: action.TargetUser,
+			TargetId:   action.TargetId,
+			Details:    action.Details,
+			At:         action.At,
+		})
+	}
+	return &messages.GetModLogResponse{Success: true, Entries: entries, PageLinks: links}
+}
@@ -0,0 +1,80 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestModLog(t *testing.T) {
+	store := newTestStore(t, "owner", "helper", "troll")
+	state := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Moderators["helper"] = []string{messages.ModPermUsers}
+	})
+
+	state.handleMuteUser(&messages.MuteUser{SubredditName: "golang", ModeratorId: "helper", Username: "troll", Days: 3})
+	state.handleBanUser(&messages.BanUser{SubredditName: "golang", ModeratorId: "owner", Username: "troll", Reason: "spam"})
+	state.handleBanUser(&messages.BanUser{SubredditName: "golang", ModeratorId: "troll", Username: "helper"})
+	state.handleEdit(&messages.EditSubreddit{Name: "golang", AuthorId: "owner", Type: messages.SubredditPrivate})
+
+	read := func(msg *messages.GetModLog) []*messages.ModLogEntry {
+		msg.SubredditName = "golang"
+		response := state.handleGetModLog(msg)
+		if !response.Success {
+			t.Fatalf("handleGetModLog(%+v) error = %s", msg, response.Error)
+		}
+		return response.Entries
+	}
+	if entries := read(&messages.GetModLog{UserId: "helper"}); len(entries) != 3 {
+		t.Fatalf("log = %+v, want the mute, ban and edit but not the failed ban", entries)
+	}
+	if entries := read(&messages.GetModLog{UserId: "owner", Moderator: "helper"}); len(entries) != 1 || entries[0].Details != "3 days" {
+		t.Errorf("helper's actions = %+v, want one 3 day mute", entries)
+	}
+	if entries := read(&messages.GetModLog{UserId: "owner", Action: messages.ModLogBanUser}); len(entries) != 1 || entries[0].Details != "spam (permanent)" {
+		t.Errorf("bans = %+v, want one permanent ban for spam", entries)
+	}
+	if entries := read(&messages.GetModLog{UserId: "owner", Since: 1, Until: 2}); len(entries) != 0 {
+		t.Errorf("log in 1970 = %+v, want nothing", entries)
+	}
+	if response := state.handleGetModLog(&messages.GetModLog{SubredditName: "golang", UserId: "troll"}); response.Success {
+		t.Errorf("a non-moderator read the mod log")
+	}
+}
+
+func TestModLogOfDeletedSubreddit(t *testing.T) {
+	store := newTestStore(t)
+	state := NewSubredditActor(nil, store)
+	store.SetAdmins([]string{"admin"})
+	subreddit := setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Moderators["helper"] = []string{messages.ModPermUsers}
+	})
+	store.Subreddits.Delete("golang")
+	store.ArchivedSubreddits.Put("golang", &ArchivedSubreddit{Subreddit: subreddit, DeletedBy: "owner", Complete: true})
+	logModAction(store, "golang", &ModAction{Moderator: "owner", Action: messages.ModLogDeleteSubreddit})
+
+	tests := []struct {
+		name          string
+		subredditName string
+		userId        string
+		wantSuccess   bool
+	}{
+		{"Creator", "golang", "owner", true},
+		{"Former moderator", "golang", "helper", true},
+		{"Admin", "golang", "admin", true},
+		{"Anyone else", "golang", "troll", false},
+		{"Never existed", "rust", "admin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := state.handleGetModLog(&messages.GetModLog{SubredditName: tt.subredditName, UserId: tt.userId})
+			if response.Success != tt.wantSuccess {
+				t.Fatalf("handleGetModLog() = %+v, want success %v", response, tt.wantSuccess)
+			}
+			if tt.wantSuccess && (len(response.Entries) != 1 || response.Entries[0].Action != messages.ModLogDeleteSubreddit) {
+				t.Errorf("entries = %+v, want the deletion", response.Entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/messages"
+	"sort"
+	"time"
+)
+
+// removedPlaceholder replaces what a moderator removed
+const removedPlaceholder = "[removed]"
+
+// maxReportDetails caps the free text on a report
+const maxReportDetails = 500
+
+// Moderation is what moderators know about a post or comment. Its owner
+// changes it like the rest of the entity.
+type Moderation struct {
+	Reports        map[string]*Report // reporter -> their latest report
+	ReportsIgnored bool               // Reports still count but no longer queue it
+	Removed        bool
+	Filtered       bool // Removed by AutoModerator until a moderator decides
+	Approved       bool
+}
+
+type Report struct {
+	Reason  string
+	Details string
+	At      int64
+}
+
+func (moderation Moderation) clone() Moderation {
+	copied := moderation
+	copied.Reports = make(map[string]*Report, len(moderation.Reports))
+	for reporter, report := range moderation.Reports {
+		copied.Reports[reporter] = report
+	}
+	return copied
+}
+
+// report records or replaces reporter's report
+func (moderation *Moderation) report(reporter, reason, details string, now time.Time) error {
+	switch reason {
+	case messages.ReportSpam, messages.ReportHarassment, messages.ReportHate,
+		messages.ReportMisinformation, messages.ReportBreaksRules, messages.ReportOther:
+	default:
+		return fmt.Errorf("Unknown report reason %q, want spam, harassment, hate, misinformation, breaks-rules or other", reason)
+	}
+	if len(details) > maxReportDetails {
+		return fmt.Errorf("Report details must be at most %d characters", maxReportDetails)
+	}
+	if moderation.Reports == nil {
+		moderation.Reports = make(map[string]*Report)
+	}
+	moderation.Reports[reporter] = &Report{Reason: reason, Details: details, At: now.Unix()}
+	return nil
+}
+
+// apply carries out a moderator's action. Approving clears the reports and
+// undoes a removal.
+func (moderation *Moderation) apply(action string) error {
+	switch action {
+	case messages.ModActionApprove:
+		moderation.Approved = true
+		moderation.Removed = false
+		moderation.Filtered = false
+		moderation.ReportsIgnored = false
+		moderation.Reports = make(map[string]*Report)
+	case messages.ModActionRemove:
+		moderation.Removed = true
+		moderation.Filtered = false
+		moderation.Approved = false
+	case messages.ModActionIgnoreReports:
+		moderation.ReportsIgnored = true
+	default:
+		return fmt.Errorf("Unknown action %q, want approve, remove or ignore-reports", action)
+	}
+	return nil
+}
+
+// inQueue says whether the item belongs in queue. Only posts can be
+// unmoderated; filtered items wait in the reported queue.
+func (moderation *Moderation) inQueue(queue string, isPost bool) bool {
+	switch queue {
+	case messages.QueueReported:
+		return moderation.Filtered || len(moderation.Reports) > 0 && !moderation.ReportsIgnored && !moderation.Removed
+	case messages.QueueRemoved:
+		return moderation.Removed
+	case messages.QueueUnmoderated:
+		return isPost && !moderation.Approved && !moderation.Removed
+	}
+	return false
+}
+
+func (moderation *Moderation) reasons() map[string]int {
+	reasons := make(map[string]int)
+	for _, report := range moderation.Reports {
+		reasons[report.Reason]++
+	}
+	return reasons
+}
+
+// Mod log actions for moderating posts and comments, by messages.ModAction
+var (
+	postModLogActions = map[string]string{
+		messages.ModActionApprove:       messages.ModLogApprovePost,
+		messages.ModActionRemove:        messages.ModLogRemovePost,
+		messages.ModActionIgnoreReports: messages.ModLogIgnorePostReports,
+	}
+	commentModLogActions = map[string]string{
+		messages.ModActionApprove:       messages.ModLogApproveComment,
+		messages.ModActionRemove:        messages.ModLogRemoveComment,
+		messages.ModActionIgnoreReports: messages.ModLogIgnoreCommentReports,
+	}
+)
+
+func (state *PostActor) handleReport(msg *messages.ReportPost) *messages.ReportPostResponse {
+	post, exists := state.store.Posts.Get(msg.PostId)
+	if !exists || !canViewSubreddit(state.store, post.SubredditName, msg.ReporterId) {
+		return &messages.ReportPostResponse{Success: false, Error: "Post not found"}
+	}
+
+	post = post.clone()
+	if err := post.report(msg.ReporterId, msg.Reason, msg.Details, time.Now()); err != nil {
+		return &messages.ReportPostResponse{Success: false, Error: err.Error()}
+	}
+	if err := state.store.Posts.Put(post.PostId, post); err != nil {
+		return &messages.ReportPostResponse{Success: false, Error: "Failed to save report"}
+	}
+	return &messages.ReportPostResponse{Success: true}
+}
+
+func (state *PostActor) handleModerate(msg *messages.ModeratePost) *messages.ModeratePostResponse {
+	post, exists := state.store.Posts.Get(msg.PostId)
+	if !exists {
+		return &messages.ModeratePostResponse{Success: false, Error: "Post not found"}
+	}
+	if !canModerate(state.store, post.SubredditName, msg.ModeratorId, messages.ModPermPosts) {
+		return &messages.ModeratePostResponse{Success: false, Error: "Not authorized to moderate posts"}
+	}
+
+	post = post.clone()
+	if err := post.apply(msg.Action); err != nil {
+		return &messages.ModeratePostResponse{Success: false, Error: err.Error()}
+	}
+	if err := state.store.Posts.Put(post.PostId, post); err != nil {
+		return &messages.ModeratePostResponse{Success: false, Error: "Failed to save post"}
+	}
+	logModAction(state.store, post.SubredditName, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     postModLogActions[msg.Action],
+		TargetUser: post.AuthorId,
+		TargetId:   post.PostId,
+	})
+	return &messages.ModeratePostResponse{Success: true}
+}
+
+// commentSubreddit finds the subreddit a comment was posted in
+func commentSubreddit(store *Store, comment *StoredComment) (string, bool) {
+	post, exists := store.Posts.Get(comment.PostId)
+	if !exists {
+		return "", false
+	}
+	return post.SubredditName, true
+}
+
+func (state *CommentActor) handleReport(msg *messages.ReportComment) *messages.ReportCommentResponse {
+	comment, exists := state.store.Comments.Get(msg.CommentId)
+	if !exists {
+		return &messages.ReportCommentResponse{Success: false, Error: "Comment not found"}
+	}
+	if subredditName, found := commentSubreddit(state.store, comment); found && !canViewSubreddit(state.store, subredditName, msg.ReporterId) {
+		return &messages.ReportCommentResponse{Success: false, Error: "Comment not found"}
+	}
+
+	comment = comment.clone()
+	if err := comment.report(msg.ReporterId, msg.Reason, msg.Details, time.Now()); err != nil {
+		return &messages.ReportCommentResponse{Success: false, Error: err.Error()}
+	}
+	if err := state.store.Comments.Put(comment.CommentId, comment); err != nil {
+		return &messages.ReportCommentResponse{Success: false, Error: "Failed to save report"}
+	}
+	return &messages.ReportCommentResponse{Success: true}
+}
+
+func (state *CommentActor) handleModerate(msg *messages.ModerateComment) *messages.ModerateCommentResponse {
+	comment, exists := state.store.Comments.Get(msg.CommentId)
+	if !exists {
+		return &messages.ModerateCommentResponse{Success: false, Error: "Comment not found"}
+	}
+	if !state.canModerate(comment, msg.ModeratorId) {
+		return &messages.ModerateCommentResponse{Success: false, Error: "Not authorized to moderate comments"}
+	}
+
+	comment = comment.clone()
+	if err := comment.apply(msg.Action); err != nil {
+		return &messages.ModerateCommentResponse{Success: false, Error: err.Error()}
+	}
+	if err := state.store.Comments.Put(comment.CommentId, comment); err != nil {
+		return &messages.ModerateCommentResponse{Success: false, Error: "Failed to save comment"}
+	}
+	subredditName, _ := commentSubreddit(state.store, comment)
+	logModAction(state.store, subredditName, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     commentModLogActions[msg.Action],
+		TargetUser: comment.AuthorId,
+		TargetId:   comment.CommentId,
+	})
+	return &messages.ModerateCommentResponse{Success: true}
+}
+
+// handleGetModQueue gathers the queue from the subreddit's posts and their
+// comments, leaving out whichever the moderator may not act on
+func (state *SubredditActor) handleGetModQueue(msg *messages.GetModQueue) *messages.GetModQueueResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.GetModQueueResponse{Success: false, Error: "Subreddit not found"}
+	}
+	queue := msg.Queue
+	if queue == "" {
+		queue = messages.QueueReported
+	}
+	switch queue {
+	case messages.QueueReported, messages.QueueRemoved, messages.QueueUnmoderated:
+	default:
+		return &messages.GetModQueueResponse{Success: false, Error: "Queue must be reported, removed or unmoderated"}
+	}
+	posts := subreddit.permits(msg.ModeratorId, messages.ModPermPosts)
+	comments := subreddit.permits(msg.ModeratorId, messages.ModPermComments)
+	if !posts && !comments {
+		return &messages.GetModQueueResponse{Success: false, Error: "Not authorized to moderate this subreddit"}
+	}
+
+	items := make([]*messages.ModQueueItem, 0)
+	postIds, _ := state.store.SubredditPosts.Get(msg.SubredditName)
+	for _, postId := range postIds {
+		post, exists := state.store.Posts.Get(postId)
+		if !exists {
+			continue
+		}
+		if posts && post.inQueue(queue, true) {
+			items = append(items, &messages.ModQueueItem{
+				Type:           "post",
+				Id:             post.PostId,
+				PostId:         post.PostId,
+				Title:          post.Title,
+				Content:        post.Content,
+				AuthorId:       post.AuthorId,
+				Timestamp:      post.Timestamp,
+				ReportCount:    len(post.Reports),
+				Reasons:        post.reasons(),
+				Removed:        post.Removed,
+				Filtered:       post.Filtered,
+				Approved:       post.Approved,
+				ReportsIgnored: post.ReportsIgnored,
+			})
+		}
+		if !comments {
+			continue
+		}
+		commentIds, _ := state.store.PostComments.Get(postId)
+		for _, comment := range threadComments(state.store, commentIds) {
+			if comment.inQueue(queue, false) {
+				items = append(items, &messages.ModQueueItem{
+					Type:           "comment",
+					Id:             comment.CommentId,
+					PostId:         comment.PostId,
+					Content:        comment.Content,
+					AuthorId:       comment.AuthorId,
+					Timestamp:      comment.Timestamp,
+					ReportCount:    len(comment.Reports),
+					Reasons:        comment.reasons(),
+					Removed:        comment.Removed,
+					Filtered:       comment.Filtered,
+					Approved:       comment.Approved,
+					ReportsIgnored: comment.ReportsIgnored,
+				})
+			}
+		}
+	}
+
+	pager, err := newPager("modqueue/"+msg.SubredditName+"/"+queue, msg.Page, time.Now().Unix())
+	if err != nil {
+		return &messages.GetModQueueResponse{Success: false, Error: err.Error()}
+	}
+	key := func(item *messages.ModQueueItem) pageKey {
+		return pageKey{Score: float64(item.Timestamp), Timestamp: item.Timestamp, ID: item.Id}
+	}
+	sort.Slice(items, func(i, j int) bool { return rankedBefore(key(items[i]), key(items[j])) })
+	paged, links := paginate(pager, items, key, rankedBefore)
+	return &messages.GetModQueueResponse{Success: true, Items: paged, PageLinks: links}
+}
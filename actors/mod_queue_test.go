@@ -0,0 +1,91 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestModQueue(t *testing.T) {
+	store := newTestStore(t)
+	posts := NewPostActor(nil, store)
+	comments := NewCommentActor(store)
+	subreddits := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Moderators["janitor"] = []string{messages.ModPermComments}
+	})
+	store.Posts.Put("post", &StoredPost{PostId: "post", SubredditName: "golang", AuthorId: "op", Timestamp: 1, Votes: map[string]bool{}})
+	appendID(store.SubredditPosts, "golang", "post")
+	store.Comments.Put("rude", &StoredComment{CommentId: "rude", PostId: "post", AuthorId: "troll", Content: "rude", Timestamp: 2, Votes: map[string]bool{}})
+	appendID(store.PostComments, "post", "rude")
+
+	if response := posts.handleReport(&messages.ReportPost{PostId: "post", ReporterId: "a", Reason: "boring"}); response.Success {
+		t.Fatalf("an unknown reason was accepted")
+	}
+	for _, reporter := range []string{"a", "b", "b"} {
+		if response := comments.handleReport(&messages.ReportComment{CommentId: "rude", ReporterId: reporter, Reason: messages.ReportHarassment}); !response.Success {
+			t.Fatalf("handleReport() error = %s", response.Error)
+		}
+	}
+	posts.handleReport(&messages.ReportPost{PostId: "post", ReporterId: "a", Reason: messages.ReportSpam})
+
+	queue := func(moderator, name string) []*messages.ModQueueItem {
+		response := subreddits.handleGetModQueue(&messages.GetModQueue{SubredditName: "golang", ModeratorId: moderator, Queue: name})
+		if !response.Success {
+			t.Fatalf("handleGetModQueue(%s, %s) error = %s", moderator, name, response.Error)
+		}
+		return response.Items
+	}
+	if items := queue("owner", ""); len(items) != 2 || items[0].Id != "rude" || items[0].ReportCount != 2 {
+		t.Fatalf("reported = %+v, want the comment with 2 reports, then the post", items)
+	}
+	if items := queue("janitor", messages.QueueReported); len(items) != 1 || items[0].Type != "comment" {
+		t.Errorf("a comments-only moderator was shown %+v", items)
+	}
+
+	if response := comments.handleModerate(&messages.ModerateComment{CommentId: "rude", ModeratorId: "janitor", Action: messages.ModActionRemove}); !response.Success {
+		t.Fatalf("handleModerate() error = %s", response.Error)
+	}
+	if response := posts.handleModerate(&messages.ModeratePost{PostId: "post", ModeratorId: "janitor", Action: messages.ModActionApprove}); response.Success {
+		t.Errorf("a comments-only moderator approved a post")
+	}
+	posts.handleModerate(&messages.ModeratePost{PostId: "post", ModeratorId: "owner", Action: messages.ModActionApprove})
+
+	if items := queue("owner", messages.QueueReported); len(items) != 0 {
+		t.Errorf("reported = %+v, want nothing after moderating both", items)
+	}
+	if items := queue("owner", messages.QueueRemoved); len(items) != 1 || items[0].Content != "rude" {
+		t.Errorf("removed = %+v, want the comment as written", items)
+	}
+
+	commentIds, _ := store.PostComments.Get("post")
+	tree, _, _ := comments.listComments("comments/post", commentIds, "post", SortNew, 0, 0, messages.Page{})
+	if len(tree) != 1 || tree[0].Content != removedPlaceholder || !tree[0].Removed {
+		t.Errorf("tree = %+v, want the comment shown as %s", tree, removedPlaceholder)
+	}
+}
+
+func TestRemovedContentIsFrozen(t *testing.T) {
+	store := setupVoting(t)
+	ask := newTestEngine(t, store)
+	if response := ask(&messages.ModeratePost{PostId: "post", ModeratorId: "owner", Action: messages.ModActionRemove}).(*messages.ModeratePostResponse); !response.Success {
+		t.Fatalf("ModeratePost = %+v", response)
+	}
+	if response := ask(&messages.ModerateComment{CommentId: "comment", ModeratorId: "owner", Action: messages.ModActionRemove}).(*messages.ModerateCommentResponse); !response.Success {
+		t.Fatalf("ModerateComment = %+v", response)
+	}
+
+	if response := ask(&messages.EditPost{PostId: "post", AuthorId: "author", Content: "put back"}).(*messages.EditPostResponse); response.Success {
+		t.Errorf("the author edited a removed post")
+	}
+	if response := ask(&messages.EditComment{CommentId: "comment", AuthorId: "author", Content: "put back"}).(*messages.EditCommentResponse); response.Success {
+		t.Errorf("the author edited a removed comment")
+	}
+	for _, target := range []string{"post", "comment"} {
+		if response := ask(&messages.Vote{UserID: "alice", TargetID: target, IsUpvote: true, Type: target}).(*messages.VoteResponse); response.Success {
+			t.Errorf("alice voted on a removed %s", target)
+		}
+	}
+	if karma := ask(&messages.GetKarma{UserID: "author"}).(*messages.GetKarmaResponse); karma.PostKarma != 0 || karma.CommentKarma != 0 {
+		t.Errorf("karma = post %d, comment %d, want nothing for removed content", karma.PostKarma, karma.CommentKarma)
+	}
+}
@@ -0,0 +1,574 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/messages"
+	"sort"
+	"strings"
+	"time"
+)
+
+var modPermissions = map[string]bool{
+	messages.ModPermAll:      true,
+	messages.ModPermPosts:    true,
+	messages.ModPermComments: true,
+	messages.ModPermUsers:    true,
+	messages.ModPermConfig:   true,
+}
+
+// permits reports whether username moderates the subreddit with the given
+// permission. The creator always does, which also covers subreddits stored
+// before there were moderator lists.
+func (subreddit *Subreddit) permits(username, permission string) bool {
+	if username == subreddit.CreatorId {
+		return true
+	}
+	for _, granted := range subreddit.Moderators[username] {
+		if granted == messages.ModPermAll || granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// isModerator reports whether username moderates the subreddit at all
+func (subreddit *Subreddit) isModerator(username string) bool {
+	_, isModerator := subreddit.Moderators[username]
+	return isModerator || username == subreddit.CreatorId
+}
+
+// canModerate is permits for actors that don't own the subreddit
+func canModerate(store *Store, subredditName, username, permission string) bool {
+	subreddit, exists := store.Subreddits.Get(subredditName)
+	return exists && subreddit.permits(username, permission)
+}
+
+// checkPermissions rejects unknown permissions and returns the rest sorted,
+// without duplicates
+func checkPermissions(permissions []string) ([]string, error) {
+	if len(permissions) == 0 {
+		return nil, fmt.Errorf("Give at least one permission")
+	}
+	seen := make(map[string]bool, len(permissions))
+	checked := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if !modPermissions[permission] {
+			return nil, fmt.Errorf("Unknown permission %q, want all, posts, comments, users or config", permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			checked = append(checked, permission)
+		}
+	}
+	sort.Strings(checked)
+	return checked, nil
+}
+
+func (state *SubredditActor) handleInviteModerator(msg *messages.InviteModerator) *messages.InviteModeratorResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.InviteModeratorResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermAll) {
+		return &messages.InviteModeratorResponse{Success: false, Error: "Not authorized to manage moderators"}
+	}
+	if _, isModerator := subreddit.Moderators[msg.Username]; isModerator || msg.Username == subreddit.CreatorId {
+		return &messages.InviteModeratorResponse{Success: false, Error: "User is already a moderator"}
+	}
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.InviteModeratorResponse{Success: false, Error: "User not found"}
+	}
+	permissions, err := checkPermissions(msg.Permissions)
+	if err != nil {
+		return &messages.InviteModeratorResponse{Success: false, Error: err.Error()}
+	}
+
+	subreddit = subreddit.clone()
+	subreddit.ModInvites[msg.Username] = permissions
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.InviteModeratorResponse{Success: false, Error: "Failed to save invitation"}
+	}
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogInviteModerator,
+		TargetUser: msg.Username,
+		Details:    strings.Join(permissions, ","),
+	})
+	fmt.Printf("SubredditActor: %s invited %s to moderate %s with %v\n", msg.ModeratorId, msg.Username, msg.SubredditName, permissions)
+	return &messages.InviteModeratorResponse{Success: true}
+}
+
+func (state *SubredditActor) handleAcceptModeratorInvite(msg *messages.AcceptModeratorInvite) *messages.AcceptModeratorInviteResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.AcceptModeratorInviteResponse{Success: false, Error: "Subreddit not found"}
+	}
+	permissions, invited := subreddit.ModInvites[msg.Username]
+	if !invited {
+		return &messages.AcceptModeratorInviteResponse{Success: false, Error: "No moderator invitation for this user"}
+	}
+
+	subreddit = subreddit.clone()
+	delete(subreddit.ModInvites, msg.Username)
+	subreddit.Moderators[msg.Username] = permissions
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.AcceptModeratorInviteResponse{Success: false, Error: "Failed to save moderator"}
+	}
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator:  msg.Username,
+		Action:     messages.ModLogAddModerator,
+		TargetUser: msg.Username,
+		Details:    strings.Join(permissions, ","),
+	})
+	fmt.Printf("SubredditActor: %s now moderates %s\n", msg.Username, msg.SubredditName)
+	return &messages.AcceptModeratorInviteResponse{Success: true}
+}
+
+func (state *SubredditActor) handleSetModeratorPermissions(msg *messages.SetModeratorPermissions) *messages.SetModeratorPermissionsResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.SetModeratorPermissionsResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermAll) {
+		return &messages.SetModeratorPermissionsResponse{Success: false, Error: "Not authorized to manage moderators"}
+	}
+	if msg.Username == subreddit.CreatorId {
+		return &messages.SetModeratorPermissionsResponse{Success: false, Error: "The creator's permissions can't be changed"}
+	}
+	if _, isModerator := subreddit.Moderators[msg.Username]; !isModerator {
+		return &messages.SetModeratorPermissionsResponse{Success: false, Error: "User is not a moderator"}
+	}
+	permissions, err := checkPermissions(msg.Permissions)
+	if err != nil {
+		return &messages.SetModeratorPermissionsResponse{Success: false, Error: err.Error()}
+	}
+
+	subreddit = subreddit.clone()
+	subreddit.Moderators[msg.Username] = permissions
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.SetModeratorPermissionsResponse{Success: false, Error: "Failed to save moderator"}
+	}
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogSetPermissions,
+		TargetUser: msg.Username,
+		Details:    strings.Join(permissions, ","),
+	})
+	return &messages.SetModeratorPermissionsResponse{Success: true}
+}
+
+func (state *SubredditActor) handleRemoveModerator(msg *messages.RemoveModerator) *messages.RemoveModeratorResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.RemoveModeratorResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if msg.ModeratorId != msg.Username && !subreddit.permits(msg.ModeratorId, messages.ModPermAll) {
+		return &messages.RemoveModeratorResponse{Success: false, Error: "Not authorized to manage moderators"}
+	}
+	if msg.Username == subreddit.CreatorId {
+		return &messages.RemoveModeratorResponse{Success: false, Error: "The creator can't be removed"}
+	}
+	_, isModerator := subreddit.Moderators[msg.Username]
+	_, invited := subreddit.ModInvites[msg.Username]
+	if !isModerator && !invited {
+		return &messages.RemoveModeratorResponse{Success: false, Error: "User is not a moderator"}
+	}
+
+	subreddit = subreddit.clone()
+	delete(subreddit.Moderators, msg.Username)
+	delete(subreddit.ModInvites, msg.Username)
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.RemoveModeratorResponse{Success: false, Error: "Failed to save moderators"}
+	}
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogRemoveModerator,
+		TargetUser: msg.Username,
+	})
+	fmt.Printf("SubredditActor: %s no longer moderates %s\n", msg.Username, msg.SubredditName)
+	return &messages.RemoveModeratorResponse{Success: true}
+}
+
+// handleGetModerators lists the creator first, then moderators and pending
+// invitations by name
+func (state *SubredditActor) handleGetModerators(msg *messages.GetModerators) *messages.GetModeratorsResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.GetModeratorsResponse{Success: false, Error: "Subreddit not found"}
+	}
+
+	moderators := []*messages.Moderator{{Username: subreddit.CreatorId, Permissions: []string{messages.ModPermAll}}}
+	others := make([]*messages.Moderator, 0, len(subreddit.Moderators)+len(subreddit.ModInvites))
+	for username, permissions := range subreddit.Moderators {
+		if username != subreddit.CreatorId {
+			others = append(others, &messages.Moderator{Username: username, Permissions: permissions})
+		}
+	}
+	for username, permissions := range subreddit.ModInvites {
+		others = append(others, &messages.Moderator{Username: username, Permissions: permissions, Pending: true})
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Username < others[j].Username })
+
+	return &messages.GetModeratorsResponse{
+		Success:    true,
+		Moderators: append(moderators, others...),
+	}
+}
+
+// Sanction is a ban or mute of one user in one subreddit
+type Sanction struct {
+	Reason    string
+	By        string
+	At        int64
+	ExpiresAt int64 // 0 if it doesn't expire
+}
+
+func (sanction *Sanction) activeAt(now time.Time) bool {
+	return sanction != nil && (sanction.ExpiresAt == 0 || sanction.ExpiresAt > now.Unix())
+}
+
+// Things a user can do in a subreddit, as checked by checkParticipation
+const (
+	participatePost = iota
+	participateComment
+	participateVote
+	participateJoin
+)
+
+// checkParticipation says why username may not take part in the subreddit
+// this way, or nil if they may. Bans block everything; mutes block posts and
+// comments; restricted subreddits only take posts from moderators and
+// approved submitters, and private ones only hear from their members.
+func (subreddit *Subreddit) checkParticipation(username string, action int, now time.Time) error {
+	if action != participateJoin && !subreddit.canView(username) {
+		return fmt.Errorf("This subreddit is private")
+	}
+	if ban := subreddit.Bans[username]; ban.activeAt(now) {
+		if ban.Reason != "" {
+			return fmt.Errorf("You are banned from this subreddit: %s", ban.Reason)
+		}
+		return fmt.Errorf("You are banned from this subreddit")
+	}
+	if action != participatePost && action != participateComment {
+		return nil
+	}
+	if subreddit.Mutes[username].activeAt(now) {
+		return fmt.Errorf("You are muted in this subreddit")
+	}
+	if action == participatePost && subreddit.Type == messages.SubredditRestricted &&
+		!subreddit.ApprovedSubmitters[username] && !subreddit.permits(username, messages.ModPermPosts) {
+		return fmt.Errorf("Only approved users can post in this subreddit")
+	}
+	return nil
+}
+
+// canView says whether username may read the subreddit's posts and comments:
+// anyone in public and restricted subreddits, only members and moderators in
+// private ones
+func (subreddit *Subreddit) canView(username string) bool {
+	return subreddit.Type != messages.SubredditPrivate || subreddit.Members[username] || subreddit.isModerator(username)
+}
+
+// canViewSubreddit is canView for actors that don't own the subreddit. Posts
+// outliving their subreddit stay visible.
+func canViewSubreddit(store *Store, subredditName, username string) bool {
+	subreddit, exists := store.Subreddits.Get(subredditName)
+	return !exists || subreddit.canView(username)
+}
+
+// checkSubredditType fills in the default, public, and rejects anything else
+// unknown
+func checkSubredditType(subredditType string) (string, error) {
+	switch subredditType {
+	case "":
+		return messages.SubredditPublic, nil
+	case messages.SubredditPublic, messages.SubredditRestricted, messages.SubredditPrivate:
+		return subredditType, nil
+	}
+	return "", fmt.Errorf("Unknown subreddit type %q, want public, restricted or private", subredditType)
+}
+
+// checkSubredditParticipation is checkParticipation for actors that don't own
+// the subreddit
+func checkSubredditParticipation(store *Store, subredditName, username string, action int) error {
+	subreddit, exists := store.Subreddits.Get(subredditName)
+	if !exists {
+		return fmt.Errorf("Subreddit not found")
+	}
+	return subreddit.checkParticipation(username, action, time.Now())
+}
+
+// sanctionDetails describes a ban or mute for the mod log
+func sanctionDetails(reason string, days int) string {
+	length := "permanent"
+	if days == 1 {
+		length = "1 day"
+	} else if days > 1 {
+		length = fmt.Sprintf("%d days", days)
+	}
+	if reason == "" {
+		return length
+	}
+	return reason + " (" + length + ")"
+}
+
+// Which of a Subreddit's sanction lists sanction and lift change
+const (
+	sanctionBans  = "bans"
+	sanctionMutes = "mutes"
+)
+
+// sanction bans or mutes username for days, 0 meaning until lifted.
+// Moderators can't be sanctioned in their own subreddit.
+func (state *SubredditActor) sanction(subredditName, moderatorId, username, list, reason string, days int) error {
+	subreddit, exists := state.store.Subreddits.Get(subredditName)
+	if !exists {
+		return fmt.Errorf("Subreddit not found")
+	}
+	if !subreddit.permits(moderatorId, messages.ModPermUsers) {
+		return fmt.Errorf("Not authorized to manage users")
+	}
+	if subreddit.isModerator(username) {
+		return fmt.Errorf("Moderators can't be banned or muted")
+	}
+	if !state.store.Users.Has(username) {
+		return fmt.Errorf("User not found")
+	}
+	if days < 0 {
+		return fmt.Errorf("Days must not be negative")
+	}
+
+	now := time.Now()
+	sanction := &Sanction{Reason: reason, By: moderatorId, At: now.Unix()}
+	if days > 0 {
+		sanction.ExpiresAt = now.AddDate(0, 0, days).Unix()
+	}
+
+	subreddit = subreddit.clone()
+	if list == sanctionBans {
+		subreddit.Bans[username] = sanction
+	} else {
+		subreddit.Mutes[username] = sanction
+	}
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return fmt.Errorf("Failed to save subreddit")
+	}
+	fmt.Printf("SubredditActor: %s put %s on the %s list of %s\n", moderatorId, username, list, subredditName)
+	return nil
+}
+
+// lift ends a ban or mute early
+func (state *SubredditActor) lift(subredditName, moderatorId, username, list string) error {
+	subreddit, exists := state.store.Subreddits.Get(subredditName)
+	if !exists {
+		return fmt.Errorf("Subreddit not found")
+	}
+	if !subreddit.permits(moderatorId, messages.ModPermUsers) {
+		return fmt.Errorf("Not authorized to manage users")
+	}
+
+	sanctions := subreddit.Bans
+	if list == sanctionMutes {
+		sanctions = subreddit.Mutes
+	}
+	if _, exists := sanctions[username]; !exists {
+		if list == sanctionBans {
+			return fmt.Errorf("User is not banned")
+		}
+		return fmt.Errorf("User is not muted")
+	}
+
+	subreddit = subreddit.clone()
+	if list == sanctionBans {
+		delete(subreddit.Bans, username)
+	} else {
+		delete(subreddit.Mutes, username)
+	}
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return fmt.Errorf("Failed to save subreddit")
+	}
+	return nil
+}
+
+func (state *SubredditActor) handleBanUser(msg *messages.BanUser) *messages.BanUserResponse {
+	if err := state.sanction(msg.SubredditName, msg.ModeratorId, msg.Username, sanctionBans, msg.Reason, msg.Days); err != nil {
+		return &messages.BanUserResponse{Success: false, Error: err.Error()}
+	}
+	logModAction(state.store, msg.SubredditName, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogBanUser,
+		TargetUser: msg.Username,
+		Details:    sanctionDetails(msg.Reason, msg.Days),
+	})
+	return &messages.BanUserResponse{Success: true}
+}
+
+func (state *SubredditActor) handleUnbanUser(msg *messages.UnbanUser) *messages.UnbanUserResponse {
+	if err := state.lift(msg.SubredditName, msg.ModeratorId, msg.Username, sanctionBans); err != nil {
+		return &messages.UnbanUserResponse{Success: false, Error: err.Error()}
+	}
+	logModAction(state.store, msg.SubredditName, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogUnbanUser,
+		TargetUser: msg.Username,
+	})
+	return &messages.UnbanUserResponse{Success: true}
+}
+
+func (state *SubredditActor) handleMuteUser(msg *messages.MuteUser) *messages.MuteUserResponse {
+	if err := state.sanction(msg.SubredditName, msg.ModeratorId, msg.Username, sanctionMutes, msg.Reason, msg.Days); err != nil {
+		return &messages.MuteUserResponse{Success: false, Error: err.Error()}
+	}
+	logModAction(state.store, msg.SubredditName, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogMuteUser,
+		TargetUser: msg.Username,
+		Details:    sanctionDetails(msg.Reason, msg.Days),
+	})
+	return &messages.MuteUserResponse{Success: true}
+}
+
+func (state *SubredditActor) handleUnmuteUser(msg *messages.UnmuteUser) *messages.UnmuteUserResponse {
+	if err := state.lift(msg.SubredditName, msg.ModeratorId, msg.Username, sanctionMutes); err != nil {
+		return &messages.UnmuteUserResponse{Success: false, Error: err.Error()}
+	}
+	logModAction(state.store, msg.SubredditName, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     messages.ModLogUnmuteUser,
+		TargetUser: msg.Username,
+	})
+	return &messages.UnmuteUserResponse{Success: true}
+}
+
+func (state *SubredditActor) handleSetApprovedSubmitter(msg *messages.SetApprovedSubmitter) *messages.SetApprovedSubmitterResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.SetApprovedSubmitterResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermUsers) {
+		return &messages.SetApprovedSubmitterResponse{Success: false, Error: "Not authorized to manage users"}
+	}
+	if msg.Approved && !state.store.Users.Has(msg.Username) {
+		return &messages.SetApprovedSubmitterResponse{Success: false, Error: "User not found"}
+	}
+
+	subreddit = subreddit.clone()
+	if msg.Approved {
+		subreddit.ApprovedSubmitters[msg.Username] = true
+	} else {
+		delete(subreddit.ApprovedSubmitters, msg.Username)
+	}
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.SetApprovedSubmitterResponse{Success: false, Error: "Failed to save subreddit"}
+	}
+	action := messages.ModLogApproveSubmitter
+	if !msg.Approved {
+		action = messages.ModLogRemoveSubmitter
+	}
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     action,
+		TargetUser: msg.Username,
+	})
+	return &messages.SetApprovedSubmitterResponse{Success: true}
+}
+
+// handleGetSubredditUsers lists one of the user lists, only to moderators
+// with the users permission, by name
+func (state *SubredditActor) handleGetSubredditUsers(msg *messages.GetSubredditUsers) *messages.GetSubredditUsersResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.GetSubredditUsersResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermUsers) {
+		return &messages.GetSubredditUsersResponse{Success: false, Error: "Not authorized to manage users"}
+	}
+
+	now := time.Now()
+	users := make([]*messages.SubredditUser, 0)
+	switch msg.List {
+	case messages.UserListBanned, messages.UserListMuted:
+		sanctions := subreddit.Bans
+		if msg.List == messages.UserListMuted {
+			sanctions = subreddit.Mutes
+		}
+		for username, sanction := range sanctions {
+			if sanction.activeAt(now) {
+				users = append(users, &messages.SubredditUser{
+					Username:  username,
+					Reason:    sanction.Reason,
+					By:        sanction.By,
+					At:        sanction.At,
+					ExpiresAt: sanction.ExpiresAt,
+				})
+			}
+		}
+	case messages.UserListApproved:
+		for username := range subreddit.ApprovedSubmitters {
+			users = append(users, &messages.SubredditUser{Username: username})
+		}
+	default:
+		return &messages.GetSubredditUsersResponse{Success: false, Error: "List must be banned, muted or approved"}
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return &messages.GetSubredditUsersResponse{Success: true, Users: users}
+}
+
+// handleGetJoinRequests lists who is waiting to join, oldest first
+func (state *SubredditActor) handleGetJoinRequests(msg *messages.GetJoinRequests) *messages.GetJoinRequestsResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.GetJoinRequestsResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermUsers) {
+		return &messages.GetJoinRequestsResponse{Success: false, Error: "Not authorized to manage users"}
+	}
+
+	requests := make([]*messages.JoinRequest, 0, len(subreddit.JoinRequests))
+	for username, at := range subreddit.JoinRequests {
+		requests = append(requests, &messages.JoinRequest{Username: username, At: at})
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].At != requests[j].At {
+			return requests[i].At < requests[j].At
+		}
+		return requests[i].Username < requests[j].Username
+	})
+	return &messages.GetJoinRequestsResponse{Success: true, Requests: requests}
+}
+
+// handleRespondJoinRequest takes the user off the queue, making them a member
+// if accepted. A denied user may ask again.
+func (state *SubredditActor) handleRespondJoinRequest(msg *messages.RespondJoinRequest) *messages.RespondJoinRequestResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+	if !exists {
+		return &messages.RespondJoinRequestResponse{Success: false, Error: "Subreddit not found"}
+	}
+	if !subreddit.permits(msg.ModeratorId, messages.ModPermUsers) {
+		return &messages.RespondJoinRequestResponse{Success: false, Error: "Not authorized to manage users"}
+	}
+	if _, pending := subreddit.JoinRequests[msg.Username]; !pending {
+		return &messages.RespondJoinRequestResponse{Success: false, Error: "No pending join request from this user"}
+	}
+
+	subreddit = subreddit.clone()
+	delete(subreddit.JoinRequests, msg.Username)
+	if msg.Accept {
+		subreddit.Members[msg.Username] = true
+	}
+	if err := state.store.Subreddits.Put(subreddit.Name, subreddit); err != nil {
+		return &messages.RespondJoinRequestResponse{Success: false, Error: "Failed to save subreddit"}
+	}
+	action := messages.ModLogDenyJoinRequest
+	if msg.Accept {
+		action = messages.ModLogAcceptJoinRequest
+		state.store.SubredditIndex.Add(subredditDocument(subreddit))
+	}
+	logModAction(state.store, subreddit.Name, &ModAction{
+		Moderator:  msg.ModeratorId,
+		Action:     action,
+		TargetUser: msg.Username,
+	})
+	return &messages.RespondJoinRequestResponse{Success: true}
+}
@@ -0,0 +1,216 @@
+package actors
+
+import (
+	"reddit/messages"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModeratorInvitations(t *testing.T) {
+	store := newTestStore(t, "owner", "helper", "stranger")
+	state := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "golang", nil)
+
+	invite := &messages.InviteModerator{SubredditName: "golang", ModeratorId: "stranger", Username: "helper", Permissions: []string{messages.ModPermPosts}}
+	if response := state.handleInviteModerator(invite); response.Success {
+		t.Fatalf("a non-moderator could invite moderators")
+	}
+	invite.ModeratorId = "owner"
+	invite.Permissions = []string{"delete-everything"}
+	if response := state.handleInviteModerator(invite); response.Success {
+		t.Fatalf("an unknown permission was accepted")
+	}
+	invite.Permissions = []string{messages.ModPermPosts, messages.ModPermPosts}
+	if response := state.handleInviteModerator(invite); !response.Success {
+		t.Fatalf("handleInviteModerator() error = %s", response.Error)
+	}
+
+	if canModerate(store, "golang", "helper", messages.ModPermPosts) {
+		t.Fatalf("an invitation granted permissions before it was accepted")
+	}
+	if response := state.handleAcceptModeratorInvite(&messages.AcceptModeratorInvite{SubredditName: "golang", Username: "helper"}); !response.Success {
+		t.Fatalf("handleAcceptModeratorInvite() error = %s", response.Error)
+	}
+	if !canModerate(store, "golang", "helper", messages.ModPermPosts) {
+		t.Errorf("helper can't remove posts after accepting")
+	}
+	if canModerate(store, "golang", "helper", messages.ModPermComments) {
+		t.Errorf("helper can remove comments without the permission")
+	}
+
+	moderators := state.handleGetModerators(&messages.GetModerators{SubredditName: "golang"}).Moderators
+	if len(moderators) != 2 || moderators[0].Username != "owner" || len(moderators[1].Permissions) != 1 {
+		t.Errorf("moderators = %+v, want owner then helper with posts only", moderators)
+	}
+
+	remove := &messages.RemoveModerator{SubredditName: "golang", ModeratorId: "helper", Username: "owner"}
+	if response := state.handleRemoveModerator(remove); response.Success {
+		t.Errorf("a moderator without all removed the creator")
+	}
+	remove.Username = "helper"
+	if response := state.handleRemoveModerator(remove); !response.Success {
+		t.Errorf("a moderator couldn't step down: %s", response.Error)
+	}
+	if canModerate(store, "golang", "helper", messages.ModPermPosts) {
+		t.Errorf("helper still moderates after stepping down")
+	}
+}
+
+func TestSanctions(t *testing.T) {
+	store := newTestStore(t, "owner", "troll", "regular")
+	state := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Type = messages.SubredditRestricted
+	})
+	participation := func(username string, action int) error {
+		return checkSubredditParticipation(store, "golang", username, action)
+	}
+
+	if err := participation("regular", participatePost); err == nil {
+		t.Errorf("an unapproved user posted in a restricted subreddit")
+	}
+	if response := state.handleSetApprovedSubmitter(&messages.SetApprovedSubmitter{SubredditName: "golang", ModeratorId: "owner", Username: "regular", Approved: true}); !response.Success {
+		t.Fatalf("handleSetApprovedSubmitter() error = %s", response.Error)
+	}
+	if err := participation("regular", participatePost); err != nil {
+		t.Errorf("an approved submitter can't post: %v", err)
+	}
+
+	if response := state.handleBanUser(&messages.BanUser{SubredditName: "golang", ModeratorId: "troll", Username: "owner"}); response.Success {
+		t.Fatalf("a non-moderator banned the creator")
+	}
+	if response := state.handleMuteUser(&messages.MuteUser{SubredditName: "golang", ModeratorId: "owner", Username: "troll", Days: 1}); !response.Success {
+		t.Fatalf("handleMuteUser() error = %s", response.Error)
+	}
+	if err := participation("troll", participateComment); err == nil {
+		t.Errorf("a muted user commented")
+	}
+	if err := participation("troll", participateVote); err != nil {
+		t.Errorf("a muted user can't vote: %v", err)
+	}
+	if response := state.handleBanUser(&messages.BanUser{SubredditName: "golang", ModeratorId: "owner", Username: "troll", Reason: "spam"}); !response.Success {
+		t.Fatalf("handleBanUser() error = %s", response.Error)
+	}
+	if err := participation("troll", participateVote); err == nil {
+		t.Errorf("a banned user voted")
+	}
+
+	subreddit, _ := store.Subreddits.Get("golang")
+	if subreddit.checkParticipation("troll", participateJoin, time.Now()) == nil {
+		t.Errorf("a banned user joined")
+	}
+	if subreddit.Mutes["troll"].activeAt(time.Now().Add(48 * time.Hour)) {
+		t.Errorf("a one-day mute is still active two days later")
+	}
+
+	banned := state.handleGetSubredditUsers(&messages.GetSubredditUsers{SubredditName: "golang", ModeratorId: "owner", List: messages.UserListBanned}).Users
+	if len(banned) != 1 || banned[0].Username != "troll" || banned[0].Reason != "spam" {
+		t.Errorf("banned = %+v, want troll for spam", banned)
+	}
+	if response := state.handleUnbanUser(&messages.UnbanUser{SubredditName: "golang", ModeratorId: "owner", Username: "troll"}); !response.Success {
+		t.Fatalf("handleUnbanUser() error = %s", response.Error)
+	}
+	if err := participation("troll", participateVote); err != nil {
+		t.Errorf("an unbanned user can't vote: %v", err)
+	}
+}
+
+func TestPrivateSubreddits(t *testing.T) {
+	store := newTestStore(t)
+	state := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "secret", func(subreddit *Subreddit) {
+		subreddit.Type = messages.SubredditPrivate
+		subreddit.JoinRequests = map[string]int64{"late": 20, "early": 10}
+	})
+
+	if canViewSubreddit(store, "secret", "early") || !canViewSubreddit(store, "secret", "owner") {
+		t.Fatalf("only moderators should see a private subreddit with no members")
+	}
+	if err := checkSubredditParticipation(store, "secret", "early", participateVote); err == nil {
+		t.Errorf("an outsider voted in a private subreddit")
+	}
+
+	requests := state.handleGetJoinRequests(&messages.GetJoinRequests{SubredditName: "secret", ModeratorId: "owner"}).Requests
+	if len(requests) != 2 || requests[0].Username != "early" {
+		t.Fatalf("requests = %+v, want early then late", requests)
+	}
+	accept := &messages.RespondJoinRequest{SubredditName: "secret", ModeratorId: "early", Username: "early", Accept: true}
+	if response := state.handleRespondJoinRequest(accept); response.Success {
+		t.Fatalf("a user accepted their own join request")
+	}
+	accept.ModeratorId = "owner"
+	if response := state.handleRespondJoinRequest(accept); !response.Success {
+		t.Fatalf("handleRespondJoinRequest() error = %s", response.Error)
+	}
+	deny := &messages.RespondJoinRequest{SubredditName: "secret", ModeratorId: "owner", Username: "late"}
+	if response := state.handleRespondJoinRequest(deny); !response.Success {
+		t.Fatalf("handleRespondJoinRequest() error = %s", response.Error)
+	}
+
+	if !canViewSubreddit(store, "secret", "early") {
+		t.Errorf("an accepted member can't see the subreddit")
+	}
+	if canViewSubreddit(store, "secret", "late") {
+		t.Errorf("a denied user can see the subreddit")
+	}
+
+	edit := &messages.EditSubreddit{Name: "secret", AuthorId: "owner", Type: messages.SubredditPublic}
+	if response := state.handleEdit(edit); !response.Success {
+		t.Fatalf("handleEdit() error = %s", response.Error)
+	}
+	if !canViewSubreddit(store, "secret", "late") {
+		t.Errorf("a public subreddit is still hidden")
+	}
+}
+
+func TestModeratorDeletesRemove(t *testing.T) {
+	store := newTestStore(t, "owner", "bob", "carol")
+	ask := newTestEngine(t, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Members = map[string]bool{"bob": true, "carol": true}
+	})
+	store.Posts.Put("post", &StoredPost{PostId: "post", SubredditName: "golang", AuthorId: "bob", Votes: map[string]bool{}})
+	appendID(store.SubredditPosts, "golang", "post")
+	comment := ask(&messages.CreateComment{PostId: "post", AuthorId: "bob", Content: "first"}).(*messages.CreateCommentResponse)
+	reply := ask(&messages.CreateComment{PostId: "post", ParentId: comment.CommentId, AuthorId: "carol", Content: "second"}).(*messages.CreateCommentResponse)
+	if !comment.Success || !reply.Success {
+		t.Fatalf("commenting = %+v, %+v", comment, reply)
+	}
+	if response := ask(&messages.Vote{UserID: "bob", TargetID: reply.CommentId, IsUpvote: true, Type: "comment"}).(*messages.VoteResponse); !response.Success {
+		t.Fatalf("Vote = %+v", response)
+	}
+
+	if response := ask(&messages.DeleteComment{CommentId: comment.CommentId, AuthorId: "owner"}).(*messages.DeleteCommentResponse); !response.Success {
+		t.Fatalf("DeleteComment = %+v", response)
+	}
+	if response := ask(&messages.DeletePost{PostId: "post", AuthorId: "owner"}).(*messages.DeletePostResponse); !response.Success {
+		t.Fatalf("DeletePost = %+v", response)
+	}
+
+	if post, exists := store.Posts.Get("post"); !exists || !post.Removed {
+		t.Errorf("post = %+v, want it removed", post)
+	}
+	if stored, exists := store.Comments.Get(comment.CommentId); !exists || !stored.Removed {
+		t.Errorf("comment = %+v, want it removed", stored)
+	}
+	if _, exists := store.Comments.Get(reply.CommentId); !exists {
+		t.Errorf("the reply went with the comment")
+	}
+	if history, _ := store.UserComments.Get("carol"); len(history) != 1 {
+		t.Errorf("carol's comments = %v, want her reply", history)
+	}
+	if karma := ask(&messages.GetKarma{UserID: "carol"}).(*messages.GetKarmaResponse); karma.CommentKarma != 1 {
+		t.Errorf("carol's comment karma = %d, want 1", karma.CommentKarma)
+	}
+
+	var logged []string
+	actions, _ := store.ModLog.Get("golang")
+	for _, action := range actions {
+		logged = append(logged, action.Action+" "+action.TargetUser)
+	}
+	want := []string{messages.ModLogRemoveComment + " bob", messages.ModLogRemovePost + " bob"}
+	if strings.Join(logged, ", ") != strings.Join(want, ", ") {
+		t.Errorf("mod log = %v, want %v", logged, want)
+	}
+}
@@ -0,0 +1,249 @@
+package actors
+
+import (
+	"fmt"
+	"reddit/messages"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
+)
+
+// maxNotifications is how many notifications each user keeps; the oldest go
+// first
+const maxNotifications = 500
+
+// maxMentions caps how many users one post or comment can notify by name, so
+// nobody can ping a crowd
+const maxMentions = 3
+
+// maxPreviewLength is how much of a post, comment or message a notification
+// quotes
+const maxPreviewLength = 100
+
+// mentionPattern finds u/username, but not inside links or other words
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_/.-])u/([A-Za-z0-9_-]{3,20})\b`)
+
+// StoredNotification is one entry in a user's inbox
+type StoredNotification struct {
+	NotificationId string
+	Username       string
+	Type           string
+	From           string
+	SubredditName  string
+	PostId         string
+	CommentId      string
+	MessageId      string
+	Preview        string
+	Timestamp      int64
+	Read           bool
+}
+
+type NotificationActor struct {
+	store *Store
+}
+
+func NewNotificationActor(store *Store) *NotificationActor {
+	return &NotificationActor{
+		store: store,
+	}
+}
+
+func (state *NotificationActor) Receive(context actor.Context) {
+	switch msg := context.Message().(type) {
+	case *messages.Notify:
+		state.handleNotify(msg)
+
+	case *messages.GetNotifications:
+		response := state.handleGet(msg)
+		context.Respond(response)
+
+	case *messages.MarkNotificationsRead:
+		response := state.handleMarkRead(msg)
+		context.Respond(response)
+	}
+}
+
+// notificationsOn says whether the user wants notifications of the type.
+// Every type is on until turned off.
+func notificationsOn(profile *UserProfile, notificationType string) bool {
+	return profile == nil || !profile.NotificationsOff[notificationType]
+}
+
+func isNotificationType(notificationType string) bool {
+	for _, known := range messages.NotificationTypes {
+		if notificationType == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (state *NotificationActor) handleNotify(msg *messages.Notify) {
+	if msg.Username == msg.From || !isNotificationType(msg.Type) || !state.store.Users.Has(msg.Username) {
+		return
+	}
+	if profile, _ := state.store.Profiles.Get(msg.Username); !notificationsOn(profile, msg.Type) {
+		return
+	}
+
+	notification := &StoredNotification{
+		NotificationId: uuid.New().String(),
+		Username:       msg.Username,
+		Type:           msg.Type,
+		From:           msg.From,
+		SubredditName:  msg.SubredditName,
+		PostId:         msg.PostId,
+		CommentId:      msg.CommentId,
+		MessageId:      msg.MessageId,
+		Preview:        msg.Preview,
+		Timestamp:      time.Now().Unix(),
+	}
+	if err := state.store.Notifications.Put(notification.NotificationId, notification); err != nil {
+		fmt.Printf("NotificationActor: Failed to save notification for %s: %v\n", msg.Username, err)
+		return
+	}
+
+	appendID(state.store.UserNotifications, msg.Username, notification.NotificationId)
+	dropped, _ := state.store.UserNotifications.Trim(msg.Username, maxNotifications)
+	for _, notificationId := range dropped {
+		state.store.Notifications.Delete(notificationId)
+	}
+}
+
+func (state *NotificationActor) handleGet(msg *messages.GetNotifications) *messages.GetNotificationsResponse {
+	listing := "notifications/" + msg.Username
+	if msg.UnreadOnly {
+		listing += "/unread"
+	}
+	pager, err := newPager(listing, msg.Page, time.Now().Unix())
+	if err != nil {
+		return &messages.GetNotificationsResponse{Success: false, Error: err.Error()}
+	}
+
+	response := &messages.GetNotificationsResponse{Success: true}
+	notifications := make([]*StoredNotification, 0)
+	ids, _ := state.store.UserNotifications.Get(msg.Username)
+	for _, notificationId := range ids {
+		notification, exists := state.store.Notifications.Get(notificationId)
+		if !exists {
+			continue
+		}
+		if !notification.Read {
+			response.UnreadCount++
+		} else if msg.UnreadOnly {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+
+	key := func(notification *StoredNotification) pageKey {
+		return pageKey{Timestamp: notification.Timestamp, ID: notification.NotificationId}
+	}
+	sort.Slice(notifications, func(i, j int) bool { return rankedBefore(key(notifications[i]), key(notifications[j])) })
+	paged, links := paginate(pager, notifications, key, rankedBefore)
+
+	response.Notifications = make([]*messages.Notification, 0, len(paged))
+	for _, notification := range paged {
+		response.Notifications = append(response.Notifications, &messages.Notification{
+			NotificationId: notification.NotificationId,
+			Type:           notification.Type,
+			From:           notification.From,
+			SubredditName:  notification.SubredditName,
+			PostId:         notification.PostId,
+			CommentId:      notification.CommentId,
+			MessageId:      notification.MessageId,
+			Preview:        notification.Preview,
+			Timestamp:      notification.Timestamp,
+			Read:           notification.Read,
+		})
+	}
+	response.PageLinks = links
+	return response
+}
+
+func (state *NotificationActor) handleMarkRead(msg *messages.MarkNotificationsRead) *messages.MarkNotificationsReadResponse {
+	ids := msg.NotificationIds
+	if msg.All {
+		ids, _ = state.store.UserNotifications.Get(msg.Username)
+	} else if len(ids) == 0 {
+		return &messages.MarkNotificationsReadResponse{Success: false, Error: "Name the notifications to mark, or mark all"}
+	}
+
+	marked := 0
+	for _, notificationId := range ids {
+		notification, exists := state.store.Notifications.Get(notificationId)
+		if !exists || notification.Username != msg.Username {
+			if !msg.All {
+				return &messages.MarkNotificationsReadResponse{Success: false, Error: "Notification not found", Marked: marked}
+			}
+			continue
+		}
+		if notification.Read == msg.Read {
+			continue
+		}
+
+		updated := *notification
+		updated.Read = msg.Read
+		if err := state.store.Notifications.Put(updated.NotificationId, &updated); err != nil {
+			return &messages.MarkNotificationsReadResponse{Success: false, Error: "Failed to save notification", Marked: marked}
+		}
+		marked++
+	}
+	return &messages.MarkNotificationsReadResponse{Success: true, Marked: marked}
+}
+
+// sendNotification passes a notification to the engine without waiting
+func sendNotification(context actor.Context, notification *messages.Notify) {
+	if context.Parent() == nil {
+		return
+	}
+	context.Send(context.Parent(), notification)
+}
+
+// notifyMentions tells the first few users named with u/username in text,
+// leaving out those in skip and any who can't see the subreddit. notification
+// is filled in for each of them.
+func notifyMentions(context actor.Context, store *Store, text string, skip map[string]bool, notification messages.Notify) {
+	notification.Type = messages.NotifyMention
+	for _, username := range mentionedUsers(store, text) {
+		if skip[username] || !canViewSubreddit(store, notification.SubredditName, username) {
+			continue
+		}
+		mention := notification
+		mention.Username = username
+		sendNotification(context, &mention)
+	}
+}
+
+// mentionedUsers returns the existing users named in text, in order and at
+// most maxMentions of them. Names match regardless of case.
+func mentionedUsers(store *Store, text string) []string {
+	found := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username, exists := store.Usernames.Get(strings.ToLower(match[1]))
+		if !exists || seen[username] || !store.Users.Has(username) {
+			continue
+		}
+		seen[username] = true
+		found = append(found, username)
+		if len(found) == maxMentions {
+			break
+		}
+	}
+	return found
+}
+
+// notificationPreview quotes the start of text
+func notificationPreview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxPreviewLength {
+		return text
+	}
+	return string([]rune(text)[:maxPreviewLength-1]) + "…"
+}
@@ -0,0 +1,58 @@
+package actors
+
+import (
+	"reddit/messages"
+	"reflect"
+	"testing"
+)
+
+func TestMentionedUsers(t *testing.T) {
+	store := NewMemoryStore()
+	for _, username := range []string{"alice", "Bob", "carol", "dave"} {
+		store.Users.Put(username, "hash")
+		claimUsername(store, username)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Any case", "thanks u/ALICE and u/bob", []string{"alice", "Bob"}},
+		{"Each user once", "u/alice u/alice", []string{"alice"}},
+		{"Not in links", "see https://example.com/u/alice", []string{}},
+		{"Unknown users", "u/nobody", []string{}},
+		{"At most three", "u/alice u/bob u/carol u/dave", []string{"alice", "Bob", "carol"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mentionedUsers(store, tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mentionedUsers(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	store := NewMemoryStore()
+	state := NewNotificationActor(store)
+	store.Users.Put("gopher", "hash")
+	store.Profiles.Put("gopher", &UserProfile{NotificationsOff: map[string]bool{messages.NotifyMention: true}})
+
+	state.handleNotify(&messages.Notify{Username: "gopher", Type: messages.NotifyMention, From: "someone"})
+	state.handleNotify(&messages.Notify{Username: "gopher", Type: messages.NotifyPostReply, From: "gopher"})
+	state.handleNotify(&messages.Notify{Username: "gopher", Type: messages.NotifyMessage, From: "someone", Preview: "hi"})
+
+	response := state.handleGet(&messages.GetNotifications{Username: "gopher"})
+	if len(response.Notifications) != 1 || response.Notifications[0].Type != messages.NotifyMessage || response.UnreadCount != 1 {
+		t.Fatalf("handleGet() = %+v, want only the message, unread", response)
+	}
+
+	marked := state.handleMarkRead(&messages.MarkNotificationsRead{Username: "gopher", All: true, Read: true})
+	if !marked.Success || marked.Marked != 1 {
+		t.Errorf("handleMarkRead() = %+v, want 1 marked", marked)
+	}
+	if response := state.handleGet(&messages.GetNotifications{Username: "gopher", UnreadOnly: true}); len(response.Notifications) != 0 || response.UnreadCount != 0 {
+		t.Errorf("unread after marking all read = %+v", response)
+	}
+}
@@ -0,0 +1,152 @@
+package actors
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reddit/messages"
+)
+
+const (
+	defaultPageLimit = 25
+	maxPageLimit     = 100
+)
+
+var errBadCursor = errors.New("Invalid cursor")
+
+// pageKey is where an item sits in a listing. Cursors hold the key of the
+// item at the edge of a page rather than an offset, so items inserted or
+// removed elsewhere don't shift what the next page shows.
+type pageKey struct {
+	Score     float64 `json:"s,omitempty"`
+	Timestamp int64   `json:"t,omitempty"`
+	ID        string  `json:"i"`
+}
+
+// pageCursor is what the opaque after/before strings decode to. Listing names
+// the ordering the cursor belongs to and At is when the first page was
+// ranked, so scores that drift with time rank every page the same way.
+type pageCursor struct {
+	Listing string  `json:"l"`
+	At      int64   `json:"a"`
+	Key     pageKey `json:"k"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, errBadCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Key.ID == "" {
+		return cursor, errBadCursor
+	}
+	return cursor, nil
+}
+
+// pager slices one ordered listing into pages. Build it with newPager before
+// ranking, since the cursor decides the time the listing is ranked at.
+type pager struct {
+	listing string
+	page    messages.Page
+	cursor  *pageCursor
+	at      int64
+}
+
+// newPager checks the page parameters against the listing. now is used as the
+// ranking time unless the cursor carries an earlier one.
+func newPager(listing string, page messages.Page, now int64) (*pager, error) {
+	if page.After != "" && page.Before != "" {
+		return nil, errors.New("Pass either after or before, not both")
+	}
+	if page.Limit < 0 || page.Limit > maxPageLimit {
+		return nil, errors.New("Limit must be between 1 and 100")
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultPageLimit
+	}
+
+	p := &pager{listing: listing, page: page, at: now}
+	encoded := page.After
+	if encoded == "" {
+		encoded = page.Before
+	}
+	if encoded != "" {
+		cursor, err := decodeCursor(encoded)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Listing != listing {
+			return nil, errors.New("Cursor belongs to a different listing")
+		}
+		p.cursor = &cursor
+		p.at = cursor.At
+	}
+	return p, nil
+}
+
+// paginate returns the page of items, already in listing order, and the links
+// to its neighbours. before reports whether key a comes ahead of key b.
+func paginate[T any](p *pager, items []T, key func(T) pageKey, before func(a, b pageKey) bool) ([]T, messages.PageLinks) {
+	start, end := 0, len(items)
+	if p.cursor != nil {
+		// First item past the cursor, wherever the cursor's own item went
+		split := len(items)
+		for i, item := range items {
+			if before(p.cursor.Key, key(item)) {
+				split = i
+				break
+			}
+		}
+		if p.page.After != "" {
+			start = split
+		} else {
+			// Items ahead of the cursor, minus the cursor's item if it's still there
+			end = split
+			if end > 0 && !before(key(items[end-1]), p.cursor.Key) {
+				end--
+			}
+		}
+	}
+
+	if p.page.Before != "" {
+		if end-start > p.page.Limit {
+			start = end - p.page.Limit
+		}
+	} else if end-start > p.page.Limit {
+		end = start + p.page.Limit
+	}
+
+	var links messages.PageLinks
+	if start > 0 && start < len(items) {
+		links.Prev = encodeCursor(pageCursor{Listing: p.listing, At: p.at, Key: key(items[start])})
+	}
+	if end < len(items) && end > 0 {
+		links.Next = encodeCursor(pageCursor{Listing: p.listing, At: p.at, Key: key(items[end-1])})
+	}
+	return items[start:end], links
+}
+
+// rankedBefore orders by score, then newest first, then by ID
+func rankedBefore(a, b pageKey) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Timestamp != b.Timestamp {
+		return a.Timestamp > b.Timestamp
+	}
+	return a.ID > b.ID
+}
+
+// oldestBefore orders oldest first, then by ID
+func oldestBefore(a, b pageKey) bool {
+	if a.Timestamp != b.Timestamp {
+		return a.Timestamp < b.Timestamp
+	}
+	return a.ID < b.ID
+}
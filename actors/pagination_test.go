@@ -0,0 +1,62 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	keys := []pageKey{{Timestamp: 1, ID: "a"}, {Timestamp: 2, ID: "b"}, {Timestamp: 3, ID: "c"}, {Timestamp: 4, ID: "d"}, {Timestamp: 5, ID: "e"}}
+	key := func(k pageKey) pageKey { return k }
+	page := func(items []pageKey, request messages.Page) ([]string, messages.PageLinks) {
+		t.Helper()
+		p, err := newPager("test", request, 0)
+		if err != nil {
+			t.Fatalf("newPager(%+v) error = %v", request, err)
+		}
+		paged, links := paginate(p, items, key, oldestBefore)
+		ids := make([]string, len(paged))
+		for i, k := range paged {
+			ids[i] = k.ID
+		}
+		return ids, links
+	}
+	expect := func(got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("page = %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("page = %v, want %v", got, want)
+			}
+		}
+	}
+
+	first, links := page(keys, messages.Page{Limit: 2})
+	expect(first, "a", "b")
+	if links.Prev != "" || links.Next == "" {
+		t.Fatalf("first page links = %+v, want only next", links)
+	}
+
+	// Items arriving ahead of the cursor don't shift the next page
+	grown := append([]pageKey{{Timestamp: 0, ID: "z"}}, keys...)
+	second, links := page(grown, messages.Page{After: links.Next, Limit: 2})
+	expect(second, "c", "d")
+
+	back, _ := page(grown, messages.Page{Before: links.Prev, Limit: 2})
+	expect(back, "a", "b")
+
+	last, links := page(grown, messages.Page{After: links.Next, Limit: 2})
+	expect(last, "e")
+	if links.Next != "" {
+		t.Errorf("last page next = %q, want none", links.Next)
+	}
+
+	if _, err := newPager("other", messages.Page{After: links.Prev}, 0); err == nil {
+		t.Errorf("newPager() accepted a cursor from another listing")
+	}
+	if _, err := newPager("test", messages.Page{After: "not-a-cursor"}, 0); err == nil {
+		t.Errorf("newPager() accepted a malformed cursor")
+	}
+}
@@ -4,35 +4,132 @@ import (
 	"fmt"
 	"reddit/messages"
 	"strings"
-	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/google/uuid"
 )
 
+// maxSlugLength caps the title part of post URLs
+const maxSlugLength = 50
+
 type StoredPost struct {
 	PostId        string
 	Title         string
 	Content       string
 	AuthorId      string
 	SubredditName string
-	Timestamp     int64
+	Flair         string
+	Timestamp     int64           // Unix seconds the post was created
+	EditedAt      int64           // Unix seconds of the last edit, 0 if never edited
+	Votes         map[string]bool // username -> isUpvote
+	Moderation
 }
 
-// Global shared state for all post actors
-var (
-	globalPosts = make(map[string]*StoredPost)
-	subredditPosts = make(map[string][]string)  // subredditName -> []postId
-	postMutex   sync.RWMutex
-)
+// clone copies the post so its owner can change it without racing readers
+// that still hold the stored value
+func (post *StoredPost) clone() *StoredPost {
+	copied := *post
+	copied.Votes = make(map[string]bool, len(post.Votes))
+	for userId, isUpvote := range post.Votes {
+		copied.Votes[userId] = isUpvote
+	}
+	copied.Moderation = post.Moderation.clone()
+	return &copied
+}
+
+// newPostID returns an opaque, unique post ID. UUIDv7 IDs start with the
+// creation time, so they sort in the order posts were made.
+func newPostID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// postSlug turns a title into the readable part of a post URL: lowercase
+// words joined by underscores, like Reddit's, cut at a word boundary
+func postSlug(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	slug := ""
+	for _, word := range words {
+		if slug == "" {
+			// A single overlong word still gets cut, on a rune boundary
+			for _, r := range word {
+				if len(slug)+utf8.RuneLen(r) > maxSlugLength {
+					break
+				}
+				slug += string(r)
+			}
+			continue
+		}
+		if len(slug)+1+len(word) > maxSlugLength {
+			break
+		}
+		slug += "_" + word
+	}
+	return slug
+}
+
+// toPost converts a stored post into the message form clients see. Removed
+// posts keep their title.
+func toPost(post *StoredPost, pid *actor.PID) *messages.Post {
+	return &messages.Post{
+		PostId:        post.PostId,
+		Title:         post.Title,
+		Content:       postContent(post),
+		AuthorId:      post.AuthorId,
+		SubredditName: post.SubredditName,
+		Flair:         post.Flair,
+		VoteCount:     calculateVotes(post.Votes),
+		Slug:          postSlug(post.Title),
+		Timestamp:     post.Timestamp,
+		EditedAt:      post.EditedAt,
+		Removed:       post.Removed,
+		ActorPID:      pid,
+	}
+}
+
+func postContent(post *StoredPost) string {
+	if post.Removed {
+		return removedPlaceholder
+	}
+	return post.Content
+}
+
+// toPostFeed converts a stored post, with its comment tree, into a feed entry
+func toPostFeed(store *Store, post *StoredPost) *messages.PostFeed {
+	postFeed := &messages.PostFeed{
+		PostId:        post.PostId,
+		Title:         post.Title,
+		Content:       postContent(post),
+		AuthorId:      post.AuthorId,
+		SubredditName: post.SubredditName,
+		Flair:         post.Flair,
+		VoteCount:     calculateVotes(post.Votes),
+		Slug:          postSlug(post.Title),
+		Timestamp:     post.Timestamp,
+		EditedAt:      post.EditedAt,
+		Removed:       post.Removed,
+	}
+
+	// Count the replies too, as the post page shows them
+	commentIds, _ := store.PostComments.Get(post.PostId)
+	postFeed.CommentCount = countComments(store, commentIds)
+	return postFeed
+}
 
 type PostActor struct {
 	system *actor.ActorSystem
+	store  *Store
 }
 
-func NewPostActor(system *actor.ActorSystem) *PostActor {
+func NewPostActor(system *actor.ActorSystem, store *Store) *PostActor {
 	return &PostActor{
 		system: system,
+		store:  store,
 	}
 }
 
@@ -42,13 +139,21 @@ func (state *PostActor) Receive(context actor.Context) {
 		
 		response := &messages.PostResponse{}
 		
-		// Generate post ID (you might want a better ID generation strategy)
-		postId := fmt.Sprintf("post_%s_%s", msg.SubredditName, msg.Title)
+		// The engine assigns the ID before routing the post here
+		postId := msg.PostId
+		if postId == "" {
+			postId = newPostID()
+		}
 		
-		postMutex.Lock()
-		if _, exists := globalPosts[postId]; exists {
+		if state.store.Posts.Has(postId) {
 			response.Success = false
 			response.Error = "Post already exists"
+		} else if err := checkSubredditParticipation(state.store, msg.SubredditName, msg.AuthorId, participatePost); err != nil {
+			response.Success = false
+			response.Error = err.Error()
+		} else if err := checkPostSettings(state.store, msg.SubredditName, msg.Flair); err != nil {
+			response.Success = false
+			response.Error = err.Error()
 		} else {
 			// Store the post
 			post := &StoredPost{
@@ -57,117 +162,168 @@ func (state *PostActor) Receive(context actor.Context) {
 				Content:       msg.Content,
 				AuthorId:      msg.AuthorId,
 				SubredditName: msg.SubredditName,
+				Flair:         msg.Flair,
+				Timestamp:     time.Now().Unix(),
+				Votes:         make(map[string]bool),
 			}
-			globalPosts[postId] = post
+			replies := state.autoModerate(post)
+			state.store.Posts.Put(postId, post)
+			state.store.PostIndex.Add(postDocument(post))
 			
-			subredditPosts[msg.SubredditName] = append(subredditPosts[msg.SubredditName], postId)
+			appendID(state.store.SubredditPosts, msg.SubredditName, postId)
+			appendID(state.store.UserPosts, msg.AuthorId, postId)
+			sendAutoModReplies(context, postId, "", replies)
+			if !post.Removed {
+				notifyMentions(context, state.store, post.Title+"\n"+post.Content, nil, messages.Notify{
+					From:          post.AuthorId,
+					SubredditName: post.SubredditName,
+					PostId:        postId,
+					Preview:       notificationPreview(post.Title),
+				})
+			}
 			
 			response.Success = true
 			response.PostId = postId
 			response.ActorPID = context.Self()
 		}
-		postMutex.Unlock()
 		
 		context.Respond(response)
 
 	case *messages.GetPost:
 		response := &messages.GetPostResponse{}
 		
-		postMutex.RLock()
-		if post, exists := globalPosts[msg.PostId]; exists {
+		// Posts in private subreddits don't exist as far as outsiders know
+		if post, exists := state.store.Posts.Get(msg.PostId); exists && canViewSubreddit(state.store, post.SubredditName, msg.UserId) {
 			response.Success = true
-			response.Post = &messages.Post{
-				PostId:        post.PostId,
-				Title:         post.Title,
-				Content:       post.Content,
-				AuthorId:      post.AuthorId,
-				SubredditName: post.SubredditName,
-				ActorPID:      context.Self(),
-			}
+			response.Post = toPost(post, context.Self())
 		} else {
 			response.Success = false
 			response.Error = "Post not found"
 		}
-		postMutex.RUnlock()
 		
 		context.Respond(response)
 
 	case *messages.ListSubredditPosts:
 		fmt.Printf("PostActor: Listing posts for subreddit %s\n", msg.SubredditName)
 		response := &messages.ListSubredditPostsResponse{}
-		response.Posts = make([]*messages.Post, 0)
-		
-		postMutex.RLock()
-		fmt.Printf("PostActor: Found %d total posts\n", len(globalPosts))
-		for _, post := range globalPosts {
-			if post.SubredditName == msg.SubredditName {
-				fmt.Printf("PostActor: Adding post %s to response\n", post.PostId)
-				response.Posts = append(response.Posts, &messages.Post{
-					PostId:        post.PostId,
-					Title:         post.Title,
-					Content:       post.Content,
-					AuthorId:      post.AuthorId,
-					SubredditName: post.SubredditName,
-					ActorPID:      context.Self(),
-				})
+
+		sortBy, period, err := checkRanking(msg.Sort, msg.Period)
+		if err != nil {
+			response.Success = false
+			response.Error = err.Error()
+			context.Respond(response)
+			return
+		}
+
+		if !canViewSubreddit(state.store, msg.SubredditName, msg.UserId) {
+			response.Success = false
+			response.Error = "This subreddit is private"
+			context.Respond(response)
+			return
+		}
+
+		posts := make([]*StoredPost, 0)
+		postIds, _ := state.store.SubredditPosts.Get(msg.SubredditName)
+		for _, postId := range postIds {
+			if post, exists := state.store.Posts.Get(postId); exists {
+				posts = append(posts, post)
 			}
 		}
-		postMutex.RUnlock()
-		
+
+		paged, links, err := pagePosts(posts, sortBy, period, msg.Page)
+		if err != nil {
+			response.Success = false
+			response.Error = err.Error()
+			context.Respond(response)
+			return
+		}
+
+		response.Posts = make([]*messages.Post, 0, len(paged))
+		for _, post := range paged {
+			response.Posts = append(response.Posts, toPost(post, context.Self()))
+		}
+		response.PageLinks = links
+
 		fmt.Printf("PostActor: Returning %d posts\n", len(response.Posts))
 		response.Success = true
 		context.Respond(response)
 
 	case *messages.DeletePost:
-		response := state.handleDelete(msg)
-		context.Respond(response)
-
-	case *messages.DeleteSubredditPosts:
-		response := &messages.DeleteSubredditPostsResponse{}
-		
-		postMutex.Lock()
-		// Get all posts for this subreddit
-		if posts, exists := subredditPosts[msg.SubredditName]; exists {
-			// Delete each post and its comments
-			for _, postId := range posts {
-				// Delete comments first
-				deleteCommentsMsg := &messages.DeletePostComments{
-					PostId: postId,
-					ActorPID: msg.ActorPID,
-				}
-				
-				future := state.system.Root.RequestFuture(msg.ActorPID, deleteCommentsMsg, 5*time.Second)
-				if _, err := future.Result(); err != nil {
-					continue // Skip if comment deletion fails
-				}
-				
-				// Delete the post
-				delete(globalPosts, postId)
-			}
-			delete(subredditPosts, msg.SubredditName)
-			response.Success = true
-		} else {
-			response.Success = true // No posts to delete is still a success
-		}
-		postMutex.Unlock()
-		
-		context.Respond(response)
+		state.handleDelete(context, msg)
 
 	case *messages.SearchPosts:
 		response := state.handleSearch(msg)
 		context.Respond(response)
 
+	case *messages.GetUserPosts:
+		response := state.handleUserPosts(msg)
+		context.Respond(response)
+
 	case *messages.EditPost:
 		response := state.handleEdit(msg)
 		context.Respond(response)
+
+	case *messages.Vote:
+		response := state.handleVote(context, msg)
+		context.Respond(response)
+
+	case *messages.ReportPost:
+		response := state.handleReport(msg)
+		context.Respond(response)
+
+	case *messages.ModeratePost:
+		response := state.handleModerate(msg)
+		context.Respond(response)
+
+	case *messages.AnonymizePost:
+		response := state.handleAnonymize(msg)
+		context.Respond(response)
 	}
 }
 
-func (state *PostActor) handleDelete(msg *messages.DeletePost) *messages.DeletePostResponse {
-	postMutex.Lock()
-	defer postMutex.Unlock()
+// handleDelete has the post's comments deleted, then deletes the post. It
+// answers through context once the comments are gone.
+func (state *PostActor) handleDelete(context actor.Context, msg *messages.DeletePost) {
+	if response := state.checkDelete(msg); response != nil {
+		context.Respond(response)
+		return
+	}
+	// A moderator removes someone else's post instead, keeping everyone's
+	// comments under it
+	if post, _ := state.store.Posts.Get(msg.PostId); !msg.Cascade && post.AuthorId != msg.AuthorId {
+		response := state.handleModerate(&messages.ModeratePost{PostId: msg.PostId, ModeratorId: msg.AuthorId, Action: messages.ModActionRemove})
+		context.Respond(&messages.DeletePostResponse{Success: response.Success, Error: response.Error})
+		return
+	}
+	if context.Parent() == nil {
+		context.Respond(state.finishDelete(context, msg))
+		return
+	}
 
-	post, exists := globalPosts[msg.PostId]
+	deleteCommentsMsg := &messages.DeletePostComments{
+		PostId:  msg.PostId,
+		Archive: msg.Cascade,
+	}
+	requestInTurn(context, []interface{}{deleteCommentsMsg}, func(request, response interface{}) error {
+		if deleteResponse, ok := response.(*messages.DeletePostCommentsResponse); !ok || !deleteResponse.Success {
+			return fmt.Errorf("the comment actor refused")
+		}
+		return nil
+	}, func(err error) {
+		if err != nil {
+			context.Respond(&messages.DeletePostResponse{
+				Success: false,
+				Error:   "Failed to delete comments: " + err.Error(),
+			})
+			return
+		}
+		context.Respond(state.finishDelete(context, msg))
+	})
+}
+
+// checkDelete returns why the post can't be deleted, or nil
+func (state *PostActor) checkDelete(msg *messages.DeletePost) *messages.DeletePostResponse {
+	post, exists := state.store.Posts.Get(msg.PostId)
 	if !exists {
 		return &messages.DeletePostResponse{
 			Success: false,
@@ -175,106 +331,82 @@ func (state *PostActor) handleDelete(msg *messages.DeletePost) *messages.DeleteP
 		}
 	}
 
-	// Verify ownership
-	if post.AuthorId != msg.AuthorId {
+	// Verify ownership, unless the whole subreddit is going; moderators
+	// with the posts permission may remove anyone's post
+	if !msg.Cascade && post.AuthorId != msg.AuthorId &&
+		!canModerate(state.store, post.SubredditName, msg.AuthorId, messages.ModPermPosts) {
 		return &messages.DeletePostResponse{
 			Success: false,
 			Error:   "Not authorized to delete this post",
 		}
 	}
+	return nil
+}
 
-	// Delete all comments first (send message to comment actor via engine)
-	deleteCommentsMsg := &messages.DeletePostComments{
-		PostId: msg.PostId,
-		ActorPID: msg.ActorPID,
-	}
-	
-	// Send to engine actor and wait for response
-	if msg.ActorPID != nil {
-		future := state.system.Root.RequestFuture(msg.ActorPID, deleteCommentsMsg, 5*time.Second)
-		if response, err := future.Result(); err != nil {
-			return &messages.DeletePostResponse{
-				Success: false,
-				Error:   "Failed to delete comments: " + err.Error(),
-			}
-		} else {
-			if deleteResponse, ok := response.(*messages.DeletePostCommentsResponse); !ok || !deleteResponse.Success {
-				return &messages.DeletePostResponse{
-					Success: false,
-					Error:   "Failed to delete comments: " + deleteResponse.Error,
-				}
-			}
+// finishDelete deletes the post once its comments are gone. The post is
+// looked up again, since another deletion may have beaten this one to it.
+func (state *PostActor) finishDelete(context actor.Context, msg *messages.DeletePost) *messages.DeletePostResponse {
+	post, exists := state.store.Posts.Get(msg.PostId)
+	if !exists {
+		return &messages.DeletePostResponse{
+			Success: false,
+			Error:   "Post not found",
 		}
 	}
 
-	// Remove from subreddit's posts
-	if posts, exists := subredditPosts[post.SubredditName]; exists {
-		for i, postId := range posts {
-			if postId == msg.PostId {
-				subredditPosts[post.SubredditName] = append(posts[:i], posts[i+1:]...)
-				break
+	// Remove from subreddit's posts and the author's history
+	removeIDFrom(state.store.SubredditPosts, post.SubredditName, msg.PostId)
+	removeIDFrom(state.store.UserPosts, post.AuthorId, msg.PostId)
+
+	// Keep a copy if the whole subreddit is being archived
+	if msg.Cascade {
+		if err := state.store.ArchivedPosts.Put(post.PostId, post); err != nil {
+			return &messages.DeletePostResponse{
+				Success: false,
+				Error:   "Failed to archive post",
 			}
 		}
 	}
 
-	// Delete the post itself
-	delete(globalPosts, msg.PostId)
+	// Take back the karma the post earned, then delete the post itself
+	sendKarmaUpdate(context, post.AuthorId, "post", -calculateVotes(post.Votes))
+	state.store.Posts.Delete(msg.PostId)
+	state.store.PostIndex.Remove(msg.PostId)
 
 	return &messages.DeletePostResponse{Success: true}
 }
 
+// handleSearch looks the query up in the post index, best match first
 func (state *PostActor) handleSearch(msg *messages.SearchPosts) *messages.SearchPostsResponse {
 	fmt.Printf("PostActor: Searching for query: %s\n", msg.Query)
-	query := strings.ToLower(msg.Query)
-	results := make([]*messages.PostFeed, 0)
-
-	postMutex.RLock()
-	defer postMutex.RUnlock()
-
-	// Search in all posts
-	for _, post := range globalPosts {
-		// Search in title and content
-		if strings.Contains(strings.ToLower(post.Title), query) || 
-		   strings.Contains(strings.ToLower(post.Content), query) {
-			
-			fmt.Printf("PostActor: Found matching post: %s\n", post.Title)
-			
-			postFeed := &messages.PostFeed{
-				PostId:        post.PostId,
-				Title:         post.Title,
-				Content:       post.Content,
-				AuthorId:      post.AuthorId,
-				
-				SubredditName: post.SubredditName,
-				Comments:      make([]*messages.CommentFeed, 0),
-			}
-
-			// Add comments
-			if comments, exists := postComments[post.PostId]; exists {
-				for _, commentId := range comments {
-					if comment, exists := globalComments[commentId]; exists {
-						commentFeed := buildCommentFeed(comment)
-						postFeed.Comments = append(postFeed.Comments, commentFeed)
-					}
-				}
-			}
 
-			results = append(results, postFeed)
+	visible := func(postId string) bool {
+		post, exists := state.store.Posts.Get(postId)
+		return exists && !post.Removed && canViewSubreddit(state.store, post.SubredditName, msg.UserId)
+	}
+	hits, links, err := searchIndex(state.store.PostIndex, "search/posts", msg.Query, msg.Page, visible)
+	if err != nil {
+		return &messages.SearchPostsResponse{
+			Success: false,
+			Error:   err.Error(),
 		}
 	}
 
-	fmt.Printf("PostActor: Found %d matching posts\n", len(results))
+	results := make([]*messages.PostFeed, 0, len(hits))
+	for _, hit := range hits {
+		if post, exists := state.store.Posts.Get(hit.ID); exists {
+			results = append(results, toPostFeed(state.store, post))
+		}
+	}
 	return &messages.SearchPostsResponse{
-		Success: true,
-		Posts:   results,
+		Success:   true,
+		Posts:     results,
+		PageLinks: links,
 	}
 }
 
 func (state *PostActor) handleEdit(msg *messages.EditPost) *messages.EditPostResponse {
-	postMutex.Lock()
-	defer postMutex.Unlock()
-
-	post, exists := globalPosts[msg.PostId]
+	post, exists := state.store.Posts.Get(msg.PostId)
 	if !exists {
 		return &messages.EditPostResponse{
 			Success: false,
@@ -289,14 +421,87 @@ func (state *PostActor) handleEdit(msg *messages.EditPost) *messages.EditPostRes
 			Error:   "Not authorized to edit this post",
 		}
 	}
+	if post.Removed {
+		return &messages.EditPostResponse{
+			Success: false,
+			Error:   "Can't edit a removed post",
+		}
+	}
 
 	// Update content
+	post = post.clone()
+	post.EditedAt = time.Now().Unix()
 	if msg.Content != "" {
 		post.Content = msg.Content
 	}
 	if msg.Title != "" {
 		post.Title = msg.Title
 	}
+	// Edits go through the rules again, but AutoModerator's replies are
+	// dropped; it only answers new posts, so editing can't make it repeat itself
+	state.autoModerate(post)
+	if err := state.store.Posts.Put(post.PostId, post); err != nil {
+		return &messages.EditPostResponse{
+			Success: false,
+			Error:   "Failed to save post",
+		}
+	}
+	state.store.PostIndex.Add(postDocument(post))
 
 	return &messages.EditPostResponse{Success: true}
 }
+
+// autoModerate runs the subreddit's AutoModerator rules on a post about to be
+// saved and returns the replies to post
+func (state *PostActor) autoModerate(post *StoredPost) []string {
+	subject := autoModSubject{
+		isPost: true,
+		title:  post.Title,
+		body:   post.Content,
+		flair:  post.Flair,
+		author: post.AuthorId,
+	}
+	now := time.Now()
+	matched := autoModMatches(state.store, post.SubredditName, subject, now)
+	return applyAutoMod(state.store, post.SubredditName, subject, post.PostId, &post.Moderation, &post.Flair, matched, now)
+}
+
+func (state *PostActor) handleVote(context actor.Context, msg *messages.Vote) *messages.VoteResponse {
+	fmt.Printf("Handling vote for post %s by user %s (upvote: %v)\n",
+		msg.TargetID, msg.UserID, msg.IsUpvote)
+
+	// Posts in private subreddits are hidden from outsiders, as GetPost hides them
+	if post, exists := state.store.Posts.Get(msg.TargetID); exists && canViewSubreddit(state.store, post.SubredditName, msg.UserID) {
+		if err := checkSubredditParticipation(state.store, post.SubredditName, msg.UserID, participateVote); err != nil {
+			return &messages.VoteResponse{Success: false, Error: err.Error()}
+		}
+		// Removed posts earn nothing more
+		if post.Removed {
+			return &messages.VoteResponse{Success: false, Error: "Can't vote on a removed post"}
+		}
+		post = post.clone()
+
+		// Voting the same way twice retracts the vote, the other way flips it
+		change := 0
+		if previousVote, hasVoted := post.Votes[msg.UserID]; hasVoted {
+			if previousVote == msg.IsUpvote {
+				delete(post.Votes, msg.UserID)
+				change = -voteValue(previousVote)
+			} else {
+				post.Votes[msg.UserID] = msg.IsUpvote
+				change = voteValue(msg.IsUpvote) - voteValue(previousVote)
+			}
+		} else {
+			post.Votes[msg.UserID] = msg.IsUpvote
+			change = voteValue(msg.IsUpvote)
+		}
+
+		if err := state.store.Posts.Put(post.PostId, post); err != nil {
+			return &messages.VoteResponse{Success: false, Error: "Failed to save vote"}
+		}
+
+		sendKarmaUpdate(context, post.AuthorId, "post", change)
+		return &messages.VoteResponse{Success: true}
+	}
+	return &messages.VoteResponse{Success: false, Error: "Post not found"}
+}
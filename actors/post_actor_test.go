@@ -0,0 +1,131 @@
+package actors
+
+import (
+	"reddit/messages"
+	"strings"
+	"testing"
+)
+
+func TestPostSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello World", "hello_world"},
+		{"  What's new in Go 1.23?  ", "what_s_new_in_go_1_23"},
+		{"a/b\\c?d#e", "a_b_c_d_e"},
+		{"!!!", ""},
+		{strings.Repeat("long ", 30), strings.TrimSuffix(strings.Repeat("long_", 10), "_")},
+	}
+
+	for _, tt := range tests {
+		if got := postSlug(tt.title); got != tt.want {
+			t.Errorf("postSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestNewPostID(t *testing.T) {
+	first, second := newPostID(), newPostID()
+	if first == second {
+		t.Fatalf("newPostID() returned %q twice", first)
+	}
+	if first > second {
+		t.Errorf("newPostID() = %q then %q, want IDs in creation order", first, second)
+	}
+}
+
+// setupVoting returns a store with a post and a comment by "author" in a
+// subreddit that alice and carol belong to
+func setupVoting(t *testing.T) *Store {
+	store := newTestStore(t, "author", "alice", "carol")
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Members = map[string]bool{"author": true, "alice": true, "carol": true}
+	})
+	store.Posts.Put("post", &StoredPost{PostId: "post", SubredditName: "golang", AuthorId: "author", Votes: map[string]bool{}})
+	store.Comments.Put("comment", &StoredComment{CommentId: "comment", PostId: "post", AuthorId: "author", Votes: map[string]bool{}})
+	return store
+}
+
+func TestVoteFlipsAndRetracts(t *testing.T) {
+	store := setupVoting(t)
+	ask := newTestEngine(t, store)
+
+	// Each step builds on the ones before it
+	tests := []struct {
+		name      string
+		voter     string
+		isUpvote  bool
+		wantScore int
+	}{
+		{"Upvote", "alice", true, 1},
+		{"Same vote again removes it", "alice", true, 0},
+		{"Downvote", "alice", false, -1},
+		{"Opposite vote flips it", "alice", true, 1},
+		{"Second voter", "carol", false, 0},
+		{"Second voter flips", "carol", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vote := &messages.Vote{UserID: tt.voter, TargetID: "post", IsUpvote: tt.isUpvote, Type: "post"}
+			if response := ask(vote).(*messages.VoteResponse); !response.Success {
+				t.Fatalf("Vote = %+v", response)
+			}
+			post, _ := store.Posts.Get("post")
+			if score := calculateVotes(post.Votes); score != tt.wantScore {
+				t.Errorf("score = %d, want %d", score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestVotesMoveKarma(t *testing.T) {
+	store := setupVoting(t)
+	ask := newTestEngine(t, store)
+
+	// Each step builds on the ones before it
+	tests := []struct {
+		name        string
+		voter       string
+		target      string
+		isUpvote    bool
+		wantPost    int
+		wantComment int
+	}{
+		{"Post upvote", "alice", "post", true, 1, 0},
+		{"Post vote flipped", "alice", "post", false, -1, 0},
+		{"Post vote removed", "alice", "post", false, 0, 0},
+		{"Second voter", "carol", "post", true, 1, 0},
+		{"Comment upvote", "alice", "comment", true, 1, 1},
+		{"Comment vote flipped", "alice", "comment", false, 1, -1},
+		{"Comment vote removed", "alice", "comment", false, 1, 0},
+		{"Own vote counts too", "author", "comment", true, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vote := &messages.Vote{UserID: tt.voter, TargetID: tt.target, IsUpvote: tt.isUpvote, Type: tt.target}
+			if response := ask(vote).(*messages.VoteResponse); !response.Success {
+				t.Fatalf("Vote = %+v", response)
+			}
+			karma := ask(&messages.GetKarma{UserID: "author"}).(*messages.GetKarmaResponse)
+			if karma.PostKarma != tt.wantPost || karma.CommentKarma != tt.wantComment {
+				t.Errorf("karma = post %d, comment %d, want post %d, comment %d", karma.PostKarma, karma.CommentKarma, tt.wantPost, tt.wantComment)
+			}
+		})
+	}
+}
+
+func TestRemovedPostsReadTheSameEverywhere(t *testing.T) {
+	store := newTestStore(t)
+	post := &StoredPost{PostId: "post", AuthorId: "author", Content: "spam", Votes: map[string]bool{}, Moderation: Moderation{Removed: true}}
+
+	full, feed := toPost(post, nil), toPostFeed(store, post)
+	if !full.Removed || full.Content != removedPlaceholder {
+		t.Errorf("toPost() = removed %v, content %q", full.Removed, full.Content)
+	}
+	if !feed.Removed || feed.Content != removedPlaceholder {
+		t.Errorf("toPostFeed() = removed %v, content %q", feed.Removed, feed.Content)
+	}
+}
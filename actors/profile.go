@@ -0,0 +1,285 @@
+package actors
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reddit/messages"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// UserProfile is what is known about a user besides their password
+type UserProfile struct {
+	CreatedAt     int64 // Unix seconds they registered; 0 for users from before profiles
+	DisplayName   string
+	Bio           string
+	AvatarURL     string
+	Email         string    // Private to the user
+	EmailVerified bool      // The user followed the link mailed to Email
+	Deactivated   bool      // Hidden until they log in again
+	Suspension    *Sanction // Site-wide, set by admins; nil if never suspended
+
+	NotificationsOff map[string]bool `json:",omitempty"` // Notification types turned off
+}
+
+// Limits on what users write about themselves
+const (
+	maxDisplayNameLength = 30
+	maxBioLength         = 200
+	maxAvatarURLLength   = 500
+	maxEmailLength       = 254
+)
+
+// checkProfileEdit tidies up the fields being set and checks them
+func checkProfileEdit(msg *messages.EditUserProfile) error {
+	for _, field := range []*string{msg.Email, msg.DisplayName, msg.Bio, msg.AvatarURL} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+
+	if msg.DisplayName != nil {
+		if utf8.RuneCountInString(*msg.DisplayName) > maxDisplayNameLength {
+			return fmt.Errorf("Display name must be at most %d characters", maxDisplayNameLength)
+		}
+		if strings.ContainsAny(*msg.DisplayName, "\n\r\t") {
+			return fmt.Errorf("Display name must be on one line")
+		}
+	}
+	if msg.Bio != nil && utf8.RuneCountInString(*msg.Bio) > maxBioLength {
+		return fmt.Errorf("Bio must be at most %d characters", maxBioLength)
+	}
+	if msg.AvatarURL != nil && *msg.AvatarURL != "" {
+		link, err := url.Parse(*msg.AvatarURL)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return fmt.Errorf("Avatar must be an http or https link")
+		}
+		if len(*msg.AvatarURL) > maxAvatarURLLength {
+			return fmt.Errorf("Avatar link must be at most %d characters", maxAvatarURLLength)
+		}
+	}
+	for notificationType := range msg.Notifications {
+		if !isNotificationType(notificationType) {
+			return fmt.Errorf("Unknown notification type %q, want one of %v", notificationType, messages.NotificationTypes)
+		}
+	}
+	if msg.Email != nil && *msg.Email != "" {
+		return checkEmail(*msg.Email)
+	}
+	return nil
+}
+
+// checkEmail takes only a bare address, not "Name <address>"
+func checkEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > maxEmailLength {
+		return fmt.Errorf("Email address is not valid")
+	}
+	return nil
+}
+
+func (state *UserActor) handleEditProfile(msg *messages.EditUserProfile) *messages.EditUserProfileResponse {
+	if !state.store.Users.Has(msg.UserId) {
+		return &messages.EditUserProfileResponse{Success: false, Error: "User not found"}
+	}
+	if err := checkProfileEdit(msg); err != nil {
+		return &messages.EditUserProfileResponse{Success: false, Error: err.Error()}
+	}
+
+	profile := &UserProfile{}
+	if current, exists := state.store.Profiles.Get(msg.UserId); exists {
+		copied := *current
+		profile = &copied
+	}
+	if msg.DisplayName != nil {
+		profile.DisplayName = *msg.DisplayName
+	}
+	if msg.Bio != nil {
+		profile.Bio = *msg.Bio
+	}
+	if msg.AvatarURL != nil {
+		profile.AvatarURL = *msg.AvatarURL
+	}
+	if len(msg.Notifications) > 0 {
+		notificationsOff := make(map[string]bool)
+		for notificationType, off := range profile.NotificationsOff {
+			notificationsOff[notificationType] = off
+		}
+		for notificationType, on := range msg.Notifications {
+			if on {
+				delete(notificationsOff, notificationType)
+			} else {
+				notificationsOff[notificationType] = true
+			}
+		}
+		profile.NotificationsOff = notificationsOff
+	}
+	emailChanged := msg.Email != nil && *msg.Email != profile.Email
+	if emailChanged {
+		profile.Email = *msg.Email
+		profile.EmailVerified = false
+	}
+
+	if err := state.store.Profiles.Put(msg.UserId, profile); err != nil {
+		return &messages.EditUserProfileResponse{Success: false, Error: "Failed to save profile"}
+	}
+	state.store.UserIndex.Add(userDocument(msg.UserId, profile.DisplayName))
+	if emailChanged && profile.Email != "" {
+		state.sendVerification(msg.UserId, profile.Email)
+	}
+	return &messages.EditUserProfileResponse{Success: true}
+}
+
+func (state *UserActor) handleGetProfile(msg *messages.GetUserProfile) *messages.GetUserProfileResponse {
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.GetUserProfileResponse{Success: false, Error: "User not found"}
+	}
+
+	if accountHidden(state.store, msg.Username, msg.ViewerId) {
+		return &messages.GetUserProfileResponse{Success: false, Error: "This account is deactivated"}
+	}
+
+	info := &messages.UserProfile{Username: msg.Username}
+	if profile, exists := state.store.Profiles.Get(msg.Username); exists {
+		info.DisplayName = profile.DisplayName
+		info.Bio = profile.Bio
+		info.AvatarURL = profile.AvatarURL
+		info.CreatedAt = profile.CreatedAt
+		if msg.ViewerId == msg.Username {
+			info.Email = profile.Email
+			info.EmailVerified = profile.EmailVerified
+			info.Notifications = make(map[string]bool, len(messages.NotificationTypes))
+			for _, notificationType := range messages.NotificationTypes {
+				info.Notifications[notificationType] = notificationsOn(profile, notificationType)
+			}
+		}
+		if profile.Suspension != nil && state.store.isAdmin(msg.ViewerId) {
+			info.Suspension = &messages.Suspension{
+				Reason:    profile.Suspension.Reason,
+				By:        profile.Suspension.By,
+				At:        profile.Suspension.At,
+				ExpiresAt: profile.Suspension.ExpiresAt,
+			}
+		}
+	}
+	if karma, exists := state.store.Karma.Get(msg.Username); exists {
+		info.PostKarma = karma.PostKarma
+		info.CommentKarma = karma.CommentKarma
+	}
+	info.TotalKarma = info.PostKarma + info.CommentKarma
+	return &messages.GetUserProfileResponse{Success: true, Profile: info}
+}
+
+// accountHidden is true for a deactivated account, unless it's the user
+// themself or an admin looking
+func accountHidden(store *Store, username, viewerId string) bool {
+	if viewerId == username || store.isAdmin(viewerId) {
+		return false
+	}
+	profile, exists := store.Profiles.Get(username)
+	return exists && profile.Deactivated
+}
+
+// checkHistorySort fills in the defaults for a user's history, newest first
+// and over all time
+func checkHistorySort(sortBy, period string) (string, string, error) {
+	if sortBy == "" {
+		sortBy = SortNew
+	}
+	if period == "" {
+		period = "all"
+	}
+	switch sortBy {
+	case SortNew, SortTop, SortControversial:
+	default:
+		return "", "", fmt.Errorf("Unknown sort %q, want new, top or controversial", sortBy)
+	}
+	return checkRanking(sortBy, period)
+}
+
+func (state *PostActor) handleUserPosts(msg *messages.GetUserPosts) *messages.GetUserPostsResponse {
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.GetUserPostsResponse{Success: false, Error: "User not found"}
+	}
+	if accountHidden(state.store, msg.Username, msg.ViewerId) {
+		return &messages.GetUserPostsResponse{Success: false, Error: "This account is deactivated"}
+	}
+	sortBy, period, err := checkHistorySort(msg.Sort, msg.Period)
+	if err != nil {
+		return &messages.GetUserPostsResponse{Success: false, Error: err.Error()}
+	}
+
+	posts := make([]*StoredPost, 0)
+	postIds, _ := state.store.UserPosts.Get(msg.Username)
+	for _, postId := range postIds {
+		post, exists := state.store.Posts.Get(postId)
+		if exists && canViewSubreddit(state.store, post.SubredditName, msg.ViewerId) {
+			posts = append(posts, post)
+		}
+	}
+
+	// The listing is per user so a cursor can't be replayed against another's
+	pager, err := newPager(sortBy+"/"+period+"/u/"+msg.Username, msg.Page, time.Now().Unix())
+	if err != nil {
+		return &messages.GetUserPostsResponse{Success: false, Error: err.Error()}
+	}
+	now := time.Unix(pager.at, 0)
+	key := func(post *StoredPost) pageKey { return postKey(post, sortBy, now) }
+	paged, links := paginate(pager, rankPosts(posts, sortBy, period, now), key, rankedBefore)
+	results := make([]*messages.Post, 0, len(paged))
+	for _, post := range paged {
+		results = append(results, toPost(post, nil))
+	}
+	return &messages.GetUserPostsResponse{Success: true, Posts: results, PageLinks: links}
+}
+
+func (state *CommentActor) handleUserComments(msg *messages.GetUserComments) *messages.GetUserCommentsResponse {
+	if !state.store.Users.Has(msg.Username) {
+		return &messages.GetUserCommentsResponse{Success: false, Error: "User not found"}
+	}
+	if accountHidden(state.store, msg.Username, msg.ViewerId) {
+		return &messages.GetUserCommentsResponse{Success: false, Error: "This account is deactivated"}
+	}
+	sortBy, _, err := checkHistorySort(msg.Sort, "")
+	if err != nil {
+		return &messages.GetUserCommentsResponse{Success: false, Error: err.Error()}
+	}
+
+	comments := make([]*StoredComment, 0)
+	commentIds, _ := state.store.UserComments.Get(msg.Username)
+	for _, commentId := range commentIds {
+		comment, exists := state.store.Comments.Get(commentId)
+		if !exists || comment.Removed {
+			continue
+		}
+		if post, exists := state.store.Posts.Get(comment.PostId); exists && !canViewSubreddit(state.store, post.SubredditName, msg.ViewerId) {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+
+	pager, err := newPager("comments/"+sortBy+"/u/"+msg.Username, msg.Page, time.Now().Unix())
+	if err != nil {
+		return &messages.GetUserCommentsResponse{Success: false, Error: err.Error()}
+	}
+	key := func(comment *StoredComment) pageKey { return commentKey(comment, sortBy, false) }
+	sort.Slice(comments, func(i, j int) bool { return rankedBefore(key(comments[i]), key(comments[j])) })
+	paged, links := paginate(pager, comments, key, rankedBefore)
+
+	results := make([]*messages.Comment, 0, len(paged))
+	for _, comment := range paged {
+		results = append(results, &messages.Comment{
+			CommentId: comment.CommentId,
+			PostId:    comment.PostId,
+			ParentId:  comment.ParentId,
+			Content:   comment.Content,
+			AuthorId:  comment.AuthorId,
+			Timestamp: comment.Timestamp,
+			VoteCount: calculateVotes(comment.Votes),
+		})
+	}
+	return &messages.GetUserCommentsResponse{Success: true, Comments: results, PageLinks: links}
+}
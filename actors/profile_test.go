@@ -0,0 +1,51 @@
+package actors
+
+import (
+	"reddit/messages"
+	"testing"
+)
+
+func TestEditUserProfile(t *testing.T) {
+	store := NewMemoryStore()
+	state := NewUserActor(store)
+	store.Users.Put("gopher", "hash")
+	store.Profiles.Put("gopher", &UserProfile{CreatedAt: 1700000000})
+	store.Karma.Put("gopher", &UserKarma{PostKarma: 5, CommentKarma: 2})
+
+	text := func(value string) *string { return &value }
+	tests := []struct {
+		name    string
+		edit    *messages.EditUserProfile
+		wantErr bool
+	}{
+		{"Valid edit", &messages.EditUserProfile{DisplayName: text(" Go Pher "), Bio: text("Writes Go"), AvatarURL: text("https://img.example.com/g.png"), Email: text("g@example.com")}, false},
+		{"Display name too long", &messages.EditUserProfile{DisplayName: text("abcdefghijklmnopqrstuvwxyz01234")}, true},
+		{"Avatar not a web link", &messages.EditUserProfile{AvatarURL: text("javascript:alert(1)")}, true},
+		{"Email with a name", &messages.EditUserProfile{Email: text("Gopher <g@example.com>")}, true},
+		{"Email not an address", &messages.EditUserProfile{Email: text("gopher")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.edit.UserId = "gopher"
+			response := state.handleEditProfile(tt.edit)
+			if response.Success == tt.wantErr {
+				t.Errorf("handleEditProfile() = %+v, wantErr %v", response, tt.wantErr)
+			}
+		})
+	}
+
+	// Left-out fields stay; an empty one clears
+	state.handleEditProfile(&messages.EditUserProfile{UserId: "gopher", Bio: text("")})
+
+	mine := state.handleGetProfile(&messages.GetUserProfile{Username: "gopher", ViewerId: "gopher"}).Profile
+	if mine.DisplayName != "Go Pher" || mine.Bio != "" || mine.Email != "g@example.com" || mine.CreatedAt != 1700000000 || mine.TotalKarma != 7 {
+		t.Errorf("own profile = %+v, want the trimmed name, no bio, the email, created at and 7 karma", mine)
+	}
+	if theirs := state.handleGetProfile(&messages.GetUserProfile{Username: "gopher", ViewerId: "someone"}).Profile; theirs.Email != "" {
+		t.Errorf("another user saw the email %q", theirs.Email)
+	}
+	query, _ := store.UserIndex.Parse("pher")
+	if hits := store.UserIndex.Search(query); len(hits) != 1 {
+		t.Errorf("searching the display name found %d users, want 1", len(hits))
+	}
+}
@@ -0,0 +1,231 @@
+package actors
+
+import (
+	"fmt"
+	"math"
+	"reddit/messages"
+	"sort"
+	"time"
+)
+
+// Post orderings, as accepted by ?sort=
+const (
+	SortHot           = "hot"
+	SortNew           = "new"
+	SortTop           = "top"
+	SortControversial = "controversial"
+	SortRising        = "rising"
+
+	// Comments also sort by these
+	SortBest = "best"
+	SortOld  = "old"
+	SortQA   = "qa"
+)
+
+// Time windows for top and controversial, as accepted by ?t=
+var rankingPeriods = map[string]time.Duration{
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+	"all":  0,
+}
+
+const (
+	// hotEpoch and hotDecay are Reddit's: a post needs 10x the score to
+	// match one posted 12.5 hours later
+	hotEpoch = 1134028003
+	hotDecay = 45000
+
+	// risingWindow is how recent a post must be to count as rising
+	risingWindow = 24 * time.Hour
+
+	// bestConfidence is the z-score for the 80% confidence Reddit's best
+	// sort uses
+	bestConfidence = 1.281551565545
+)
+
+// checkRanking fills in the defaults, hot and day, and rejects anything else
+// unknown
+func checkRanking(sortBy, period string) (string, string, error) {
+	if sortBy == "" {
+		sortBy = SortHot
+	}
+	if period == "" {
+		period = "day"
+	}
+
+	switch sortBy {
+	case SortHot, SortNew, SortTop, SortControversial, SortRising:
+	default:
+		return "", "", fmt.Errorf("Unknown sort %q, want hot, new, top, controversial or rising", sortBy)
+	}
+	if _, known := rankingPeriods[period]; !known {
+		return "", "", fmt.Errorf("Unknown time period %q, want day, week or all", period)
+	}
+	return sortBy, period, nil
+}
+
+// rankPosts orders posts for a listing. Top and controversial only keep
+// posts from the period; rising only keeps posts from the last day. Removed
+// posts drop out of every listing.
+func rankPosts(posts []*StoredPost, sortBy, period string, now time.Time) []*StoredPost {
+	cutoff := int64(0)
+	switch sortBy {
+	case SortTop, SortControversial:
+		if window := rankingPeriods[period]; window > 0 {
+			cutoff = now.Add(-window).Unix()
+		}
+	case SortRising:
+		cutoff = now.Add(-risingWindow).Unix()
+	}
+
+	ranked := make([]*StoredPost, 0, len(posts))
+	keys := make(map[string]pageKey, len(posts))
+	for _, post := range posts {
+		if post.Timestamp < cutoff || post.Removed {
+			continue
+		}
+		ranked = append(ranked, post)
+		keys[post.PostId] = postKey(post, sortBy, now)
+	}
+
+	// Ties go to the newer post, then the ID keeps the order stable
+	sort.Slice(ranked, func(i, j int) bool {
+		return rankedBefore(keys[ranked[i].PostId], keys[ranked[j].PostId])
+	})
+	return ranked
+}
+
+// postKey is the post's place in a listing ranked by sortBy, for rankPosts and
+// for the cursors pointing into it
+func postKey(post *StoredPost, sortBy string, now time.Time) pageKey {
+	key := pageKey{Timestamp: post.Timestamp, ID: post.PostId}
+	ups, downs := tallyVotes(post.Votes)
+	switch sortBy {
+	case SortHot:
+		key.Score = hotScore(ups, downs, post.Timestamp)
+	case SortNew:
+		key.Score = float64(post.Timestamp)
+	case SortTop:
+		key.Score = float64(ups - downs)
+	case SortControversial:
+		key.Score = controversialScore(ups, downs)
+	case SortRising:
+		key.Score = risingScore(ups, downs, post.Timestamp, now)
+	}
+	return key
+}
+
+// pagePosts ranks posts and cuts out the page asked for. The listing is named
+// after the ordering so a cursor can't be replayed against another one.
+func pagePosts(posts []*StoredPost, sortBy, period string, page messages.Page) ([]*StoredPost, messages.PageLinks, error) {
+	p, err := newPager(sortBy+"/"+period, page, time.Now().Unix())
+	if err != nil {
+		return nil, messages.PageLinks{}, err
+	}
+	now := time.Unix(p.at, 0)
+	key := func(post *StoredPost) pageKey { return postKey(post, sortBy, now) }
+	paged, links := paginate(p, rankPosts(posts, sortBy, period, now), key, rankedBefore)
+	return paged, links, nil
+}
+
+// checkCommentSort fills in the default, best, and rejects anything else
+// unknown
+func checkCommentSort(sortBy string) (string, error) {
+	switch sortBy {
+	case "":
+		return SortBest, nil
+	case SortBest, SortTop, SortNew, SortOld, SortControversial, SortQA:
+		return sortBy, nil
+	}
+	return "", fmt.Errorf("Unknown sort %q, want best, top, new, old, controversial or qa", sortBy)
+}
+
+// commentKey is the comment's place among its siblings. answered says whether
+// the post's author replied to it, which is all that counts for qa.
+func commentKey(comment *StoredComment, sortBy string, answered bool) pageKey {
+	key := pageKey{Timestamp: comment.Timestamp, ID: comment.CommentId}
+	ups, downs := tallyVotes(comment.Votes)
+	switch sortBy {
+	case SortBest:
+		key.Score = bestScore(ups, downs)
+	case SortTop:
+		key.Score = float64(ups - downs)
+	case SortNew:
+		key.Score = float64(comment.Timestamp)
+	case SortControversial:
+		key.Score = controversialScore(ups, downs)
+	case SortQA:
+		// Best never reaches 1, so answered comments all rank first
+		key.Score = bestScore(ups, downs)
+		if answered {
+			key.Score++
+		}
+	}
+	return key
+}
+
+// commentOrder is how keys from commentKey compare
+func commentOrder(sortBy string) func(a, b pageKey) bool {
+	if sortBy == SortOld {
+		return oldestBefore
+	}
+	return rankedBefore
+}
+
+func tallyVotes(votes map[string]bool) (ups, downs int) {
+	for _, isUpvote := range votes {
+		if isUpvote {
+			ups++
+		} else {
+			downs++
+		}
+	}
+	return ups, downs
+}
+
+// hotScore is Reddit's hot ranking: the order of magnitude of the score plus
+// a bonus that grows steadily with the post's age
+func hotScore(ups, downs int, timestamp int64) float64 {
+	score := ups - downs
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+	sign := 0.0
+	if score > 0 {
+		sign = 1
+	} else if score < 0 {
+		sign = -1
+	}
+	return sign*order + float64(timestamp-hotEpoch)/hotDecay
+}
+
+// controversialScore is Reddit's: many votes, split close to evenly. Posts
+// nobody voted against aren't controversial at all.
+func controversialScore(ups, downs int) float64 {
+	if ups <= 0 || downs <= 0 {
+		return 0
+	}
+	magnitude := float64(ups + downs)
+	balance := float64(downs) / float64(ups)
+	if ups < downs {
+		balance = float64(ups) / float64(downs)
+	}
+	return math.Pow(magnitude, balance)
+}
+
+// risingScore is the score gained per hour, so a young post with a handful
+// of votes beats an older one that has stalled
+func risingScore(ups, downs int, timestamp int64, now time.Time) float64 {
+	hours := now.Sub(time.Unix(timestamp, 0)).Hours()
+	return float64(ups-downs) / (math.Max(hours, 0) + 2)
+}
+
+// bestScore is the lower bound of the Wilson interval on the share of
+// upvotes, so a few votes count for less than many at the same ratio
+func bestScore(ups, downs int) float64 {
+	n := float64(ups + downs)
+	if n == 0 {
+		return 0
+	}
+	z := bestConfidence
+	p := float64(ups) / n
+	return (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+}
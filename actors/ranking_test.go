@@ -0,0 +1,63 @@
+package actors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankPosts(t *testing.T) {
+	now := time.Now()
+	hoursAgo := func(hours int) int64 { return now.Add(-time.Duration(hours) * time.Hour).Unix() }
+	votes := func(ups, downs int) map[string]bool {
+		result := make(map[string]bool)
+		for i := 0; i < ups; i++ {
+			result[string(rune('a'+i))] = true
+		}
+		for i := 0; i < downs; i++ {
+			result[string(rune('A'+i))] = false
+		}
+		return result
+	}
+
+	posts := []*StoredPost{
+		{PostId: "old-popular", Timestamp: hoursAgo(72), Votes: votes(20, 0)},
+		{PostId: "fresh", Timestamp: hoursAgo(1), Votes: votes(3, 0)},
+		{PostId: "divisive", Timestamp: hoursAgo(5), Votes: votes(6, 5)},
+		{PostId: "disliked", Timestamp: hoursAgo(2), Votes: votes(0, 4)},
+	}
+
+	tests := []struct {
+		sort   string
+		period string
+		want   []string
+	}{
+		{SortHot, "day", []string{"fresh", "divisive", "disliked", "old-popular"}},
+		{SortNew, "day", []string{"fresh", "disliked", "divisive", "old-popular"}},
+		{SortTop, "day", []string{"fresh", "divisive", "disliked"}},
+		{SortTop, "all", []string{"old-popular", "fresh", "divisive", "disliked"}},
+		{SortControversial, "day", []string{"divisive", "fresh", "disliked"}},
+		{SortRising, "day", []string{"fresh", "divisive", "disliked"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort+"/"+tt.period, func(t *testing.T) {
+			ranked := rankPosts(posts, tt.sort, tt.period, now)
+			got := make([]string, len(ranked))
+			for i, post := range ranked {
+				got[i] = post.PostId
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("rankPosts() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("rankPosts() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, _, err := checkRanking("best", ""); err == nil {
+		t.Errorf("checkRanking(\"best\") succeeded, want an error")
+	}
+}
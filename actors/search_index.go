@@ -0,0 +1,142 @@
+package actors
+
+import (
+	"reddit/messages"
+	"reddit/search"
+	"time"
+)
+
+// The indexes are derived from the tables, so they aren't persisted: OpenStore
+// rebuilds them and the owning actors keep them current from then on.
+
+// newPostIndex indexes titles and bodies, a title match counting double, and
+// lets queries filter on subreddit: and author:
+func newPostIndex(store *Store) *search.Index {
+	index := search.NewIndex(map[string]float64{"title": 2, "content": 1}, "subreddit", "author")
+	store.Posts.Range(func(postId string, post *StoredPost) bool {
+		index.Add(postDocument(post))
+		return true
+	})
+	return index
+}
+
+func postDocument(post *StoredPost) search.Document {
+	return search.Document{
+		ID:     post.PostId,
+		Fields: map[string]string{"title": post.Title, "content": post.Content},
+		Tags:   map[string]string{"subreddit": post.SubredditName, "author": post.AuthorId},
+		Time:   post.Timestamp,
+	}
+}
+
+// newCommentIndex indexes comment text, filterable by author: and post:
+func newCommentIndex(store *Store) *search.Index {
+	index := search.NewIndex(map[string]float64{"content": 1}, "author", "post")
+	store.Comments.Range(func(commentId string, comment *StoredComment) bool {
+		index.Add(commentDocument(comment))
+		return true
+	})
+	return index
+}
+
+func commentDocument(comment *StoredComment) search.Document {
+	return search.Document{
+		ID:     comment.CommentId,
+		Fields: map[string]string{"content": comment.Content},
+		Tags:   map[string]string{"author": comment.AuthorId, "post": comment.PostId},
+		Time:   comment.Timestamp,
+	}
+}
+
+// newSubredditIndex indexes names and descriptions, a name match counting
+// double; bigger subreddits are suggested first
+func newSubredditIndex(store *Store) *search.Index {
+	index := search.NewIndex(map[string]float64{"name": 2, "description": 1}, "creator")
+	store.Subreddits.Range(func(name string, subreddit *Subreddit) bool {
+		index.Add(subredditDocument(subreddit))
+		return true
+	})
+	return index
+}
+
+func subredditDocument(subreddit *Subreddit) search.Document {
+	return search.Document{
+		ID:     subreddit.Name,
+		Fields: map[string]string{"name": subreddit.Name, "description": subreddit.Description},
+		Tags:   map[string]string{"creator": subreddit.CreatorId},
+		Weight: float64(len(subreddit.Members)),
+	}
+}
+
+// newUserIndex indexes usernames
+func newUserIndex(store *Store) *search.Index {
+	index := search.NewNameIndex(map[string]float64{"name": 1, "displayName": 0.5})
+	store.Users.Range(func(username string, passwordHash string) bool {
+		displayName := ""
+		if profile, exists := store.Profiles.Get(username); exists {
+			displayName = profile.DisplayName
+		}
+		index.Add(userDocument(username, displayName))
+		return true
+	})
+	return index
+}
+
+func userDocument(username, displayName string) search.Document {
+	return search.Document{
+		ID:     username,
+		Fields: map[string]string{"name": username, "displayName": displayName},
+	}
+}
+
+// searchIndex runs a query against one of the indexes and cuts out the page
+// asked for. The cursor's listing includes the query, so paging stays within
+// one search. visible, if set, drops hits the searcher may not see before
+// paging, so pages stay full.
+func searchIndex(index *search.Index, listing, text string, page messages.Page, visible func(id string) bool) ([]search.Hit, messages.PageLinks, error) {
+	query, err := index.Parse(text)
+	if err != nil {
+		return nil, messages.PageLinks{}, err
+	}
+	pager, err := newPager(listing+"/"+text, page, time.Now().Unix())
+	if err != nil {
+		return nil, messages.PageLinks{}, err
+	}
+
+	key := func(hit search.Hit) pageKey {
+		return pageKey{Score: hit.Score, Timestamp: hit.Time, ID: hit.ID}
+	}
+	hits := index.Search(query)
+	if visible != nil {
+		shown := hits[:0]
+		for _, hit := range hits {
+			if visible(hit.ID) {
+				shown = append(shown, hit)
+			}
+		}
+		hits = shown
+	}
+	paged, links := paginate(pager, hits, key, rankedBefore)
+	return paged, links, nil
+}
+
+// defaultSuggestions is how many names Autocomplete returns unless asked
+const defaultSuggestions = 10
+
+// autocomplete answers an Autocomplete message from one of the name indexes
+func autocomplete(index *search.Index, msg *messages.Autocomplete) *messages.AutocompleteResponse {
+	limit := msg.Limit
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if msg.Prefix == "" {
+		return &messages.AutocompleteResponse{Success: true, Names: []string{}}
+	}
+	return &messages.AutocompleteResponse{
+		Success: true,
+		Names:   index.Suggest(msg.Prefix, limit),
+	}
+}
@@ -0,0 +1,225 @@
+package actors
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	tokenKindAccess  = "access"
+	tokenKindRefresh = "refresh"
+	tokenKindVerify  = "verify" // Mailed to confirm an email address
+	tokenKindReset   = "reset"  // Mailed to set a forgotten password
+)
+
+var (
+	errTokenInvalid = errors.New("Invalid token")
+	errTokenExpired = errors.New("Token expired")
+	errTokenRevoked = errors.New("Token revoked")
+)
+
+// authToken is the server-side record of an issued token. Tokens are random
+// strings prefixed with the encoded username, which lets the engine route them
+// to the user's owning actor; only their SHA-256 is kept, so a leaked table
+// can't be replayed.
+type authToken struct {
+	Username  string
+	SessionId string // Shared by the access/refresh tokens of one login
+	Kind      string // One of the tokenKind constants
+	Email     string // The address a verify or reset token was mailed to
+	IssuedAt  int64
+	ExpiresAt int64
+	Revoked   bool
+}
+
+// tokenPair is what a client gets back from login and refresh
+type tokenPair struct {
+	SessionId    string
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    int64
+}
+
+func newOpaqueToken(username string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	prefix := base64.RawURLEncoding.EncodeToString([]byte(username))
+	return prefix + "." + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// tokenUsername reads the username a token was issued to, for routing only.
+// It is not proof of anything until the token is looked up.
+func tokenUsername(token string) string {
+	prefix, _, found := strings.Cut(token, ".")
+	if !found {
+		return ""
+	}
+	username, err := base64.RawURLEncoding.DecodeString(prefix)
+	if err != nil {
+		return ""
+	}
+	return string(username)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens creates an access/refresh token pair. An empty sessionId starts
+// a new session. Only the actor owning username may call it.
+func issueTokens(store *Store, username, sessionId string, now time.Time) (*tokenPair, error) {
+	if sessionId == "" {
+		sessionId = uuid.New().String()
+	}
+
+	accessToken, err := newOpaqueToken(username)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newOpaqueToken(username)
+	if err != nil {
+		return nil, err
+	}
+
+	pruneExpiredTokens(store, username, now)
+
+	expiresAt := now.Add(accessTokenTTL).Unix()
+	err = saveToken(store, hashToken(accessToken), &authToken{
+		Username:  username,
+		SessionId: sessionId,
+		Kind:      tokenKindAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = saveToken(store, hashToken(refreshToken), &authToken{
+		Username:  username,
+		SessionId: sessionId,
+		Kind:      tokenKindRefresh,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(refreshTokenTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{
+		SessionId:    sessionId,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// issueMailedToken creates a single-use token of kind for mailing to email,
+// revoking any of the same kind still outstanding. Only the actor owning
+// username may call it.
+func issueMailedToken(store *Store, username, kind, email string, ttl time.Duration, now time.Time) (string, error) {
+	token, err := newOpaqueToken(username)
+	if err != nil {
+		return "", err
+	}
+
+	pruneExpiredTokens(store, username, now)
+	revokeTokens(store, username, func(record *authToken) bool { return record.Kind == kind })
+
+	err = saveToken(store, hashToken(token), &authToken{
+		Username:  username,
+		Kind:      kind,
+		Email:     email,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// lookupToken checks a token of the given kind
+func lookupToken(store *Store, token, kind string, now time.Time) (*authToken, error) {
+	record, exists := store.Tokens.Get(hashToken(token))
+	if !exists || record.Kind != kind {
+		return nil, errTokenInvalid
+	}
+	if record.Revoked {
+		return nil, errTokenRevoked
+	}
+	if now.Unix() >= record.ExpiresAt {
+		return nil, errTokenExpired
+	}
+	if !store.Users.Has(record.Username) {
+		return nil, errTokenInvalid
+	}
+	return record, nil
+}
+
+// saveToken stores a new token record and lists it under its user
+func saveToken(store *Store, key string, record *authToken) error {
+	if err := store.Tokens.Put(key, record); err != nil {
+		return err
+	}
+	return appendID(store.UserTokens, record.Username, key)
+}
+
+// revokeTokens revokes every token of username matching the filter. Only the
+// actor owning username may call it.
+func revokeTokens(store *Store, username string, match func(record *authToken) bool) int {
+	keys, _ := store.UserTokens.Get(username)
+	revoked := 0
+	for _, key := range keys {
+		record, exists := store.Tokens.Get(key)
+		if !exists || record.Revoked || !match(record) {
+			continue
+		}
+		updated := *record
+		updated.Revoked = true
+		store.Tokens.Put(key, &updated)
+		revoked++
+	}
+	return revoked
+}
+
+// pruneExpiredTokens forgets a user's tokens a day after they expire; until
+// then they still report "expired" rather than "invalid"
+func pruneExpiredTokens(store *Store, username string, now time.Time) {
+	cutoff := now.Add(-24 * time.Hour).Unix()
+	keys, _ := store.UserTokens.Get(username)
+	expired := make(map[string]bool)
+	for _, key := range keys {
+		if record, exists := store.Tokens.Get(key); !exists || record.ExpiresAt < cutoff {
+			store.Tokens.Delete(key)
+			expired[key] = true
+		}
+	}
+	if len(expired) > 0 {
+		store.UserTokens.Remove(username, func(key string) bool { return expired[key] })
+	}
+}
+
+// tokenErrorCode maps a token error onto the code clients can switch on
+func tokenErrorCode(err error) string {
+	switch err {
+	case errTokenExpired:
+		return "token_expired"
+	case errTokenRevoked:
+		return "token_revoked"
+	default:
+		return "invalid_token"
+	}
+}
@@ -0,0 +1,248 @@
+package actors
+
+import (
+	"os"
+	"reddit/mail"
+	"reddit/search"
+	"reddit/storage"
+	"strings"
+)
+
+// Store holds every table the actors keep their state in. Actors read and
+// write through it rather than through package-level maps, so the backend
+// chosen at startup decides whether state survives a restart.
+//
+// Each entity is changed only by the actor that owns its key, which the
+// engine's consistent-hash routers pick, so owners never need a lock. Other actors may still read it: owners treat
+// stored values as immutable and Put a changed copy instead, so a reader never
+// sees a half-applied update. Shared ID lists are changed with appendID and
+// removeIDFrom, which are atomic.
+type Store struct {
+	backend storage.Backend
+	admins  map[string]bool // Set once at startup
+	mailer  mail.Mailer     // Standard output unless set at startup
+	autoMod *autoModCache   // Compiled AutoModerator patterns, by subreddit
+
+	// Users
+	Users        *storage.Table[string]       // username -> bcrypt password hash
+	Usernames    *storage.Table[string]       // lowercase username -> username, kept after deletion
+	Karma        *storage.Table[*UserKarma]   // username -> karma
+	Profiles     *storage.Table[*UserProfile] // username -> profile
+	Tokens       *storage.Table[*authToken]   // sha256(token) -> token record
+	UserTokens   *storage.List[string]        // username -> sha256 of each of their tokens
+	UserPosts    *storage.List[string]        // username -> []PostId they wrote
+	UserComments *storage.List[string]        // username -> []CommentId they wrote
+	UserIndex    *search.Index                // Every username and display name
+
+	// Subreddits
+	Subreddits     *storage.Table[*Subreddit] // name -> subreddit
+	SubredditIndex *search.Index              // Names and descriptions
+	ModLog         *storage.List[*ModAction]  // subredditName -> actions, oldest first
+
+	// Deleted subreddits with their posts and comments
+	ArchivedSubreddits *storage.Table[*ArchivedSubreddit] // name -> subreddit as deleted
+	ArchivedPosts      *storage.Table[*StoredPost]        // PostId -> post
+	ArchivedComments   *storage.Table[*StoredComment]     // CommentId -> comment
+
+	// Posts
+	Posts          *storage.Table[*StoredPost] // PostId -> post
+	SubredditPosts *storage.List[string]       // subredditName -> []PostId
+	PostIndex      *search.Index               // Full text of every post
+
+	// Comments
+	Comments       *storage.Table[*StoredComment] // CommentId -> comment
+	PostComments   *storage.List[string]          // PostId -> []CommentId
+	CommentReplies *storage.List[string]          // ParentCommentId -> []CommentId
+	CommentIndex   *search.Index                  // Full text of every comment
+
+	// Direct messages
+	DirectMessages *storage.Table[*StoredDirectMessage] // MessageID -> message
+	UserMessages   *storage.List[string]                // userID -> []MessageID, oldest first
+	ThreadMessages *storage.List[string]                // ThreadID -> []MessageID, oldest first
+
+	// Notifications
+	Notifications     *storage.Table[*StoredNotification] // NotificationId -> notification
+	UserNotifications *storage.List[string]               // username -> []NotificationId, oldest first
+}
+
+// OpenStore loads every table from the backend
+func OpenStore(backend storage.Backend) (*Store, error) {
+	store := &Store{backend: backend, mailer: mail.NewWriterMailer(os.Stdout), autoMod: newAutoModCache()}
+
+	var err error
+	if store.Users, err = storage.NewTable[string](backend, "users"); err != nil {
+		return nil, err
+	}
+	if store.Usernames, err = storage.NewTable[string](backend, "usernames"); err != nil {
+		return nil, err
+	}
+	if store.Karma, err = storage.NewTable[*UserKarma](backend, "karma"); err != nil {
+		return nil, err
+	}
+	if store.Profiles, err = storage.NewTable[*UserProfile](backend, "profiles"); err != nil {
+		return nil, err
+	}
+	if store.Tokens, err = storage.NewTable[*authToken](backend, "tokens"); err != nil {
+		return nil, err
+	}
+	if store.UserTokens, err = storage.NewList[string](backend, "user_tokens"); err != nil {
+		return nil, err
+	}
+	if store.UserPosts, err = storage.NewList[string](backend, "user_posts"); err != nil {
+		return nil, err
+	}
+	if store.UserComments, err = storage.NewList[string](backend, "user_comments"); err != nil {
+		return nil, err
+	}
+	if store.Subreddits, err = storage.NewTable[*Subreddit](backend, "subreddits"); err != nil {
+		return nil, err
+	}
+	if store.ModLog, err = storage.NewList[*ModAction](backend, "mod_log"); err != nil {
+		return nil, err
+	}
+	if store.ArchivedSubreddits, err = storage.NewTable[*ArchivedSubreddit](backend, "archived_subreddits"); err != nil {
+		return nil, err
+	}
+	if store.ArchivedPosts, err = storage.NewTable[*StoredPost](backend, "archived_posts"); err != nil {
+		return nil, err
+	}
+	if store.ArchivedComments, err = storage.NewTable[*StoredComment](backend, "archived_comments"); err != nil {
+		return nil, err
+	}
+	if store.Posts, err = storage.NewTable[*StoredPost](backend, "posts"); err != nil {
+		return nil, err
+	}
+	if store.SubredditPosts, err = storage.NewList[string](backend, "subreddit_posts"); err != nil {
+		return nil, err
+	}
+	if store.Comments, err = storage.NewTable[*StoredComment](backend, "comments"); err != nil {
+		return nil, err
+	}
+	if store.PostComments, err = storage.NewList[string](backend, "post_comments"); err != nil {
+		return nil, err
+	}
+	if store.CommentReplies, err = storage.NewList[string](backend, "comment_replies"); err != nil {
+		return nil, err
+	}
+	if store.DirectMessages, err = storage.NewTable[*StoredDirectMessage](backend, "direct_messages"); err != nil {
+		return nil, err
+	}
+	if store.UserMessages, err = storage.NewList[string](backend, "user_messages"); err != nil {
+		return nil, err
+	}
+	if store.ThreadMessages, err = storage.NewList[string](backend, "thread_messages"); err != nil {
+		return nil, err
+	}
+	if store.Notifications, err = storage.NewTable[*StoredNotification](backend, "notifications"); err != nil {
+		return nil, err
+	}
+	if store.UserNotifications, err = storage.NewList[string](backend, "user_notifications"); err != nil {
+		return nil, err
+	}
+
+	backfillUsernames(store)
+	backfillHistories(store)
+	backfillUserTokens(store)
+	store.UserIndex = newUserIndex(store)
+	store.SubredditIndex = newSubredditIndex(store)
+	store.PostIndex = newPostIndex(store)
+	store.CommentIndex = newCommentIndex(store)
+	return store, nil
+}
+
+// SetAdmins names the users who may suspend accounts. Call it before the
+// engine starts.
+func (store *Store) SetAdmins(usernames []string) {
+	store.admins = make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		if username = strings.TrimSpace(username); username != "" {
+			store.admins[username] = true
+		}
+	}
+}
+
+func (store *Store) isAdmin(username string) bool {
+	return store.admins[username]
+}
+
+// SetMailer picks how emails are sent. Call it before the engine starts.
+func (store *Store) SetMailer(mailer mail.Mailer) {
+	store.mailer = mailer
+}
+
+// backfillUsernames claims the names of users registered before names were
+// unique regardless of case
+func backfillUsernames(store *Store) {
+	if store.Usernames.Len() > 0 {
+		return
+	}
+	store.Users.Range(func(username string, passwordHash string) bool {
+		claimUsername(store, username)
+		return true
+	})
+}
+
+// claimUsername atomically takes the name, whatever its case, for username.
+// It is false if someone already has it.
+func claimUsername(store *Store, username string) bool {
+	claimed := false
+	store.Usernames.Update(strings.ToLower(username), func(owner string, exists bool) (string, bool) {
+		if exists {
+			return owner, true
+		}
+		claimed = true
+		return username, true
+	})
+	return claimed
+}
+
+// backfillHistories fills in who wrote what for stores saved before users
+// had a history
+func backfillHistories(store *Store) {
+	if store.UserPosts.Len() == 0 {
+		store.Posts.Range(func(postId string, post *StoredPost) bool {
+			appendID(store.UserPosts, post.AuthorId, postId)
+			return true
+		})
+	}
+	if store.UserComments.Len() == 0 {
+		store.Comments.Range(func(commentId string, comment *StoredComment) bool {
+			appendID(store.UserComments, comment.AuthorId, commentId)
+			return true
+		})
+	}
+}
+
+// backfillUserTokens indexes the tokens of stores saved before each user's
+// tokens were listed
+func backfillUserTokens(store *Store) {
+	if store.UserTokens.Len() > 0 {
+		return
+	}
+	store.Tokens.Range(func(key string, record *authToken) bool {
+		appendID(store.UserTokens, record.Username, key)
+		return true
+	})
+}
+
+// NewMemoryStore returns an empty store that lives only in memory
+func NewMemoryStore() *Store {
+	store, _ := OpenStore(storage.NewMemoryBackend())
+	return store
+}
+
+// Close flushes and releases the backend
+func (store *Store) Close() error {
+	return store.backend.Close()
+}
+
+// appendID atomically appends id to the list stored under key
+func appendID(table *storage.List[string], key, id string) error {
+	return table.Append(key, id)
+}
+
+// removeIDFrom atomically removes id from the list stored under key, dropping
+// the key once the list is empty
+func removeIDFrom(table *storage.List[string], key, id string) error {
+	return table.Remove(key, func(existing string) bool { return existing == id })
+}
@@ -3,20 +3,16 @@ package actors
 import (
 	"fmt"
 	"reddit/messages"
-	"sync"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
 )
 
-// Global shared state for all subreddit actors
-var (
-	globalSubreddits = make(map[string]*Subreddit)
-	subredditMutex  sync.RWMutex
-)
-
 type SubredditActor struct {
 	system *actor.ActorSystem
+	store  *Store
 }
 
 type Subreddit struct {
@@ -24,11 +20,86 @@ type Subreddit struct {
 	Description string
 	CreatorId   string
 	Members     map[string]bool
+	Moderators  map[string][]string // username -> permissions; the creator moderates regardless
+	ModInvites  map[string][]string // username -> permissions offered
+
+	Type               string               // messages.SubredditPublic, Restricted or Private; empty is public
+	JoinRequests       map[string]int64     // username -> when they asked to join a private subreddit
+	ApprovedSubmitters map[string]bool      // username -> true
+	Bans               map[string]*Sanction // username -> ban, possibly expired
+	Mutes              map[string]*Sanction // username -> mute, possibly expired
+
+	AutoModRules []*messages.AutoModRule // Checked in order; replaced whole, never changed in place
+
+	Sidebar      string
+	BannerText   string
+	Rules        []messages.SubredditRule // Replaced whole, never changed in place
+	NSFW         bool
+	RequireFlair bool
+}
+
+// ArchivedSubreddit is a deleted subreddit, kept with the IDs of its posts,
+// which are kept in the post and comment archives
+type ArchivedSubreddit struct {
+	Subreddit *Subreddit
+	DeletedBy string
+	DeletedAt int64
+	PostIds   []string
+	Complete  bool // Every post is archived; false while deleting or if it was interrupted
+}
+
+// Limits on what moderators write about their subreddit
+const (
+	maxDescriptionLength = 500
+	maxSidebarLength     = 10240
+	maxBannerTextLength  = 200
+	maxSubredditRules    = 15
+	maxRuleTitleLength   = 100
+)
+
+// archiveRounds is how many times deleting a subreddit goes through its
+// posts, catching any created while the previous round ran
+const archiveRounds = 3
+
+// clone copies the subreddit so its owner can change it without racing
+// readers that still hold the stored value
+func (subreddit *Subreddit) clone() *Subreddit {
+	copied := *subreddit
+	copied.Members = make(map[string]bool, len(subreddit.Members))
+	for member, joined := range subreddit.Members {
+		copied.Members[member] = joined
+	}
+	copied.Moderators = make(map[string][]string, len(subreddit.Moderators))
+	for username, permissions := range subreddit.Moderators {
+		copied.Moderators[username] = permissions
+	}
+	copied.ModInvites = make(map[string][]string, len(subreddit.ModInvites))
+	for username, permissions := range subreddit.ModInvites {
+		copied.ModInvites[username] = permissions
+	}
+	copied.JoinRequests = make(map[string]int64, len(subreddit.JoinRequests))
+	for username, at := range subreddit.JoinRequests {
+		copied.JoinRequests[username] = at
+	}
+	copied.ApprovedSubmitters = make(map[string]bool, len(subreddit.ApprovedSubmitters))
+	for username, approved := range subreddit.ApprovedSubmitters {
+		copied.ApprovedSubmitters[username] = approved
+	}
+	copied.Bans = make(map[string]*Sanction, len(subreddit.Bans))
+	for username, ban := range subreddit.Bans {
+		copied.Bans[username] = ban
+	}
+	copied.Mutes = make(map[string]*Sanction, len(subreddit.Mutes))
+	for username, mute := range subreddit.Mutes {
+		copied.Mutes[username] = mute
+	}
+	return &copied
 }
 
-func NewSubredditActor(system *actor.ActorSystem) *SubredditActor {
+func NewSubredditActor(system *actor.ActorSystem, store *Store) *SubredditActor {
 	return &SubredditActor{
 		system: system,
+		store:  store,
 	}
 }
 
@@ -38,24 +109,37 @@ func (state *SubredditActor) Receive(context actor.Context) {
 			fmt.Printf("SubredditActor: Creating subreddit %s\n", msg.Name)
 			response := &messages.CreateSubredditResponse{}
 
-			subredditMutex.Lock()
-			if _, exists := globalSubreddits[msg.Name]; exists {
-				subredditMutex.Unlock()
+			subredditType, err := checkSubredditType(msg.Type)
+			if err != nil {
+				response.Success = false
+				response.Error = err.Error()
+			} else if state.store.Subreddits.Has(msg.Name) {
 				fmt.Printf("SubredditActor: Subreddit %s already exists\n", msg.Name)
 				response.Success = false
 				response.Error = "Subreddit already exists"
+			} else if state.store.ArchivedSubreddits.Has(msg.Name) {
+				// The archive and mod log are kept under the name
+				response.Success = false
+				response.Error = "The name belonged to a deleted subreddit"
 			} else {
 				subreddit := &Subreddit{
 					Name:        msg.Name,
 					Description: msg.Description,
 					CreatorId:   msg.CreatorId,
 					Members:     make(map[string]bool),
+					Moderators:  map[string][]string{msg.CreatorId: {messages.ModPermAll}},
+					ModInvites:  make(map[string][]string),
+
+					Type:               subredditType,
+					JoinRequests:       make(map[string]int64),
+					ApprovedSubmitters: make(map[string]bool),
+					Bans:               make(map[string]*Sanction),
+					Mutes:              make(map[string]*Sanction),
 				}
-				globalSubreddits[msg.Name] = subreddit
-				subredditMutex.Unlock()
+				state.store.Subreddits.Put(msg.Name, subreddit)
+				state.store.SubredditIndex.Add(subredditDocument(subreddit))
 				
-				fmt.Printf("SubredditActor: Created subreddit %s. Current subreddits: %v\n", 
-					msg.Name, globalSubreddits)
+				fmt.Printf("SubredditActor: Created subreddit %s\n", msg.Name)
 				response.Success = true
 				response.SubId = msg.Name
 				response.ActorPID = context.Self()
@@ -68,15 +152,32 @@ func (state *SubredditActor) Receive(context actor.Context) {
 				msg.SubredditName, msg.UserId)
 			response := &messages.JoinSubredditResponse{}
 
-			subredditMutex.Lock()
-			subreddit, exists := globalSubreddits[msg.SubredditName]
+			subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
 			if exists {
 				if _, isMember := subreddit.Members[msg.UserId]; isMember {
 					fmt.Printf("SubredditActor: User %s is already a member\n", msg.UserId)
 					response.Success = false
 					response.Error = "User is already a member"
+				} else if err := subreddit.checkParticipation(msg.UserId, participateJoin, time.Now()); err != nil {
+					response.Success = false
+					response.Error = err.Error()
+				} else if _, pending := subreddit.JoinRequests[msg.UserId]; pending {
+					response.Success = false
+					response.Error = "Join request already pending"
+				} else if subreddit.Type == messages.SubredditPrivate && !subreddit.isModerator(msg.UserId) {
+					// Private subreddits wait for a moderator to let them in
+					subreddit = subreddit.clone()
+					subreddit.JoinRequests[msg.UserId] = time.Now().Unix()
+					state.store.Subreddits.Put(msg.SubredditName, subreddit)
+					fmt.Printf("SubredditActor: Queued join request from %s\n", msg.UserId)
+					response.Success = true
+					response.Pending = true
+					response.SubId = msg.SubredditName
 				} else {
+					subreddit = subreddit.clone()
 					subreddit.Members[msg.UserId] = true
+					state.store.Subreddits.Put(msg.SubredditName, subreddit)
+					state.store.SubredditIndex.Add(subredditDocument(subreddit))
 					fmt.Printf("SubredditActor: Added user %s as member. Current members: %v\n", 
 						msg.UserId, subreddit.Members)
 					response.Success = true
@@ -87,7 +188,6 @@ func (state *SubredditActor) Receive(context actor.Context) {
 				response.Success = false
 				response.Error = "Subreddit not found"
 			}
-			subredditMutex.Unlock()
 
 			context.Respond(response)
 
@@ -95,10 +195,8 @@ func (state *SubredditActor) Receive(context actor.Context) {
 			fmt.Printf("SubredditActor: Getting members for subreddit %s\n", msg.SubredditName)
 			response := &messages.GetSubredditMembersResponse{}
 
-			subredditMutex.RLock()
-			subreddit, exists := globalSubreddits[msg.SubredditName]
-			fmt.Printf("SubredditActor: Subreddit exists: %v, Members: %v\n", exists, subreddit.Members)
-			subredditMutex.RUnlock()
+			subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
+			fmt.Printf("SubredditActor: Subreddit exists: %v\n", exists)
 
 			if exists {
 				members := make([]string, 0, len(subreddit.Members))
@@ -119,13 +217,14 @@ func (state *SubredditActor) Receive(context actor.Context) {
 		case *messages.LeaveSubreddit:
 			response := &messages.LeaveSubredditResponse{}
 
-			subredditMutex.RLock()
-			subreddit, exists := globalSubreddits[msg.SubredditName]
-			subredditMutex.RUnlock()
+			subreddit, exists := state.store.Subreddits.Get(msg.SubredditName)
 
 			if exists {
 				if _, isMember := subreddit.Members[msg.UserId]; isMember {
+					subreddit = subreddit.clone()
 					delete(subreddit.Members, msg.UserId)
+					state.store.Subreddits.Put(msg.SubredditName, subreddit)
+					state.store.SubredditIndex.Add(subredditDocument(subreddit))
 					response.Success = true
 					response.SubId = msg.SubredditName
 				} else {
@@ -144,69 +243,415 @@ func (state *SubredditActor) Receive(context actor.Context) {
 			response := &messages.GetSubredditsResponse{}
 			response.Success = true
 
-			subredditMutex.RLock()
-			allSubreddits := make([]string, 0, len(globalSubreddits))
-			for subredditName := range globalSubreddits {
+			allSubreddits := make([]string, 0, state.store.Subreddits.Len())
+			state.store.Subreddits.Range(func(subredditName string, subreddit *Subreddit) bool {
 				fmt.Printf("SubredditActor: Found subreddit: %s\n", subredditName)
 				allSubreddits = append(allSubreddits, subredditName)
-			}
-			subredditMutex.RUnlock()
+				return true
+			})
 
 			response.Subreddits = allSubreddits
 			context.Respond(response)
 
+		case *messages.EditSubreddit:
+			response := state.handleEdit(msg)
+			context.Respond(response)
+
+		case *messages.GetSubreddit:
+			response := state.handleGet(msg)
+			context.Respond(response)
+
 		case *messages.DeleteSubreddit:
-			response := state.handleDelete(msg)
+			state.handleDelete(context, msg)
+
+		case *messages.DropSubredditUser:
+			response := state.handleDropUser(msg)
+			context.Respond(response)
+
+		case *messages.InviteModerator:
+			response := state.handleInviteModerator(msg)
+			context.Respond(response)
+
+		case *messages.AcceptModeratorInvite:
+			response := state.handleAcceptModeratorInvite(msg)
+			context.Respond(response)
+
+		case *messages.SetModeratorPermissions:
+			response := state.handleSetModeratorPermissions(msg)
+			context.Respond(response)
+
+		case *messages.RemoveModerator:
+			response := state.handleRemoveModerator(msg)
+			context.Respond(response)
+
+		case *messages.GetModerators:
+			response := state.handleGetModerators(msg)
+			context.Respond(response)
+
+		case *messages.BanUser:
+			response := state.handleBanUser(msg)
+			context.Respond(response)
+
+		case *messages.UnbanUser:
+			response := state.handleUnbanUser(msg)
+			context.Respond(response)
+
+		case *messages.MuteUser:
+			response := state.handleMuteUser(msg)
+			context.Respond(response)
+
+		case *messages.UnmuteUser:
+			response := state.handleUnmuteUser(msg)
+			context.Respond(response)
+
+		case *messages.SetApprovedSubmitter:
+			response := state.handleSetApprovedSubmitter(msg)
+			context.Respond(response)
+
+		case *messages.GetSubredditUsers:
+			response := state.handleGetSubredditUsers(msg)
+			context.Respond(response)
+
+		case *messages.SetAutoModRules:
+			response := state.handleSetAutoModRules(msg)
 			context.Respond(response)
+
+		case *messages.GetAutoModRules:
+			response := state.handleGetAutoModRules(msg)
+			context.Respond(response)
+
+		case *messages.GetModLog:
+			response := state.handleGetModLog(msg)
+			context.Respond(response)
+
+		case *messages.GetModQueue:
+			response := state.handleGetModQueue(msg)
+			context.Respond(response)
+
+		case *messages.GetJoinRequests:
+			response := state.handleGetJoinRequests(msg)
+			context.Respond(response)
+
+		case *messages.RespondJoinRequest:
+			response := state.handleRespondJoinRequest(msg)
+			context.Respond(response)
+
+		case *messages.SearchSubreddits:
+			response := state.handleSearch(msg)
+			context.Respond(response)
+
+		case *messages.Autocomplete:
+			context.Respond(autocomplete(state.store.SubredditIndex, msg))
 	}
 }
 
-func (state *SubredditActor) handleDelete(msg *messages.DeleteSubreddit) *messages.DeleteSubredditResponse {
-	subredditMutex.Lock()
-	defer subredditMutex.Unlock()
+func (state *SubredditActor) handleSearch(msg *messages.SearchSubreddits) *messages.SearchSubredditsResponse {
+	fmt.Printf("SubredditActor: Searching for query: %s\n", msg.Query)
 
-	subreddit, exists := globalSubreddits[msg.Name]
+	hits, links, err := searchIndex(state.store.SubredditIndex, "search/subreddits", msg.Query, msg.Page, nil)
+	if err != nil {
+		return &messages.SearchSubredditsResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	results := make([]*messages.SubredditResult, 0, len(hits))
+	for _, hit := range hits {
+		if subreddit, exists := state.store.Subreddits.Get(hit.ID); exists {
+			results = append(results, &messages.SubredditResult{
+				Name:        subreddit.Name,
+				Description: subreddit.Description,
+				MemberCount: len(subreddit.Members),
+			})
+		}
+	}
+	return &messages.SearchSubredditsResponse{
+		Success:    true,
+		Subreddits: results,
+		PageLinks:  links,
+	}
+}
+
+// handleEdit changes the description or type, for moderators with the config
+// permission
+func (state *SubredditActor) handleEdit(msg *messages.EditSubreddit) *messages.EditSubredditResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.Name)
 	if !exists {
-		return &messages.DeleteSubredditResponse{
+		return &messages.EditSubredditResponse{
 			Success: false,
 			Error:   "Subreddit not found",
 		}
 	}
+	if !subreddit.permits(msg.AuthorId, messages.ModPermConfig) {
+		return &messages.EditSubredditResponse{
+			Success: false,
+			Error:   "Not authorized to edit this subreddit",
+		}
+	}
+	if err := checkSubredditEdit(msg); err != nil {
+		return &messages.EditSubredditResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
 
-	// Verify ownership
-	if subreddit.CreatorId != msg.AuthorId {
-		return &messages.DeleteSubredditResponse{
+	subreddit = subreddit.clone()
+	changed := make([]string, 0, 2)
+	if msg.Description != "" && msg.Description != subreddit.Description {
+		subreddit.Description = msg.Description
+		changed = append(changed, "description")
+	}
+	if msg.Type != "" {
+		subredditType, err := checkSubredditType(msg.Type)
+		if err != nil {
+			return &messages.EditSubredditResponse{
+				Success: false,
+				Error:   err.Error(),
+			}
+		}
+		// Anyone still waiting can just join once it's no longer private
+		if subredditType != messages.SubredditPrivate {
+			subreddit.JoinRequests = make(map[string]int64)
+		}
+		if subredditType != subreddit.Type {
+			changed = append(changed, "type: "+subredditType)
+		}
+		subreddit.Type = subredditType
+	}
+	if msg.Sidebar != nil && *msg.Sidebar != subreddit.Sidebar {
+		subreddit.Sidebar = *msg.Sidebar
+		changed = append(changed, "sidebar")
+	}
+	if msg.BannerText != nil && *msg.BannerText != subreddit.BannerText {
+		subreddit.BannerText = *msg.BannerText
+		changed = append(changed, "banner")
+	}
+	if msg.Rules != nil {
+		subreddit.Rules = append([]messages.SubredditRule{}, msg.Rules...)
+		changed = append(changed, fmt.Sprintf("rules: %d", len(msg.Rules)))
+	}
+	if msg.NSFW != nil && *msg.NSFW != subreddit.NSFW {
+		subreddit.NSFW = *msg.NSFW
+		changed = append(changed, fmt.Sprintf("nsfw: %v", subreddit.NSFW))
+	}
+	if msg.RequireFlair != nil && *msg.RequireFlair != subreddit.RequireFlair {
+		subreddit.RequireFlair = *msg.RequireFlair
+		changed = append(changed, fmt.Sprintf("require flair: %v", subreddit.RequireFlair))
+	}
+
+	if err := state.store.Subreddits.Put(msg.Name, subreddit); err != nil {
+		return &messages.EditSubredditResponse{
 			Success: false,
-			Error:   "Not authorized to delete this subreddit",
+			Error:   "Failed to save subreddit",
 		}
 	}
+	state.store.SubredditIndex.Add(subredditDocument(subreddit))
+	if len(changed) > 0 {
+		logModAction(state.store, msg.Name, &ModAction{
+			Moderator: msg.AuthorId,
+			Action:    messages.ModLogEditSettings,
+			Details:   strings.Join(changed, ", "),
+		})
+	}
+	return &messages.EditSubredditResponse{Success: true}
+}
 
-	// Delete all posts in subreddit
-	if msg.ActorPID != nil {
-		deletePostsMsg := &messages.DeleteSubredditPosts{
-			SubredditName: msg.Name,
-			ActorPID: msg.ActorPID,
+// checkSubredditEdit holds what moderators write to the limits
+func checkSubredditEdit(msg *messages.EditSubreddit) error {
+	if len(msg.Description) > maxDescriptionLength {
+		return fmt.Errorf("Description must be at most %d characters", maxDescriptionLength)
+	}
+	if msg.Sidebar != nil && len(*msg.Sidebar) > maxSidebarLength {
+		return fmt.Errorf("Sidebar must be at most %d characters", maxSidebarLength)
+	}
+	if msg.BannerText != nil && len(*msg.BannerText) > maxBannerTextLength {
+		return fmt.Errorf("Banner text must be at most %d characters", maxBannerTextLength)
+	}
+	if len(msg.Rules) > maxSubredditRules {
+		return fmt.Errorf("At most %d rules are allowed", maxSubredditRules)
+	}
+	for i, rule := range msg.Rules {
+		if strings.TrimSpace(rule.Title) == "" {
+			return fmt.Errorf("Rule %d needs a title", i+1)
+		}
+		if len(rule.Title) > maxRuleTitleLength {
+			return fmt.Errorf("Rule %d: title must be at most %d characters", i+1, maxRuleTitleLength)
 		}
-		
-		future := state.system.Root.RequestFuture(msg.ActorPID, deletePostsMsg, 5*time.Second)
-		if response, err := future.Result(); err != nil {
-			return &messages.DeleteSubredditResponse{
+		if len(rule.Description) > maxDescriptionLength {
+			return fmt.Errorf("Rule %d: description must be at most %d characters", i+1, maxDescriptionLength)
+		}
+	}
+	return nil
+}
+
+// checkPostSettings holds a new post to the subreddit's settings
+func checkPostSettings(store *Store, subredditName, flair string) error {
+	subreddit, exists := store.Subreddits.Get(subredditName)
+	if exists && subreddit.RequireFlair && strings.TrimSpace(flair) == "" {
+		return fmt.Errorf("Posts in this subreddit need a flair")
+	}
+	return nil
+}
+
+// handleGet describes the subreddit. Private subreddits still show who they
+// are, so people know what they ask to join.
+func (state *SubredditActor) handleGet(msg *messages.GetSubreddit) *messages.GetSubredditResponse {
+	subreddit, exists := state.store.Subreddits.Get(msg.Name)
+	if !exists {
+		return &messages.GetSubredditResponse{Success: false, Error: "Subreddit not found"}
+	}
+
+	moderators := []string{subreddit.CreatorId}
+	for username := range subreddit.Moderators {
+		if username != subreddit.CreatorId {
+			moderators = append(moderators, username)
+		}
+	}
+	sort.Strings(moderators[1:])
+	rules := subreddit.Rules
+	if rules == nil {
+		rules = []messages.SubredditRule{}
+	}
+	subredditType := subreddit.Type
+	if subredditType == "" {
+		subredditType = messages.SubredditPublic
+	}
+
+	return &messages.GetSubredditResponse{
+		Success: true,
+		Subreddit: &messages.SubredditInfo{
+			Name:         subreddit.Name,
+			Description:  subreddit.Description,
+			Sidebar:      subreddit.Sidebar,
+			BannerText:   subreddit.BannerText,
+			Rules:        rules,
+			Type:         subredditType,
+			NSFW:         subreddit.NSFW,
+			RequireFlair: subreddit.RequireFlair,
+			CreatorId:    subreddit.CreatorId,
+			Moderators:   moderators,
+			MemberCount:  len(subreddit.Members),
+		},
+	}
+}
+
+// handleDelete archives the subreddit first, so nothing new is posted to it,
+// then has every post archived by its owner, answering through context when
+// they all are. If that is interrupted, deleting again picks up where it
+// stopped.
+func (state *SubredditActor) handleDelete(context actor.Context, msg *messages.DeleteSubreddit) {
+	subreddit, exists := state.store.Subreddits.Get(msg.Name)
+	archived, wasArchived := state.store.ArchivedSubreddits.Get(msg.Name)
+	if !exists && (!wasArchived || archived.Complete) {
+		context.Respond(&messages.DeleteSubredditResponse{
+			Success: false,
+			Error:   "Subreddit not found",
+		})
+		return
+	}
+	if !exists {
+		subreddit = archived.Subreddit
+	}
+
+	// Verify ownership; admins clean up after creators who deleted their account
+	if subreddit.CreatorId != msg.AuthorId && !(subreddit.CreatorId == deletedPlaceholder && state.store.isAdmin(msg.AuthorId)) {
+		context.Respond(&messages.DeleteSubredditResponse{
+			Success: false,
+			Error:   "Not authorized to delete this subreddit",
+		})
+		return
+	}
+
+	if exists {
+		err := state.store.ArchivedSubreddits.Put(msg.Name, &ArchivedSubreddit{
+			Subreddit: subreddit,
+			DeletedBy: msg.AuthorId,
+			DeletedAt: time.Now().Unix(),
+		})
+		if err != nil {
+			context.Respond(&messages.DeleteSubredditResponse{
 				Success: false,
-				Error:   "Failed to delete posts: " + err.Error(),
-			}
-		} else {
-			if deleteResponse, ok := response.(*messages.DeleteSubredditPostsResponse); !ok || !deleteResponse.Success {
-				return &messages.DeleteSubredditResponse{
-					Success: false,
-					Error:   "Failed to delete posts: " + deleteResponse.Error,
-				}
-			}
+				Error:   "Failed to archive subreddit",
+			})
+			return
 		}
+		state.store.Subreddits.Delete(msg.Name)
+		state.store.autoMod.forget(msg.Name)
+		state.store.SubredditIndex.Remove(msg.Name)
+		logModAction(state.store, msg.Name, &ModAction{
+			Moderator: msg.AuthorId,
+			Action:    messages.ModLogDeleteSubreddit,
+		})
 	}
 
-	// Delete the subreddit itself (members are part of the subreddit struct)
-	delete(globalSubreddits, msg.Name)
+	state.archivePosts(context, msg.Name, 0, func(err error) {
+		if err != nil {
+			context.Respond(&messages.DeleteSubredditResponse{
+				Success: false,
+				Error:   err.Error() + "; delete the subreddit again to finish",
+			})
+			return
+		}
+		state.store.ArchivedSubreddits.Update(msg.Name, func(archived *ArchivedSubreddit, exists bool) (*ArchivedSubreddit, bool) {
+			if !exists {
+				return nil, false
+			}
+			copied := *archived
+			copied.Complete = true
+			return &copied, true
+		})
+		context.Respond(&messages.DeleteSubredditResponse{Success: true})
+	})
+}
+
+// archivePosts has the owner of every post in the subreddit archive and
+// delete it along with its comments, going round again for posts that
+// arrived meanwhile, then calls done
+func (state *SubredditActor) archivePosts(context actor.Context, name string, round int, done func(err error)) {
+	postIds, _ := state.store.SubredditPosts.Get(name)
+	if context.Parent() == nil || len(postIds) == 0 {
+		done(nil)
+		return
+	}
+	if round == archiveRounds {
+		done(fmt.Errorf("Posts kept arriving while deleting"))
+		return
+	}
 
-	return &messages.DeleteSubredditResponse{Success: true}
+	requests := make([]interface{}, 0, len(postIds))
+	for _, postId := range postIds {
+		requests = append(requests, &messages.DeletePost{
+			PostId:  postId,
+			Cascade: true,
+		})
+	}
+	requestInTurn(context, requests, func(request, response interface{}) error {
+		postId := request.(*messages.DeletePost).PostId
+		deleteResponse, ok := response.(*messages.DeletePostResponse)
+		switch {
+		case ok && deleteResponse.Success:
+			state.store.ArchivedSubreddits.Update(name, func(archived *ArchivedSubreddit, exists bool) (*ArchivedSubreddit, bool) {
+				if !exists {
+					return nil, false
+				}
+				copied := *archived
+				copied.PostIds = append(append([]string{}, archived.PostIds...), postId)
+				return &copied, true
+			})
+		case ok && deleteResponse.Error == "Post not found":
+			// Its author deleted it in the meantime
+			removeIDFrom(state.store.SubredditPosts, name, postId)
+		default:
+			return fmt.Errorf("Failed to delete post %s", postId)
+		}
+		return nil
+	}, func(err error) {
+		if err != nil {
+			done(err)
+			return
+		}
+		state.archivePosts(context, name, round+1, done)
+	})
 }
 
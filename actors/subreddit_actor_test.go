@@ -0,0 +1,54 @@
+package actors
+
+import (
+	"reddit/messages"
+	"strings"
+	"testing"
+)
+
+func TestEditSubreddit(t *testing.T) {
+	store := newTestStore(t)
+	state := NewSubredditActor(nil, store)
+	setupSubreddit(t, store, "golang", func(subreddit *Subreddit) {
+		subreddit.Members["reader"] = true
+		subreddit.Moderators["helper"] = []string{messages.ModPermPosts}
+	})
+
+	sidebar, yes := "Links and FAQ", true
+	long := strings.Repeat("x", maxBannerTextLength+1)
+	for _, bad := range []*messages.EditSubreddit{
+		{Name: "golang", AuthorId: "helper", Sidebar: &sidebar},
+		{Name: "golang", AuthorId: "owner", BannerText: &long},
+		{Name: "golang", AuthorId: "owner", Rules: []messages.SubredditRule{{Description: "no title"}}},
+	} {
+		if response := state.handleEdit(bad); response.Success {
+			t.Errorf("handleEdit(%+v) succeeded", bad)
+		}
+	}
+
+	edit := &messages.EditSubreddit{
+		Name:         "golang",
+		AuthorId:     "owner",
+		Sidebar:      &sidebar,
+		Rules:        []messages.SubredditRule{{Title: "Be kind"}, {Title: "No spam"}},
+		RequireFlair: &yes,
+	}
+	if response := state.handleEdit(edit); !response.Success {
+		t.Fatalf("handleEdit() error = %s", response.Error)
+	}
+	// Fields left out stay as they were
+	if response := state.handleEdit(&messages.EditSubreddit{Name: "golang", AuthorId: "owner", Description: "Gophers"}); !response.Success {
+		t.Fatalf("handleEdit() error = %s", response.Error)
+	}
+
+	info := state.handleGet(&messages.GetSubreddit{Name: "golang"}).Subreddit
+	if info.Sidebar != sidebar || len(info.Rules) != 2 || !info.RequireFlair || info.Description != "Gophers" {
+		t.Errorf("handleGet() = %+v, want the sidebar, two rules, required flair and the description", info)
+	}
+	if info.Type != messages.SubredditPublic || info.MemberCount != 1 || len(info.Moderators) != 2 || info.Moderators[0] != "owner" {
+		t.Errorf("handleGet() = %+v, want public with one member and the creator listed first", info)
+	}
+	if err := checkPostSettings(store, "golang", ""); err == nil {
+		t.Errorf("a post without flair was allowed")
+	}
+}
@@ -1,42 +1,39 @@
 package actors
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"reddit/messages"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// Global shared state for all user actors
-var (
-	globalUsers = make(map[string]string) // username -> password
-	globalKarma = make(map[string]int)    // userID -> karma
-	userMutex   sync.RWMutex
-)
+// UserKarma keeps post and comment karma apart, like Reddit's profile page
+type UserKarma struct {
+	PostKarma    int
+	CommentKarma int
+}
 
-type UserActor struct {}
+// dummyPasswordHash is compared against when a login names an unknown user, so
+// that unknown and known usernames take about the same time to reject
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
 
-func NewUserActor() *UserActor {
-	return &UserActor{}
+type UserActor struct {
+	store *Store
 }
 
-func (state *UserActor) ValidateToken(token string) (string, bool) {
-	// Token format: "reddit-token-username"
-	parts := strings.Split(token, "-")
-	if len(parts) != 3 || parts[0] != "reddit" || parts[1] != "token" {
-		return "", false
+func NewUserActor(store *Store) *UserActor {
+	return &UserActor{
+		store: store,
 	}
+}
 
-	username := parts[2]
-	
-	// Check if user exists
-	userMutex.RLock()
-	_, exists := globalUsers[username]
-	userMutex.RUnlock()
-
-	return username, exists
+// ValidateToken checks an access token and returns the record it was issued as
+func (state *UserActor) ValidateToken(token string) (*authToken, error) {
+	return lookupToken(state.store, token, tokenKindAccess, time.Now())
 }
 
 func (state *UserActor) Receive(context actor.Context) {
@@ -45,17 +42,37 @@ func (state *UserActor) Receive(context actor.Context) {
 			fmt.Printf("UserActor: Registration attempt for user: %s\n", msg.Username)
 			response := &messages.RegisterUserResponse{}
 
-			userMutex.Lock()
-			if _, exists := globalUsers[msg.Username]; exists {
-				userMutex.Unlock()
+			// The name is checked and claimed before the costly hash, so
+			// names that are taken or broken don't pay for one
+			msg.Email = strings.TrimSpace(msg.Email)
+			err := checkUsername(msg.Username)
+			if err == nil && msg.Email != "" {
+				err = checkEmail(msg.Email)
+			}
+			var passwordHash string
+
+			if err != nil {
+				response.Success = false
+				response.Error = err.Error()
+			} else if !claimUsername(state.store, msg.Username) {
 				fmt.Printf("UserActor: Username %s already exists\n", msg.Username)
 				response.Success = false
 				response.Error = "Username already exists"
+			} else if passwordHash, err = hashPassword(msg.Password); err != nil {
+				state.store.Usernames.Delete(strings.ToLower(msg.Username))
+				response.Success = false
+				response.Error = err.Error()
+			} else if err := state.store.Users.Put(msg.Username, passwordHash); err != nil {
+				state.store.Usernames.Delete(strings.ToLower(msg.Username))
+				response.Success = false
+				response.Error = "Failed to save user"
 			} else {
-				globalUsers[msg.Username] = msg.Password
-				userMutex.Unlock()
-				fmt.Printf("UserActor: Successfully registered user %s. Current users: %v\n", 
-					msg.Username, globalUsers)
+				state.store.Profiles.Put(msg.Username, &UserProfile{CreatedAt: time.Now().Unix(), Email: msg.Email})
+				state.store.UserIndex.Add(userDocument(msg.Username, ""))
+				if msg.Email != "" {
+					state.sendVerification(msg.Username, msg.Email)
+				}
+				fmt.Printf("UserActor: Successfully registered user %s\n", msg.Username)
 				response.Success = true
 				response.UserId = msg.Username
 				response.ActorPID = context.Self()
@@ -67,22 +84,45 @@ func (state *UserActor) Receive(context actor.Context) {
 			fmt.Printf("UserActor: Login attempt for user: %s\n", msg.Username)
 			response := &messages.LoginUserResponse{}
 
-			userMutex.RLock()
-			storedPassword, exists := globalUsers[msg.Username]
-			userMutex.RUnlock()
+			storedPassword, exists := state.store.Users.Get(msg.Username)
 
 			if exists {
 				fmt.Printf("UserActor: User exists, checking password\n")
-				if storedPassword == msg.Password {
-					response.Success = true
-					response.Token = "reddit-token-" + msg.Username
-					fmt.Printf("UserActor: Login successful\n")
-				} else {
+				match, legacy := checkPassword(storedPassword, msg.Password)
+				profile, _ := state.store.Profiles.Get(msg.Username)
+				if !match {
 					response.Success = false
 					response.Error = "Invalid credentials"
 					fmt.Printf("UserActor: Password mismatch\n")
+				} else if err := checkLogin(profile, time.Now()); err != nil {
+					response.Success = false
+					response.Error = err.Error()
+					fmt.Printf("UserActor: User %s is suspended\n", msg.Username)
+				} else {
+					if legacy {
+						state.upgradePassword(msg.Username, msg.Password)
+					}
+					if profile != nil && profile.Deactivated {
+						// Logging in again is how a deactivated account comes back
+						state.updateProfile(msg.Username, func(profile *UserProfile) { profile.Deactivated = false })
+						response.Reactivated = true
+					}
+
+					tokens, err := issueTokens(state.store, msg.Username, "", time.Now())
+
+					if err != nil {
+						response.Success = false
+						response.Error = "Failed to issue token"
+					} else {
+						response.Success = true
+						response.Token = tokens.AccessToken
+						response.RefreshToken = tokens.RefreshToken
+						response.ExpiresAt = tokens.ExpiresAt
+						fmt.Printf("UserActor: Login successful\n")
+					}
 				}
 			} else {
+				bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(msg.Password))
 				response.Success = false
 				response.Error = "Invalid credentials"
 				fmt.Printf("UserActor: User not found\n")
@@ -90,17 +130,35 @@ func (state *UserActor) Receive(context actor.Context) {
 
 			context.Respond(response)
 
+		case *messages.ChangePassword:
+			response := state.handleChangePassword(msg)
+			context.Respond(response)
+
+		case *messages.EditUserProfile:
+			response := state.handleEditProfile(msg)
+			context.Respond(response)
+
+		case *messages.GetUserProfile:
+			response := state.handleGetProfile(msg)
+			context.Respond(response)
+
 		case *messages.UpdateKarma:
-			userMutex.Lock()
-			if _, exists := globalUsers[msg.UserID]; exists {
-				globalKarma[msg.UserID] += msg.Change
+			if state.store.Users.Has(msg.UserID) {
+				karma := UserKarma{}
+				if current, ok := state.store.Karma.Get(msg.UserID); ok {
+					karma = *current
+				}
+				switch msg.Type {
+				case "post":
+					karma.PostKarma += msg.Change
+				case "comment":
+					karma.CommentKarma += msg.Change
+				}
+				state.store.Karma.Put(msg.UserID, &karma)
 			}
-			userMutex.Unlock()
 
 		case *messages.GetKarma:
-			userMutex.RLock()
-			if _, exists := globalUsers[msg.UserID]; !exists {
-				userMutex.RUnlock()
+			if !state.store.Users.Has(msg.UserID) {
 				context.Respond(&messages.GetKarmaResponse{
 					Success: false,
 					Error:   "User not found",
@@ -108,104 +166,279 @@ func (state *UserActor) Receive(context actor.Context) {
 				return
 			}
 
-			context.Respond(&messages.GetKarmaResponse{
-				Success: true,
-				Karma:   globalKarma[msg.UserID],
-			})
-			userMutex.RUnlock()
+			response := &messages.GetKarmaResponse{Success: true}
+			if karma, ok := state.store.Karma.Get(msg.UserID); ok {
+				response.PostKarma = karma.PostKarma
+				response.CommentKarma = karma.CommentKarma
+				response.Karma = karma.PostKarma + karma.CommentKarma
+			}
+			context.Respond(response)
 
 		case *messages.ValidateToken:
-			username, valid := state.ValidateToken(msg.Token)
-			response := &messages.ValidateTokenResponse{
-				Success:  valid,
-				Username: username,
-			}
-			if !valid {
-				response.Error = "Invalid token"
+			response := &messages.ValidateTokenResponse{}
+			if record, err := state.ValidateToken(msg.Token); err != nil {
+				response.Success = false
+				response.Error = err.Error()
+				response.ErrorCode = tokenErrorCode(err)
+			} else {
+				response.Success = true
+				response.Username = record.Username
+				response.SessionId = record.SessionId
 			}
 			context.Respond(response)
 
+		case *messages.RefreshToken:
+			response := state.handleRefreshToken(msg)
+			context.Respond(response)
+
+		case *messages.Logout:
+			response := state.handleLogout(msg)
+			context.Respond(response)
+
 		case *messages.GetFeed:
 			response := state.handleGetFeed(msg)
 			context.Respond(response)
+
+		case *messages.SearchUsers:
+			response := state.handleSearch(msg)
+			context.Respond(response)
+
+		case *messages.Autocomplete:
+			context.Respond(autocomplete(state.store.UserIndex, msg))
+
+		case *messages.DeactivateAccount:
+			response := state.handleDeactivate(msg)
+			context.Respond(response)
+
+		case *messages.DeleteAccount:
+			state.handleDeleteAccount(context, msg)
+
+		case *messages.SuspendUser:
+			response := state.handleSuspend(msg)
+			context.Respond(response)
+
+		case *messages.UnsuspendUser:
+			response := state.handleUnsuspend(msg)
+			context.Respond(response)
+
+		case *messages.VerifyEmail:
+			response := state.handleVerifyEmail(msg)
+			context.Respond(response)
+
+		case *messages.ResendVerification:
+			response := state.handleResendVerification(msg)
+			context.Respond(response)
+
+		case *messages.RequestPasswordReset:
+			response := state.handleRequestPasswordReset(msg)
+			context.Respond(response)
+
+		case *messages.ResetPassword:
+			response := state.handleResetPassword(msg)
+			context.Respond(response)
 	}
 }
 
-func (state *UserActor) handleGetFeed(msg *messages.GetFeed) *messages.FeedResponse {
-	fmt.Printf("UserActor: Getting feed for user %s\n", msg.UserId)
-	userMutex.RLock()
-	defer userMutex.RUnlock()
+func (state *UserActor) handleSearch(msg *messages.SearchUsers) *messages.SearchUsersResponse {
+	fmt.Printf("UserActor: Searching for query: %s\n", msg.Query)
 
-	feed := make([]*messages.SubredditFeed, 0)
+	hits, links, err := searchIndex(state.store.UserIndex, "search/users", msg.Query, msg.Page, nil)
+	if err != nil {
+		return &messages.SearchUsersResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
 
-	// Get all subreddits the user is a member of
-	for subredditName, subreddit := range globalSubreddits {
-		fmt.Printf("UserActor: Checking membership in %s\n", subredditName)
-		if _, isMember := subreddit.Members[msg.UserId]; isMember {
-			fmt.Printf("UserActor: User is member of %s\n", subredditName)
-			subredditFeed := &messages.SubredditFeed{
-				Name:        subredditName,
-				Description: subreddit.Description,
-				Posts:       make([]*messages.PostFeed, 0),
-			}
+	results := make([]*messages.UserResult, 0, len(hits))
+	for _, hit := range hits {
+		result := &messages.UserResult{Username: hit.ID}
+		if karma, exists := state.store.Karma.Get(hit.ID); exists {
+			result.Karma = karma.PostKarma + karma.CommentKarma
+		}
+		results = append(results, result)
+	}
+	return &messages.SearchUsersResponse{
+		Success:   true,
+		Users:     results,
+		PageLinks: links,
+	}
+}
 
-			// Get all posts for this subreddit
-			if posts, exists := subredditPosts[subredditName]; exists {
-				for _, postId := range posts {
-					if post, exists := globalPosts[postId]; exists {
-						postFeed := &messages.PostFeed{
-							PostId:        post.PostId,
-							Title:         post.Title,
-							Content:       post.Content,
-							AuthorId:      post.AuthorId,
-							SubredditName: post.SubredditName,
-							Comments:      make([]*messages.CommentFeed, 0),
-						}
-
-						// Get all comments for this post
-						if comments, exists := postComments[postId]; exists {
-							for _, commentId := range comments {
-								if comment, exists := globalComments[commentId]; exists {
-									commentFeed := buildCommentFeed(comment)
-									postFeed.Comments = append(postFeed.Comments, commentFeed)
-								}
-							}
-						}
-
-						subredditFeed.Posts = append(subredditFeed.Posts, postFeed)
-					}
-				}
-			}
+func (state *UserActor) handleRefreshToken(msg *messages.RefreshToken) *messages.RefreshTokenResponse {
+	now := time.Now()
+	record, err := lookupToken(state.store, msg.RefreshToken, tokenKindRefresh, now)
+	if err != nil {
+		return &messages.RefreshTokenResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
 
-			feed = append(feed, subredditFeed)
+	// Refresh tokens are single use: spend this one and rotate the session's
+	// access token along with it
+	revokeTokens(state.store, record.Username, func(other *authToken) bool {
+		return other.SessionId == record.SessionId
+	})
+
+	tokens, err := issueTokens(state.store, record.Username, record.SessionId, now)
+	if err != nil {
+		return &messages.RefreshTokenResponse{
+			Success: false,
+			Error:   "Failed to issue token",
 		}
 	}
 
-	return &messages.FeedResponse{
+	return &messages.RefreshTokenResponse{
+		Success:      true,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+	}
+}
+
+func (state *UserActor) handleLogout(msg *messages.Logout) *messages.LogoutResponse {
+	revoked := revokeTokens(state.store, msg.Username, func(record *authToken) bool {
+		return msg.AllSessions || record.SessionId == msg.SessionId
+	})
+
+	return &messages.LogoutResponse{
 		Success: true,
-		Feed:    feed,
+		Revoked: revoked,
+	}
+}
+
+func (state *UserActor) handleChangePassword(msg *messages.ChangePassword) *messages.ChangePasswordResponse {
+	storedPassword, exists := state.store.Users.Get(msg.Username)
+	if !exists {
+		return &messages.ChangePasswordResponse{
+			Success: false,
+			Error:   "User not found",
+		}
+	}
+
+	if match, _ := checkPassword(storedPassword, msg.OldPassword); !match {
+		return &messages.ChangePasswordResponse{
+			Success: false,
+			Error:   "Invalid credentials",
+		}
+	}
+
+	passwordHash, err := hashPassword(msg.NewPassword)
+	if err != nil {
+		return &messages.ChangePasswordResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
 	}
+
+	if err := state.store.Users.Put(msg.Username, passwordHash); err != nil {
+		return &messages.ChangePasswordResponse{
+			Success: false,
+			Error:   "Failed to save password",
+		}
+	}
+
+	// Sign out every other login and spend any pending reset or verify links,
+	// in case the old password leaked
+	revokeTokens(state.store, msg.Username, func(record *authToken) bool {
+		return msg.SessionId == "" || record.SessionId != msg.SessionId
+	})
+
+	return &messages.ChangePasswordResponse{Success: true}
 }
 
-func buildCommentFeed(comment *StoredComment) *messages.CommentFeed {
-	commentFeed := &messages.CommentFeed{
-		CommentId:  comment.CommentId,
-		Content:    comment.Content,
-		AuthorId:   comment.AuthorId,
-		Replies:    make([]*messages.CommentFeed, 0),
-		VoteCount:  calculateVotes(comment.Votes),
+// upgradePassword replaces a legacy plaintext entry with a bcrypt hash after a
+// successful login
+func (state *UserActor) upgradePassword(username, password string) {
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		fmt.Printf("UserActor: Could not upgrade password for %s: %v\n", username, err)
+		return
 	}
 
-	// Add replies recursively
-	if replies, exists := commentReplies[comment.CommentId]; exists {
-		for _, replyId := range replies {
-			if reply, exists := globalComments[replyId]; exists {
-				commentFeed.Replies = append(commentFeed.Replies, buildCommentFeed(reply))
+	if err := state.store.Users.Put(username, passwordHash); err == nil {
+		fmt.Printf("UserActor: Upgraded stored password for %s to bcrypt\n", username)
+	}
+}
+
+// hashPassword salts and hashes a password with bcrypt
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("Password is required")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err == bcrypt.ErrPasswordTooLong {
+		return "", fmt.Errorf("Password must be at most 72 bytes")
+	} else if err != nil {
+		return "", fmt.Errorf("Failed to hash password")
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash reports whether a stored credential is a bcrypt hash rather
+// than a plaintext password saved before hashing was introduced
+func isPasswordHash(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// checkPassword compares a password against a stored credential in constant
+// time. legacy is true when the credential was stored in plaintext and should
+// be rehashed now that the password is known to be right.
+func checkPassword(stored, password string) (match bool, legacy bool) {
+	if isPasswordHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil, false
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1, true
+}
+
+func (state *UserActor) handleGetFeed(msg *messages.GetFeed) *messages.FeedResponse {
+	fmt.Printf("UserActor: Getting feed for user %s\n", msg.UserId)
+
+	sortBy, period, err := checkRanking(msg.Sort, msg.Period)
+	if err != nil {
+		return &messages.FeedResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+
+	// Gather the posts of every subreddit the user is a member of, which
+	// keeps private subreddits to their members
+	posts := make([]*StoredPost, 0)
+	state.store.Subreddits.Range(func(subredditName string, subreddit *Subreddit) bool {
+		if _, isMember := subreddit.Members[msg.UserId]; isMember && subreddit.canView(msg.UserId) {
+			fmt.Printf("UserActor: User is member of %s\n", subredditName)
+			postIds, _ := state.store.SubredditPosts.Get(subredditName)
+			for _, postId := range postIds {
+				if post, exists := state.store.Posts.Get(postId); exists {
+					posts = append(posts, post)
+				}
 			}
 		}
+		return true
+	})
+
+	// Then rank them as one list and cut out the page
+	paged, links, err := pagePosts(posts, sortBy, period, msg.Page)
+	if err != nil {
+		return &messages.FeedResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
 	}
 
-	return commentFeed
+	feed := make([]*messages.PostFeed, 0, len(paged))
+	for _, post := range paged {
+		feed = append(feed, toPostFeed(state.store, post))
+	}
+
+	return &messages.FeedResponse{
+		Success:   true,
+		Posts:     feed,
+		PageLinks: links,
+	}
 }
 
 func calculateVotes(votes map[string]bool) int {
@@ -219,3 +452,24 @@ func calculateVotes(votes map[string]bool) int {
 	}
 	return count
 }
+
+// voteValue is the score contribution of a single vote
+func voteValue(isUpvote bool) int {
+	if isUpvote {
+		return 1
+	}
+	return -1
+}
+
+// sendKarmaUpdate asks the engine to move a user's karma. Actors spawned by
+// the engine reach it through their parent.
+func sendKarmaUpdate(context actor.Context, userID string, karmaType string, change int) {
+	if change == 0 || context.Parent() == nil {
+		return
+	}
+	context.Send(context.Parent(), &messages.UpdateKarma{
+		UserID: userID,
+		Change: change,
+		Type:   karmaType,
+	})
+}
@@ -1,70 +1,220 @@
 package actors
 
 import (
+	"reddit/messages"
 	"testing"
+	"time"
 )
 
 func TestValidateToken(t *testing.T) {
 	// Create a new UserActor
-	userActor := NewUserActor()
+	store := NewMemoryStore()
+	userActor := NewUserActor(store)
+
+	store.Users.Put("test-user-1", "password123")
+	now := time.Now()
+	valid, _ := issueTokens(store, "test-user-1", "", now)
+	expired, _ := issueTokens(store, "test-user-1", "", now.Add(-2*accessTokenTTL))
+	revoked, _ := issueTokens(store, "test-user-1", "", now)
+	revokeTokens(store, "test-user-1", func(record *authToken) bool { return record.SessionId == revoked.SessionId })
+
+	// The engine routes tokens by the username they carry
+	if got := tokenUsername(valid.AccessToken); got != "test-user-1" {
+		t.Fatalf("tokenUsername() = %q, want %q", got, "test-user-1")
+	}
 
 	// Setup test cases
 	tests := []struct {
 		name          string
 		token         string
 		wantUsername  string
-		wantValid     bool
-		setupUser     bool  // whether to create user before test
+		wantErr       error
 	}{
 		{
 			name:         "Valid token with existing user",
-			token:        "reddit-token-testuser1",
-			wantUsername: "testuser1",
-			wantValid:    true,
-			setupUser:    true,
+			token:        valid.AccessToken,
+			wantUsername: "test-user-1",
+			wantErr:      nil,
+		},
+		{
+			name:         "Old username-based token format",
+			token:        "reddit-token-test-user-1",
+			wantErr:      errTokenInvalid,
+		},
+		{
+			name:         "Refresh token used as access token",
+			token:        valid.RefreshToken,
+			wantErr:      errTokenInvalid,
 		},
 		{
-			name:         "Invalid token format",
-			token:        "invalid-token",
-			wantUsername: "",
-			wantValid:    false,
-			setupUser:    false,
+			name:         "Expired token",
+			token:        expired.AccessToken,
+			wantErr:      errTokenExpired,
 		},
 		{
-			name:         "Valid format but user doesn't exist",
-			token:        "reddit-token-nonexistent",
-			wantUsername: "nonexistent",
-			wantValid:    false,
-			setupUser:    false,
+			name:         "Revoked token",
+			token:        revoked.AccessToken,
+			wantErr:      errTokenRevoked,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup: Create user if needed
-			if tt.setupUser {
-				userMutex.Lock()
-				globalUsers[tt.wantUsername] = "password123"
-				userMutex.Unlock()
-			}
-
 			// Test token validation
-			gotUsername, gotValid := userActor.ValidateToken(tt.token)
+			record, err := userActor.ValidateToken(tt.token)
 
 			// Check results
-			if gotUsername != tt.wantUsername {
-				t.Errorf("ValidateToken() username = %v, want %v", gotUsername, tt.wantUsername)
+			if err != tt.wantErr {
+				t.Fatalf("ValidateToken() error = %v, want %v", err, tt.wantErr)
 			}
-			if gotValid != tt.wantValid {
-				t.Errorf("ValidateToken() valid = %v, want %v", gotValid, tt.wantValid)
+			if err == nil && record.Username != tt.wantUsername {
+				t.Errorf("ValidateToken() username = %v, want %v", record.Username, tt.wantUsername)
 			}
+		})
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := hashPassword("hunter2")
+	if err != nil {
+		t.Fatalf("hashPassword() error = %v", err)
+	}
+	if hash == "hunter2" || !isPasswordHash(hash) {
+		t.Fatalf("hashPassword() = %q, want a bcrypt hash", hash)
+	}
 
-			// Cleanup
-			if tt.setupUser {
-				userMutex.Lock()
-				delete(globalUsers, tt.wantUsername)
-				userMutex.Unlock()
+	tests := []struct {
+		name       string
+		stored     string
+		password   string
+		wantMatch  bool
+		wantLegacy bool
+	}{
+		{"Hashed, right password", hash, "hunter2", true, false},
+		{"Hashed, wrong password", hash, "hunter3", false, false},
+		{"Plaintext, right password", "hunter2", "hunter2", true, true},
+		{"Plaintext, wrong password", "hunter2", "hunter3", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMatch, gotLegacy := checkPassword(tt.stored, tt.password)
+			if gotMatch != tt.wantMatch {
+				t.Errorf("checkPassword() match = %v, want %v", gotMatch, tt.wantMatch)
+			}
+			if gotLegacy != tt.wantLegacy {
+				t.Errorf("checkPassword() legacy = %v, want %v", gotLegacy, tt.wantLegacy)
+			}
+		})
+	}
+}
+
+func TestChangePasswordRevokesOtherTokens(t *testing.T) {
+	store := NewMemoryStore()
+	userActor := NewUserActor(store)
+
+	hash, _ := hashPassword("password123")
+	store.Users.Put("test-user-1", hash)
+	now := time.Now()
+	current, _ := issueTokens(store, "test-user-1", "", now)
+	other, _ := issueTokens(store, "test-user-1", "", now)
+	reset, _ := issueMailedToken(store, "test-user-1", tokenKindReset, "user@example.com", time.Hour, now)
+
+	response := userActor.handleChangePassword(&messages.ChangePassword{
+		Username:    "test-user-1",
+		OldPassword: "password123",
+		NewPassword: "password456",
+		SessionId:   current.SessionId,
+	})
+	if !response.Success {
+		t.Fatalf("handleChangePassword() = %+v", response)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		kind    string
+		wantErr error
+	}{
+		{"Caller's access token", current.AccessToken, tokenKindAccess, nil},
+		{"Caller's refresh token", current.RefreshToken, tokenKindRefresh, nil},
+		{"Other session's access token", other.AccessToken, tokenKindAccess, errTokenRevoked},
+		{"Other session's refresh token", other.RefreshToken, tokenKindRefresh, errTokenRevoked},
+		{"Pending reset link", reset, tokenKindReset, errTokenRevoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := lookupToken(store, tt.token, tt.kind, now); err != tt.wantErr {
+				t.Errorf("lookupToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	refreshed := userActor.handleRefreshToken(&messages.RefreshToken{RefreshToken: other.RefreshToken, Username: "test-user-1"})
+	if refreshed.Success {
+		t.Errorf("an old refresh token still works: %+v", refreshed)
+	}
+}
+
+func TestPruneExpiredTokens(t *testing.T) {
+	store := NewMemoryStore()
+	store.Users.Put("test-user-1", "password123")
+	store.Users.Put("test-user-2", "password123")
+	now := time.Now()
+	longAgo := now.Add(-refreshTokenTTL - 48*time.Hour)
+	old, _ := issueTokens(store, "test-user-1", "", longAgo)
+	other, _ := issueTokens(store, "test-user-2", "", longAgo)
+	recent, _ := issueTokens(store, "test-user-1", "", now.Add(-2*accessTokenTTL))
+
+	// Issuing a token prunes only that user's long-expired tokens
+	issueTokens(store, "test-user-1", "", now)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"Long-expired token of the user", old.AccessToken, errTokenInvalid},
+		{"Recently expired token of the user", recent.AccessToken, errTokenExpired},
+		{"Long-expired token of another user", other.AccessToken, errTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := lookupToken(store, tt.token, tokenKindAccess, now); err != tt.wantErr {
+				t.Errorf("lookupToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if keys, _ := store.UserTokens.Get("test-user-1"); len(keys) != 4 {
+		t.Errorf("UserTokens = %d tokens, want the 4 still kept", len(keys))
+	}
+}
+
+func TestRegisterUser(t *testing.T) {
+	ask := newTestEngine(t, newTestStore(t))
+
+	// Each step builds on the ones before it
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  string
+	}{
+		{"Registered", "alice", "password123", ""},
+		{"Names are checked before passwords", "a", "", "Username must be 3 to 20 characters"},
+		{"Taken names are refused before hashing", "ALICE", "", "Username already exists"},
+		{"Missing password", "dave", "", "Password is required"},
+		{"A failed hash leaves the name free", "dave", "password123", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ask(&messages.RegisterUser{Username: tt.username, Password: tt.password}).(*messages.RegisterUserResponse)
+			if response.Error != tt.wantErr || response.Success != (tt.wantErr == "") {
+				t.Errorf("RegisterUser = %+v, want error %q", response, tt.wantErr)
 			}
 		})
 	}
-} 
\ No newline at end of file
+}
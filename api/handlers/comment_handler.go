@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"reddit/messages"
+	"strconv"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
@@ -69,12 +70,29 @@ func (h *CommentHandler) Create(c *gin.Context) {
     }
 }
 
-// ListByPost handles getting all comments for a post
+// ListByPost handles getting a page of a post's comments
 func (h *CommentHandler) ListByPost(c *gin.Context) {
     postId := c.Param("postId")
-    
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+    depth, ok := bindNumber(c, "depth", "Depth")
+    if !ok {
+        return
+    }
+    replies, ok := bindNumber(c, "replies", "Replies")
+    if !ok {
+        return
+    }
+
     msg := &messages.ListPostComments{
-        PostId: postId,
+        PostId:  postId,
+        UserId:  c.GetString("username"),
+        Sort:    c.Query("sort"),
+        Depth:   depth,
+        Replies: replies,
+        Page:    page,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -85,9 +103,59 @@ func (h *CommentHandler) ListByPost(c *gin.Context) {
 
     if listResponse, ok := response.(*messages.ListPostCommentsResponse); ok {
         if listResponse.Success {
-            c.JSON(http.StatusOK, gin.H{
-                "success":  true,
-                "comments": listResponse.Comments,
+            c.JSON(http.StatusOK, pageResponse(listResponse.Comments, listResponse.PageLinks))
+        } else if listResponse.Error == "Post not found" {
+            c.JSON(http.StatusNotFound, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
+            })
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
+            })
+        }
+    }
+}
+
+// Replies handles expanding a "more replies" stub
+func (h *CommentHandler) Replies(c *gin.Context) {
+    commentId := c.Param("commentId")
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+    depth, ok := bindNumber(c, "depth", "Depth")
+    if !ok {
+        return
+    }
+    replies, ok := bindNumber(c, "replies", "Replies")
+    if !ok {
+        return
+    }
+
+    msg := &messages.ListCommentReplies{
+        CommentId: commentId,
+        UserId:    c.GetString("username"),
+        Sort:      c.Query("sort"),
+        Depth:     depth,
+        Replies:   replies,
+        Page:      page,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if listResponse, ok := response.(*messages.ListCommentRepliesResponse); ok {
+        if listResponse.Success {
+            c.JSON(http.StatusOK, pageResponse(listResponse.Comments, listResponse.PageLinks))
+        } else if listResponse.Error == "Comment not found" {
+            c.JSON(http.StatusNotFound, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
             })
         } else {
             c.JSON(http.StatusBadRequest, gin.H{
@@ -98,6 +166,21 @@ func (h *CommentHandler) ListByPost(c *gin.Context) {
     }
 }
 
+// bindNumber reads a number like ?depth= for comment listings, writing the
+// 400 itself when it isn't one. Missing numbers are 0, for the default.
+func bindNumber(c *gin.Context, param, name string) (int, bool) {
+    value := c.Query(param)
+    if value == "" {
+        return 0, true
+    }
+    parsed, err := strconv.Atoi(value)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number"})
+        return 0, false
+    }
+    return parsed, true
+}
+
 // Vote handles upvoting/downvoting a comment
 func (h *CommentHandler) Vote(c *gin.Context) {
     username, exists := c.Get("username")
@@ -225,4 +308,46 @@ func (h *CommentHandler) Delete(c *gin.Context) {
             })
         }
     }
-} 
\ No newline at end of file
+} 
+// Report handles flagging a comment for the subreddit's moderators
+func (h *CommentHandler) Report(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Reason  string `json:"reason" binding:"required"`
+        Details string `json:"details"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.ReportComment{
+        CommentId:  c.Param("commentId"),
+        ReporterId: username.(string),
+        Reason:     request.Reason,
+        Details:    request.Details,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if reportResponse, ok := response.(*messages.ReportCommentResponse); ok {
+        if reportResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   reportResponse.Error,
+            })
+        }
+    }
+}
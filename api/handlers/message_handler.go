@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"reddit/messages"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+)
+
+type MessageHandler struct {
+    enginePID *actor.PID
+    system    *actor.ActorSystem
+}
+
+func NewMessageHandler(system *actor.ActorSystem, enginePID *actor.PID) *MessageHandler {
+    return &MessageHandler{
+        enginePID: enginePID,
+        system:    system,
+    }
+}
+
+// Send handles sending a direct message or replying to one
+func (h *MessageHandler) Send(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        ToUserId string `json:"toUserId"` // Optional on replies
+        ParentId string `json:"parentId"` // Optional, empty to start a new conversation
+        Content  string `json:"content" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.SendDirectMessage{
+        FromUserID: username.(string),
+        ToUserID:   request.ToUserId,
+        ParentID:   request.ParentId,
+        Content:    request.Content,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if sendResponse, ok := response.(*messages.SendDirectMessageResponse); ok {
+        if sendResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success":   true,
+                "messageId": sendResponse.MessageID,
+            })
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   sendResponse.Error,
+            })
+        }
+    }
+}
+
+// List handles listing the user's messages; ?folder=inbox|sent and ?with=username narrow it down
+func (h *MessageHandler) List(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.GetUserMessages{
+        UserID:     username.(string),
+        Folder:     c.Query("folder"),
+        WithUserID: c.Query("with"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if listResponse, ok := response.(*messages.GetUserMessagesResponse); ok {
+        if listResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success":     true,
+                "messages":    listResponse.Messages,
+                "unreadCount": listResponse.UnreadCount,
+            })
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
+            })
+        }
+    }
+}
+
+// Thread handles getting the whole conversation a message belongs to
+func (h *MessageHandler) Thread(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.GetMessageThread{
+        MessageID: c.Param("id"),
+        UserID:    username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if threadResponse, ok := response.(*messages.GetMessageThreadResponse); ok {
+        if threadResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success":  true,
+                "threadId": threadResponse.ThreadID,
+                "messages": threadResponse.Messages,
+            })
+        } else {
+            c.JSON(http.StatusNotFound, gin.H{
+                "success": false,
+                "error":   threadResponse.Error,
+            })
+        }
+    }
+}
+
+// MarkRead handles marking a received message read or unread
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Read *bool `json:"read" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.MarkMessageRead{
+        MessageID: c.Param("id"),
+        UserID:    username.(string),
+        Read:      *request.Read,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if markResponse, ok := response.(*messages.MarkMessageReadResponse); ok {
+        if markResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   markResponse.Error,
+            })
+        }
+    }
+}
+
+// Delete handles removing a message from the user's own view
+func (h *MessageHandler) Delete(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.DeleteDirectMessage{
+        MessageID: c.Param("id"),
+        UserID:    username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if deleteResponse, ok := response.(*messages.DeleteDirectMessageResponse); ok {
+        if deleteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   deleteResponse.Error,
+            })
+        }
+    }
+}
@@ -0,0 +1,758 @@
+package handlers
+
+import (
+    "net/http"
+    "reddit/messages"
+    "strconv"
+    "time"
+
+    "github.com/asynkron/protoactor-go/actor"
+    "github.com/gin-gonic/gin"
+)
+
+// ModerationHandler serves the moderator tools of a subreddit
+type ModerationHandler struct {
+    enginePID *actor.PID
+    system    *actor.ActorSystem
+}
+
+func NewModerationHandler(system *actor.ActorSystem, enginePID *actor.PID) *ModerationHandler {
+    return &ModerationHandler{
+        enginePID: enginePID,
+        system:    system,
+    }
+}
+
+// ListModerators handles listing a subreddit's moderators and pending invitations
+func (h *ModerationHandler) ListModerators(c *gin.Context) {
+    msg := &messages.GetModerators{
+        SubredditName: c.Param("name"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if listResponse, ok := response.(*messages.GetModeratorsResponse); ok {
+        if listResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success":    true,
+                "moderators": listResponse.Moderators,
+            })
+        } else {
+            c.JSON(http.StatusNotFound, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
+            })
+        }
+    }
+}
+
+// InviteModerator handles inviting a user to moderate
+func (h *ModerationHandler) InviteModerator(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Username    string   `json:"username" binding:"required"`
+        Permissions []string `json:"permissions" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.InviteModerator{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      request.Username,
+        Permissions:   request.Permissions,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if inviteResponse, ok := response.(*messages.InviteModeratorResponse); ok {
+        if inviteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   inviteResponse.Error,
+            })
+        }
+    }
+}
+
+// AcceptInvite handles the invited user accepting
+func (h *ModerationHandler) AcceptInvite(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.AcceptModeratorInvite{
+        SubredditName: c.Param("name"),
+        Username:      username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if acceptResponse, ok := response.(*messages.AcceptModeratorInviteResponse); ok {
+        if acceptResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   acceptResponse.Error,
+            })
+        }
+    }
+}
+
+// SetPermissions handles changing a moderator's permissions
+func (h *ModerationHandler) SetPermissions(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Permissions []string `json:"permissions" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.SetModeratorPermissions{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      c.Param("username"),
+        Permissions:   request.Permissions,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if setResponse, ok := response.(*messages.SetModeratorPermissionsResponse); ok {
+        if setResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   setResponse.Error,
+            })
+        }
+    }
+}
+
+// RemoveModerator handles removing a moderator or withdrawing an invitation
+func (h *ModerationHandler) RemoveModerator(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.RemoveModerator{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      c.Param("username"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if removeResponse, ok := response.(*messages.RemoveModeratorResponse); ok {
+        if removeResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   removeResponse.Error,
+            })
+        }
+    }
+}
+
+// Ban handles banning a user from the subreddit, for a number of days or for good
+func (h *ModerationHandler) Ban(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Username string `json:"username" binding:"required"`
+        Reason   string `json:"reason"`
+        Days     int    `json:"days"` // 0 for permanent
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.BanUser{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      request.Username,
+        Reason:        request.Reason,
+        Days:          request.Days,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if banResponse, ok := response.(*messages.BanUserResponse); ok {
+        if banResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   banResponse.Error,
+            })
+        }
+    }
+}
+
+// Unban handles lifting a ban early
+func (h *ModerationHandler) Unban(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.UnbanUser{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      c.Param("username"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if unbanResponse, ok := response.(*messages.UnbanUserResponse); ok {
+        if unbanResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   unbanResponse.Error,
+            })
+        }
+    }
+}
+
+// Mute handles muting a user in the subreddit
+func (h *ModerationHandler) Mute(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Username string `json:"username" binding:"required"`
+        Reason   string `json:"reason"`
+        Days     int    `json:"days"` // 0 until unmuted
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.MuteUser{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      request.Username,
+        Reason:        request.Reason,
+        Days:          request.Days,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if muteResponse, ok := response.(*messages.MuteUserResponse); ok {
+        if muteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   muteResponse.Error,
+            })
+        }
+    }
+}
+
+// Unmute handles lifting a mute early
+func (h *ModerationHandler) Unmute(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.UnmuteUser{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      c.Param("username"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if unmuteResponse, ok := response.(*messages.UnmuteUserResponse); ok {
+        if unmuteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   unmuteResponse.Error,
+            })
+        }
+    }
+}
+
+// Approve handles adding an approved submitter
+func (h *ModerationHandler) Approve(c *gin.Context) {
+    var request struct {
+        Username string `json:"username" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    h.setApprovedSubmitter(c, request.Username, true)
+}
+
+// Unapprove handles removing an approved submitter
+func (h *ModerationHandler) Unapprove(c *gin.Context) {
+    h.setApprovedSubmitter(c, c.Param("username"), false)
+}
+
+func (h *ModerationHandler) setApprovedSubmitter(c *gin.Context, target string, approved bool) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.SetApprovedSubmitter{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      target,
+        Approved:      approved,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if setResponse, ok := response.(*messages.SetApprovedSubmitterResponse); ok {
+        if setResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   setResponse.Error,
+            })
+        }
+    }
+}
+
+// ListUsers returns a handler listing the banned, muted or approved users
+func (h *ModerationHandler) ListUsers(list string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        username, exists := c.Get("username")
+        if !exists {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+            return
+        }
+
+        msg := &messages.GetSubredditUsers{
+            SubredditName: c.Param("name"),
+            ModeratorId:   username.(string),
+            List:          list,
+        }
+
+        response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+            return
+        }
+
+        if listResponse, ok := response.(*messages.GetSubredditUsersResponse); ok {
+            if listResponse.Success {
+                c.JSON(http.StatusOK, gin.H{
+                    "success": true,
+                    "users":   listResponse.Users,
+                })
+            } else {
+                c.JSON(http.StatusForbidden, gin.H{
+                    "success": false,
+                    "error":   listResponse.Error,
+                })
+            }
+        }
+    }
+}
+
+// ListJoinRequests handles listing the users waiting to join a private subreddit
+func (h *ModerationHandler) ListJoinRequests(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.GetJoinRequests{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if listResponse, ok := response.(*messages.GetJoinRequestsResponse); ok {
+        if listResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success":  true,
+                "requests": listResponse.Requests,
+            })
+        } else {
+            c.JSON(http.StatusForbidden, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
+            })
+        }
+    }
+}
+
+// AcceptJoinRequest handles letting a user into a private subreddit
+func (h *ModerationHandler) AcceptJoinRequest(c *gin.Context) {
+    h.respondJoinRequest(c, true)
+}
+
+// DenyJoinRequest handles turning a user away from a private subreddit
+func (h *ModerationHandler) DenyJoinRequest(c *gin.Context) {
+    h.respondJoinRequest(c, false)
+}
+
+func (h *ModerationHandler) respondJoinRequest(c *gin.Context, accept bool) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.RespondJoinRequest{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Username:      c.Param("username"),
+        Accept:        accept,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if respondResponse, ok := response.(*messages.RespondJoinRequestResponse); ok {
+        if respondResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   respondResponse.Error,
+            })
+        }
+    }
+}
+
+// ModQueue handles listing the reported, removed or unmoderated items of a
+// subreddit, picked by ?queue=
+func (h *ModerationHandler) ModQueue(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+
+    msg := &messages.GetModQueue{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Queue:         c.Query("queue"),
+        Page:          page,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if queueResponse, ok := response.(*messages.GetModQueueResponse); ok {
+        if queueResponse.Success {
+            c.JSON(http.StatusOK, pageResponse(queueResponse.Items, queueResponse.PageLinks))
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   queueResponse.Error,
+            })
+        }
+    }
+}
+
+// ModeratePost returns a handler approving, removing or ignoring the reports
+// on a post
+func (h *ModerationHandler) ModeratePost(action string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        username, exists := c.Get("username")
+        if !exists {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+            return
+        }
+
+        msg := &messages.ModeratePost{
+            PostId:      c.Param("postId"),
+            ModeratorId: username.(string),
+            Action:      action,
+        }
+
+        response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+            return
+        }
+
+        if moderateResponse, ok := response.(*messages.ModeratePostResponse); ok {
+            if moderateResponse.Success {
+                c.JSON(http.StatusOK, gin.H{"success": true})
+            } else {
+                c.JSON(http.StatusBadRequest, gin.H{
+                    "success": false,
+                    "error":   moderateResponse.Error,
+                })
+            }
+        }
+    }
+}
+
+// ModerateComment is ModeratePost for comments
+func (h *ModerationHandler) ModerateComment(action string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        username, exists := c.Get("username")
+        if !exists {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+            return
+        }
+
+        msg := &messages.ModerateComment{
+            CommentId:   c.Param("commentId"),
+            ModeratorId: username.(string),
+            Action:      action,
+        }
+
+        response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+            return
+        }
+
+        if moderateResponse, ok := response.(*messages.ModerateCommentResponse); ok {
+            if moderateResponse.Success {
+                c.JSON(http.StatusOK, gin.H{"success": true})
+            } else {
+                c.JSON(http.StatusBadRequest, gin.H{
+                    "success": false,
+                    "error":   moderateResponse.Error,
+                })
+            }
+        }
+    }
+}
+
+// ModLog handles reading a subreddit's mod log, filtered by ?moderator=,
+// ?action= and a ?since= / ?until= range in Unix seconds
+func (h *ModerationHandler) ModLog(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+    since, ok := bindUnixTime(c, "since")
+    if !ok {
+        return
+    }
+    until, ok := bindUnixTime(c, "until")
+    if !ok {
+        return
+    }
+
+    msg := &messages.GetModLog{
+        SubredditName: c.Param("name"),
+        UserId:        username.(string),
+        Moderator:     c.Query("moderator"),
+        Action:        c.Query("action"),
+        Since:         since,
+        Until:         until,
+        Page:          page,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if logResponse, ok := response.(*messages.GetModLogResponse); ok {
+        if logResponse.Success {
+            c.JSON(http.StatusOK, pageResponse(logResponse.Entries, logResponse.PageLinks))
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   logResponse.Error,
+            })
+        }
+    }
+}
+
+// GetAutoMod lists the subreddit's AutoModerator rules
+func (h *ModerationHandler) GetAutoMod(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.GetAutoModRules{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if rulesResponse, ok := response.(*messages.GetAutoModRulesResponse); ok {
+        if rulesResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"rules": rulesResponse.Rules})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   rulesResponse.Error,
+            })
+        }
+    }
+}
+
+// SetAutoMod replaces the subreddit's AutoModerator rules with the request
+// body, a YAML or JSON list of rules
+func (h *ModerationHandler) SetAutoMod(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    body, err := c.GetRawData()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.SetAutoModRules{
+        SubredditName: c.Param("name"),
+        ModeratorId:   username.(string),
+        Rules:         string(body),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if setResponse, ok := response.(*messages.SetAutoModRulesResponse); ok {
+        if setResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true, "count": setResponse.Count})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   setResponse.Error,
+            })
+        }
+    }
+}
+
+// bindUnixTime reads a Unix seconds query parameter, writing the 400 itself
+// when it isn't a number
+func bindUnixTime(c *gin.Context, name string) (int64, bool) {
+    value := c.Query(name)
+    if value == "" {
+        return 0, true
+    }
+    parsed, err := strconv.ParseInt(value, 10, 64)
+    if err != nil || parsed < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be Unix seconds"})
+        return 0, false
+    }
+    return parsed, true
+}
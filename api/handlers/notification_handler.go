@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"reddit/messages"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+    enginePID *actor.PID
+    system    *actor.ActorSystem
+}
+
+func NewNotificationHandler(system *actor.ActorSystem, enginePID *actor.PID) *NotificationHandler {
+    return &NotificationHandler{
+        enginePID: enginePID,
+        system:    system,
+    }
+}
+
+// List pages through the user's notifications, newest first; ?unread=true
+// leaves out those already read
+func (h *NotificationHandler) List(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+
+    msg := &messages.GetNotifications{
+        Username:   username.(string),
+        UnreadOnly: c.Query("unread") == "true",
+        Page:       page,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if listResponse, ok := response.(*messages.GetNotificationsResponse); ok {
+        if listResponse.Success {
+            body := pageResponse(listResponse.Notifications, listResponse.PageLinks)
+            body["unreadCount"] = listResponse.UnreadCount
+            c.JSON(http.StatusOK, body)
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   listResponse.Error,
+            })
+        }
+    }
+}
+
+// MarkRead marks the notifications named, or all of them, read; "read":
+// false marks them unread again
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Ids  []string `json:"ids"`
+        All  bool     `json:"all"`
+        Read *bool    `json:"read"` // Defaults to true
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.MarkNotificationsRead{
+        Username:        username.(string),
+        NotificationIds: request.Ids,
+        All:             request.All,
+        Read:            request.Read == nil || *request.Read,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if markResponse, ok := response.(*messages.MarkNotificationsReadResponse); ok {
+        if markResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success": true,
+                "marked":  markResponse.Marked,
+            })
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   markResponse.Error,
+            })
+        }
+    }
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+    "net/http"
+    "reddit/messages"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+)
+
+// bindPage reads ?after=, ?before= and ?limit= for list endpoints. On a bad
+// limit it writes the 400 itself and returns false.
+func bindPage(c *gin.Context) (messages.Page, bool) {
+    page := messages.Page{
+        After:  c.Query("after"),
+        Before: c.Query("before"),
+    }
+    if limit := c.Query("limit"); limit != "" {
+        parsed, err := strconv.Atoi(limit)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be a number"})
+            return page, false
+        }
+        page.Limit = parsed
+    }
+    return page, true
+}
+
+// pageResponse is the envelope every list endpoint answers with
+func pageResponse(items interface{}, links messages.PageLinks) gin.H {
+    return gin.H{
+        "success": true,
+        "items":   items,
+        "next":    links.Next,
+        "prev":    links.Prev,
+    }
+}
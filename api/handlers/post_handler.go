@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"reddit/messages"
+	"strconv"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
@@ -33,6 +34,7 @@ func (h *PostHandler) Create(c *gin.Context) {
         Title       string `json:"title" binding:"required"`
         Content     string `json:"content" binding:"required"`
         SubredditName string `json:"subredditName" binding:"required"`
+        Flair       string `json:"flair"`
     }
 
     if err := c.ShouldBindJSON(&request); err != nil {
@@ -45,6 +47,7 @@ func (h *PostHandler) Create(c *gin.Context) {
         Content:     request.Content,
         AuthorId:    username.(string),
         SubredditName: request.SubredditName,
+        Flair:         request.Flair,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -74,6 +77,7 @@ func (h *PostHandler) Get(c *gin.Context) {
     
     msg := &messages.GetPost{
         PostId: postId,
+        UserId: c.GetString("username"),
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -100,9 +104,17 @@ func (h *PostHandler) Get(c *gin.Context) {
 // ListBySubreddit handles listing posts in a subreddit
 func (h *PostHandler) ListBySubreddit(c *gin.Context) {
     subredditName := c.Param("name")
-    
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+
     msg := &messages.ListSubredditPosts{
         SubredditName: subredditName,
+        Sort:          c.Query("sort"),
+        Period:        c.Query("t"),
+        UserId:        c.GetString("username"),
+        Page:          page,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -113,10 +125,7 @@ func (h *PostHandler) ListBySubreddit(c *gin.Context) {
 
     if listResponse, ok := response.(*messages.ListSubredditPostsResponse); ok {
         if listResponse.Success {
-            c.JSON(http.StatusOK, gin.H{
-                "success": true,
-                "posts":   listResponse.Posts,
-            })
+            c.JSON(http.StatusOK, pageResponse(listResponse.Posts, listResponse.PageLinks))
         } else {
             c.JSON(http.StatusBadRequest, gin.H{
                 "success": false,
@@ -204,16 +213,83 @@ func (h *PostHandler) Delete(c *gin.Context) {
     }
 }
 
-// Search handles searching for posts
+// Search handles searching posts, comments, subreddits or users, picked by
+// ?type= (posts by default)
 func (h *PostHandler) Search(c *gin.Context) {
     query := c.Query("q")  // Get search query from URL parameter
     if query == "" {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
         return
     }
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+
+    var msg interface{}
+    switch c.DefaultQuery("type", "post") {
+    case "post":
+        msg = &messages.SearchPosts{Query: query, UserId: c.GetString("username"), Page: page}
+    case "comment":
+        msg = &messages.SearchComments{Query: query, UserId: c.GetString("username"), Page: page}
+    case "subreddit":
+        msg = &messages.SearchSubreddits{Query: query, Page: page}
+    case "user":
+        msg = &messages.SearchUsers{Query: query, Page: page}
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be post, comment, subreddit or user"})
+        return
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    var items interface{}
+    var links messages.PageLinks
+    var searchError string
+    switch searchResponse := response.(type) {
+    case *messages.SearchPostsResponse:
+        items, links, searchError = searchResponse.Posts, searchResponse.PageLinks, searchResponse.Error
+    case *messages.SearchCommentsResponse:
+        items, links, searchError = searchResponse.Comments, searchResponse.PageLinks, searchResponse.Error
+    case *messages.SearchSubredditsResponse:
+        items, links, searchError = searchResponse.Subreddits, searchResponse.PageLinks, searchResponse.Error
+    case *messages.SearchUsersResponse:
+        items, links, searchError = searchResponse.Users, searchResponse.PageLinks, searchResponse.Error
+    default:
+        return
+    }
+
+    if searchError == "" {
+        c.JSON(http.StatusOK, pageResponse(items, links))
+    } else {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "error":   searchError,
+        })
+    }
+}
 
-    msg := &messages.SearchPosts{
-        Query: query,
+// Autocomplete handles typeahead for subreddit and user names
+func (h *PostHandler) Autocomplete(c *gin.Context) {
+    msg := &messages.Autocomplete{
+        Type:   c.DefaultQuery("type", "subreddit"),
+        Prefix: c.Query("q"),
+    }
+    if msg.Type != "subreddit" && msg.Type != "user" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be subreddit or user"})
+        return
+    }
+    if limit := c.Query("limit"); limit != "" {
+        parsed, err := strconv.Atoi(limit)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be a number"})
+            return
+        }
+        msg.Limit = parsed
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -222,17 +298,106 @@ func (h *PostHandler) Search(c *gin.Context) {
         return
     }
 
-    if searchResponse, ok := response.(*messages.SearchPostsResponse); ok {
-        if searchResponse.Success {
+    if completeResponse, ok := response.(*messages.AutocompleteResponse); ok {
+        if completeResponse.Success {
             c.JSON(http.StatusOK, gin.H{
                 "success": true,
-                "posts":   searchResponse.Posts,
+                "names":   completeResponse.Names,
             })
         } else {
             c.JSON(http.StatusBadRequest, gin.H{
                 "success": false,
-                "error":   searchResponse.Error,
+                "error":   completeResponse.Error,
             })
         }
     }
-} 
\ No newline at end of file
+}
+
+// Vote handles upvoting/downvoting a post
+func (h *PostHandler) Vote(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    postId := c.Param("postId")
+
+    var request struct {
+        IsUpvote bool `json:"isUpvote"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.Vote{
+        UserID:   username.(string),
+        TargetID: postId,
+        IsUpvote: request.IsUpvote,
+        Type:     "post",
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if voteResponse, ok := response.(*messages.VoteResponse); ok {
+        if voteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success": true,
+            })
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   voteResponse.Error,
+            })
+        }
+    }
+}
+
+// Report handles flagging a post for the subreddit's moderators
+func (h *PostHandler) Report(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Reason  string `json:"reason" binding:"required"`
+        Details string `json:"details"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.ReportPost{
+        PostId:     c.Param("postId"),
+        ReporterId: username.(string),
+        Reason:     request.Reason,
+        Details:    request.Details,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if reportResponse, ok := response.(*messages.ReportPostResponse); ok {
+        if reportResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   reportResponse.Error,
+            })
+        }
+    }
+}
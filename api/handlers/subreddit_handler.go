@@ -34,6 +34,7 @@ func (h *SubredditHandler) Create(c *gin.Context) {
     var request struct {
         Name        string `json:"name" binding:"required"`
         Description string `json:"description" binding:"required"`
+        Type        string `json:"type"`
     }
 
     if err := c.ShouldBindJSON(&request); err != nil {
@@ -45,6 +46,7 @@ func (h *SubredditHandler) Create(c *gin.Context) {
         Name:        request.Name,
         Description: request.Description,
         CreatorId:   username.(string),  // Use the username from token
+        Type:        request.Type,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -91,7 +93,12 @@ func (h *SubredditHandler) Join(c *gin.Context) {
     }
 
     if joinResponse, ok := response.(*messages.JoinSubredditResponse); ok {
-        if joinResponse.Success {
+        if joinResponse.Success && joinResponse.Pending {
+            c.JSON(http.StatusAccepted, gin.H{
+                "success": true,
+                "pending": true,
+            })
+        } else if joinResponse.Success {
             c.JSON(http.StatusOK, gin.H{
                 "success": true,
             })
@@ -207,8 +214,15 @@ func (h *SubredditHandler) Edit(c *gin.Context) {
 
     name := c.Param("name")
     
+    // Fields left out of the request stay as they are
     var request struct {
-        Description string `json:"description" binding:"required"`
+        Description  string                   `json:"description"`
+        Type         string                   `json:"type"`
+        Sidebar      *string                  `json:"sidebar"`
+        BannerText   *string                  `json:"bannerText"`
+        Rules        []messages.SubredditRule `json:"rules"`
+        NSFW         *bool                    `json:"nsfw"`
+        RequireFlair *bool                    `json:"requireFlair"`
     }
 
     if err := c.ShouldBindJSON(&request); err != nil {
@@ -217,9 +231,15 @@ func (h *SubredditHandler) Edit(c *gin.Context) {
     }
 
     msg := &messages.EditSubreddit{
-        Name:        name,
-        Description: request.Description,
-        AuthorId:    username.(string),
+        Name:         name,
+        Description:  request.Description,
+        Type:         request.Type,
+        Sidebar:      request.Sidebar,
+        BannerText:   request.BannerText,
+        Rules:        request.Rules,
+        NSFW:         request.NSFW,
+        RequireFlair: request.RequireFlair,
+        AuthorId:     username.(string),
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -238,4 +258,61 @@ func (h *SubredditHandler) Edit(c *gin.Context) {
             })
         }
     }
-} 
\ No newline at end of file
+} 
+
+// Get describes a subreddit: its description, sidebar, rules and settings
+func (h *SubredditHandler) Get(c *gin.Context) {
+    msg := &messages.GetSubreddit{
+        Name:   c.Param("name"),
+        UserId: c.GetString("username"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if getResponse, ok := response.(*messages.GetSubredditResponse); ok {
+        if getResponse.Success {
+            c.JSON(http.StatusOK, getResponse.Subreddit)
+        } else {
+            c.JSON(http.StatusNotFound, gin.H{
+                "success": false,
+                "error":   getResponse.Error,
+            })
+        }
+    }
+}
+
+// Delete archives a subreddit with its posts and comments
+func (h *SubredditHandler) Delete(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.DeleteSubreddit{
+        Name:     c.Param("name"),
+        AuthorId: username.(string),
+    }
+
+    // Every post is archived in turn, so allow longer than a single request
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 60*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout; delete the subreddit again to finish"})
+        return
+    }
+
+    if deleteResponse, ok := response.(*messages.DeleteSubredditResponse); ok {
+        if deleteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   deleteResponse.Error,
+            })
+        }
+    }
+}
@@ -26,6 +26,7 @@ func (h *UserHandler) Register(c *gin.Context) {
     var request struct {
         Username string `json:"username" binding:"required"`
         Password string `json:"password" binding:"required"`
+        Email    string `json:"email"`
     }
 
     if err := c.ShouldBindJSON(&request); err != nil {
@@ -36,6 +37,7 @@ func (h *UserHandler) Register(c *gin.Context) {
     msg := &messages.RegisterUser{
         Username: request.Username,
         Password: request.Password,
+        Email:    request.Email,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -85,8 +87,11 @@ func (h *UserHandler) Login(c *gin.Context) {
     if loginResponse, ok := response.(*messages.LoginUserResponse); ok {
         if loginResponse.Success {
             c.JSON(http.StatusOK, gin.H{
-                "success": true,
-                "token":   loginResponse.Token,
+                "success":      true,
+                "token":        loginResponse.Token,
+                "refreshToken": loginResponse.RefreshToken,
+                "expiresAt":    loginResponse.ExpiresAt,
+                "reactivated":  loginResponse.Reactivated,
             })
         } else {
             c.JSON(http.StatusUnauthorized, gin.H{
@@ -97,6 +102,409 @@ func (h *UserHandler) Login(c *gin.Context) {
     }
 }
 
+// Refresh handles trading a refresh token for a new token pair
+func (h *UserHandler) Refresh(c *gin.Context) {
+    var request struct {
+        RefreshToken string `json:"refreshToken" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.RefreshToken{
+        RefreshToken: request.RefreshToken,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if refreshResponse, ok := response.(*messages.RefreshTokenResponse); ok {
+        if refreshResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success":      true,
+                "token":        refreshResponse.Token,
+                "refreshToken": refreshResponse.RefreshToken,
+                "expiresAt":    refreshResponse.ExpiresAt,
+            })
+        } else {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "success": false,
+                "error":   refreshResponse.Error,
+            })
+        }
+    }
+}
+
+// Logout handles revoking the current session's tokens
+func (h *UserHandler) Logout(c *gin.Context) {
+    h.logout(c, false)
+}
+
+// LogoutAll handles revoking the tokens of every session of the user
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+    h.logout(c, true)
+}
+
+func (h *UserHandler) logout(c *gin.Context, allSessions bool) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.Logout{
+        Username:    username.(string),
+        SessionId:   c.GetString("sessionId"),
+        AllSessions: allSessions,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if logoutResponse, ok := response.(*messages.LogoutResponse); ok {
+        if logoutResponse.Success {
+            c.JSON(http.StatusOK, gin.H{
+                "success": true,
+                "revoked": logoutResponse.Revoked,
+            })
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   logoutResponse.Error,
+            })
+        }
+    }
+}
+
+// ChangePassword handles replacing the authenticated user's password
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        OldPassword string `json:"oldPassword" binding:"required"`
+        NewPassword string `json:"newPassword" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.ChangePassword{
+        Username:    username.(string),
+        OldPassword: request.OldPassword,
+        NewPassword: request.NewPassword,
+        SessionId:   c.GetString("sessionId"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if changeResponse, ok := response.(*messages.ChangePasswordResponse); ok {
+        if changeResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   changeResponse.Error,
+            })
+        }
+    }
+}
+
+// VerifyEmail confirms an email with the token mailed to it
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+    var request struct {
+        Token string `json:"token" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.VerifyEmail{
+        Token: request.Token,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if verifyResponse, ok := response.(*messages.VerifyEmailResponse); ok {
+        if verifyResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   verifyResponse.Error,
+            })
+        }
+    }
+}
+
+// ResendVerification mails the logged in user a new verification token
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.ResendVerification{
+        Username: username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if resendResponse, ok := response.(*messages.ResendVerificationResponse); ok {
+        if resendResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   resendResponse.Error,
+            })
+        }
+    }
+}
+
+// ForgotPassword mails a reset token to the user's verified email, if any
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+    var request struct {
+        Username string `json:"username" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.RequestPasswordReset{
+        Username: request.Username,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if forgotResponse, ok := response.(*messages.RequestPasswordResetResponse); ok {
+        if forgotResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{
+                "success": false,
+                "error":   forgotResponse.Error,
+            })
+        }
+    }
+}
+
+// ResetPassword sets a new password with a mailed reset token
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+    var request struct {
+        Token       string `json:"token" binding:"required"`
+        NewPassword string `json:"newPassword" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.ResetPassword{
+        Token:       request.Token,
+        NewPassword: request.NewPassword,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if resetResponse, ok := response.(*messages.ResetPasswordResponse); ok {
+        if resetResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   resetResponse.Error,
+            })
+        }
+    }
+}
+
+// Deactivate hides the account until the user logs in again
+func (h *UserHandler) Deactivate(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.DeactivateAccount{
+        Username: username.(string),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if deactivateResponse, ok := response.(*messages.DeactivateAccountResponse); ok {
+        if deactivateResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   deactivateResponse.Error,
+            })
+        }
+    }
+}
+
+// DeleteAccount deletes the account for good; posts and comments stay,
+// credited to [deleted]
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Password string `json:"password" binding:"required"`
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.DeleteAccount{
+        Username: username.(string),
+        Password: request.Password,
+    }
+
+    // Every post and comment is anonymized first, which takes a while
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 60*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if deleteResponse, ok := response.(*messages.DeleteAccountResponse); ok {
+        if deleteResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   deleteResponse.Error,
+            })
+        }
+    }
+}
+
+// Suspend keeps a user from logging in, for some days or for good; admins
+// only
+func (h *UserHandler) Suspend(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    var request struct {
+        Reason string `json:"reason"`
+        Days   int    `json:"days"` // 0 suspends for good
+    }
+
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    msg := &messages.SuspendUser{
+        AdminId:  username.(string),
+        Username: c.Param("userId"),
+        Reason:   request.Reason,
+        Days:     request.Days,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if suspendResponse, ok := response.(*messages.SuspendUserResponse); ok {
+        if suspendResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   suspendResponse.Error,
+            })
+        }
+    }
+}
+
+// Unsuspend lifts a user's suspension; admins only
+func (h *UserHandler) Unsuspend(c *gin.Context) {
+    username, exists := c.Get("username")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+        return
+    }
+
+    msg := &messages.UnsuspendUser{
+        AdminId:  username.(string),
+        Username: c.Param("userId"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if unsuspendResponse, ok := response.(*messages.UnsuspendUserResponse); ok {
+        if unsuspendResponse.Success {
+            c.JSON(http.StatusOK, gin.H{"success": true})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   unsuspendResponse.Error,
+            })
+        }
+    }
+}
+
 // GetKarma handles karma retrieval
 func (h *UserHandler) GetKarma(c *gin.Context) {
     userId := c.Param("userId")
@@ -114,8 +522,10 @@ func (h *UserHandler) GetKarma(c *gin.Context) {
     if karmaResponse, ok := response.(*messages.GetKarmaResponse); ok {
         if karmaResponse.Success {
             c.JSON(http.StatusOK, gin.H{
-                "success": true,
-                "karma":   karmaResponse.Karma,
+                "success":      true,
+                "karma":        karmaResponse.Karma,
+                "postKarma":    karmaResponse.PostKarma,
+                "commentKarma": karmaResponse.CommentKarma,
             })
         } else {
             c.JSON(http.StatusNotFound, gin.H{
@@ -134,10 +544,13 @@ func (h *UserHandler) EditProfile(c *gin.Context) {
         return
     }
     
+    // Fields left out of the request stay as they are
     var request struct {
-        Email       string `json:"email,omitempty"`
-        DisplayName string `json:"displayName,omitempty"`
-        Bio         string `json:"bio,omitempty"`
+        Email         *string         `json:"email,omitempty"`
+        DisplayName   *string         `json:"displayName,omitempty"`
+        Bio           *string         `json:"bio,omitempty"`
+        AvatarURL     *string         `json:"avatarUrl,omitempty"`
+        Notifications map[string]bool `json:"notifications,omitempty"` // Type -> on
     }
 
     if err := c.ShouldBindJSON(&request); err != nil {
@@ -146,10 +559,12 @@ func (h *UserHandler) EditProfile(c *gin.Context) {
     }
 
     msg := &messages.EditUserProfile{
-        UserId:      username.(string),
-        Email:       request.Email,
-        DisplayName: request.DisplayName,
-        Bio:        request.Bio,
+        UserId:        username.(string),
+        Email:         request.Email,
+        DisplayName:   request.DisplayName,
+        Bio:           request.Bio,
+        AvatarURL:     request.AvatarURL,
+        Notifications: request.Notifications,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -170,6 +585,96 @@ func (h *UserHandler) EditProfile(c *gin.Context) {
     }
 }
 
+// GetProfile returns a user's profile with their karma
+func (h *UserHandler) GetProfile(c *gin.Context) {
+    msg := &messages.GetUserProfile{
+        Username: c.Param("userId"),
+        ViewerId: c.GetString("username"),
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if profileResponse, ok := response.(*messages.GetUserProfileResponse); ok {
+        if profileResponse.Success {
+            c.JSON(http.StatusOK, profileResponse.Profile)
+        } else {
+            c.JSON(http.StatusNotFound, gin.H{
+                "success": false,
+                "error":   profileResponse.Error,
+            })
+        }
+    }
+}
+
+// Posts lists what a user posted
+func (h *UserHandler) Posts(c *gin.Context) {
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+
+    msg := &messages.GetUserPosts{
+        Username: c.Param("userId"),
+        ViewerId: c.GetString("username"),
+        Sort:     c.Query("sort"),
+        Period:   c.Query("t"),
+        Page:     page,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if postsResponse, ok := response.(*messages.GetUserPostsResponse); ok {
+        if postsResponse.Success {
+            c.JSON(http.StatusOK, pageResponse(postsResponse.Posts, postsResponse.PageLinks))
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   postsResponse.Error,
+            })
+        }
+    }
+}
+
+// Comments lists what a user commented
+func (h *UserHandler) Comments(c *gin.Context) {
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
+
+    msg := &messages.GetUserComments{
+        Username: c.Param("userId"),
+        ViewerId: c.GetString("username"),
+        Sort:     c.Query("sort"),
+        Page:     page,
+    }
+
+    response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Request timeout"})
+        return
+    }
+
+    if commentsResponse, ok := response.(*messages.GetUserCommentsResponse); ok {
+        if commentsResponse.Success {
+            c.JSON(http.StatusOK, pageResponse(commentsResponse.Comments, commentsResponse.PageLinks))
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "success": false,
+                "error":   commentsResponse.Error,
+            })
+        }
+    }
+}
+
 // GetFeed handles user feed retrieval
 func (h *UserHandler) GetFeed(c *gin.Context) {
     username, exists := c.Get("username")
@@ -177,9 +682,16 @@ func (h *UserHandler) GetFeed(c *gin.Context) {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
         return
     }
+    page, ok := bindPage(c)
+    if !ok {
+        return
+    }
 
     msg := &messages.GetFeed{
         UserId: username.(string),
+        Sort:   c.Query("sort"),
+        Period: c.Query("t"),
+        Page:   page,
     }
 
     response, err := h.system.Root.RequestFuture(h.enginePID, msg, 5*time.Second).Result()
@@ -190,10 +702,7 @@ func (h *UserHandler) GetFeed(c *gin.Context) {
 
     if feedResponse, ok := response.(*messages.FeedResponse); ok {
         if feedResponse.Success {
-            c.JSON(http.StatusOK, gin.H{
-                "success": true,
-                "feed":    feedResponse.Feed,
-            })
+            c.JSON(http.StatusOK, pageResponse(feedResponse.Posts, feedResponse.PageLinks))
         } else {
             c.JSON(http.StatusBadRequest, gin.H{
                 "success": false,
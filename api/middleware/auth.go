@@ -43,13 +43,19 @@ func NewAuthMiddleware(system *actor.ActorSystem, enginePID *actor.PID) gin.Hand
 
 		if validateResponse, ok := response.(*messages.ValidateTokenResponse); ok {
 			if !validateResponse.Success {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": validateResponse.Error})
+				// The code lets clients tell "refresh and retry" (expired)
+				// apart from "log in again" (revoked or invalid)
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": validateResponse.Error,
+					"code":  validateResponse.ErrorCode,
+				})
 				c.Abort()
 				return
 			}
 			
-			// Store validated username in context
+			// Store validated username and session in context
 			c.Set("username", validateResponse.Username)
+			c.Set("sessionId", validateResponse.SessionId)
 			c.Next()
 		}
 	}
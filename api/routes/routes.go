@@ -3,6 +3,7 @@ package routes
 import (
 	"reddit/api/handlers"
 	"reddit/api/middleware"
+	"reddit/messages"
 
 	"github.com/asynkron/protoactor-go/actor"
 	"github.com/gin-gonic/gin"
@@ -12,18 +13,30 @@ func SetupRouter(system *actor.ActorSystem, enginePID *actor.PID,
                 userHandler *handlers.UserHandler, 
                 subredditHandler *handlers.SubredditHandler,
                 postHandler *handlers.PostHandler,
-                commentHandler *handlers.CommentHandler) *gin.Engine {
+                commentHandler *handlers.CommentHandler,
+                messageHandler *handlers.MessageHandler,
+                moderationHandler *handlers.ModerationHandler,
+                notificationHandler *handlers.NotificationHandler) *gin.Engine {
     router := gin.Default()
     
     // Public routes
     router.POST("/register", userHandler.Register)
     router.POST("/login", userHandler.Login)
+    router.POST("/refresh", userHandler.Refresh)
+    router.POST("/verify-email", userHandler.VerifyEmail)
+    router.POST("/forgot-password", userHandler.ForgotPassword)
+    router.POST("/reset-password", userHandler.ResetPassword)
 
     // Protected routes
     authorized := router.Group("/")
     authorized.Use(middleware.NewAuthMiddleware(system, enginePID))
     {
+        authorized.POST("/logout", userHandler.Logout)
+        authorized.POST("/logout/all", userHandler.LogoutAll)
+        authorized.GET("/user/:userId", userHandler.GetProfile)
         authorized.GET("/user/:userId/karma", userHandler.GetKarma)
+        authorized.GET("/user/:userId/posts", userHandler.Posts)
+        authorized.GET("/user/:userId/comments", userHandler.Comments)
         authorized.POST("/subreddit", subredditHandler.Create)
         authorized.POST("/subreddit/:name/join", subredditHandler.Join)
         authorized.POST("/subreddit/:name/leave", subredditHandler.Leave)
@@ -32,17 +45,64 @@ func SetupRouter(system *actor.ActorSystem, enginePID *actor.PID,
         authorized.POST("/post", postHandler.Create)
         authorized.GET("/post/:postId", postHandler.Get)
         authorized.GET("/subreddit/:name/posts", postHandler.ListBySubreddit)
+        authorized.POST("/post/:postId/vote", postHandler.Vote)
         authorized.POST("/comment", commentHandler.Create)
         authorized.GET("/post/:postId/comments", commentHandler.ListByPost)
+        authorized.GET("/comment/:commentId/replies", commentHandler.Replies)
         authorized.POST("/comment/:commentId/vote", commentHandler.Vote)
         authorized.PATCH("/comment/:commentId", commentHandler.Edit)
         authorized.PATCH("/post/:postId", postHandler.Edit)
+        authorized.GET("/subreddit/:name", subredditHandler.Get)
         authorized.PATCH("/subreddit/:name", subredditHandler.Edit)
+        authorized.DELETE("/subreddit/:name", subredditHandler.Delete)
         authorized.PATCH("/user/profile", userHandler.EditProfile)
+        authorized.POST("/user/password", userHandler.ChangePassword)
+        authorized.POST("/user/email/resend", userHandler.ResendVerification)
+        authorized.POST("/user/deactivate", userHandler.Deactivate)
+        authorized.DELETE("/user", userHandler.DeleteAccount)
+        authorized.POST("/admin/users/:userId/suspension", userHandler.Suspend)
+        authorized.DELETE("/admin/users/:userId/suspension", userHandler.Unsuspend)
         authorized.DELETE("/comment/:commentId", commentHandler.Delete)
         authorized.DELETE("/post/:postId", postHandler.Delete)
         authorized.GET("/feed", userHandler.GetFeed)
         authorized.GET("/search", postHandler.Search)
+        authorized.GET("/search/autocomplete", postHandler.Autocomplete)
+        authorized.POST("/messages", messageHandler.Send)
+        authorized.GET("/messages", messageHandler.List)
+        authorized.GET("/messages/:id/thread", messageHandler.Thread)
+        authorized.PATCH("/messages/:id", messageHandler.MarkRead)
+        authorized.DELETE("/messages/:id", messageHandler.Delete)
+        authorized.GET("/notifications", notificationHandler.List)
+        authorized.POST("/notifications/read", notificationHandler.MarkRead)
+        authorized.GET("/subreddit/:name/moderators", moderationHandler.ListModerators)
+        authorized.POST("/subreddit/:name/moderators", moderationHandler.InviteModerator)
+        authorized.POST("/subreddit/:name/moderators/accept", moderationHandler.AcceptInvite)
+        authorized.PUT("/subreddit/:name/moderators/:username", moderationHandler.SetPermissions)
+        authorized.DELETE("/subreddit/:name/moderators/:username", moderationHandler.RemoveModerator)
+        authorized.GET("/subreddit/:name/bans", moderationHandler.ListUsers(messages.UserListBanned))
+        authorized.POST("/subreddit/:name/bans", moderationHandler.Ban)
+        authorized.DELETE("/subreddit/:name/bans/:username", moderationHandler.Unban)
+        authorized.GET("/subreddit/:name/mutes", moderationHandler.ListUsers(messages.UserListMuted))
+        authorized.POST("/subreddit/:name/mutes", moderationHandler.Mute)
+        authorized.DELETE("/subreddit/:name/mutes/:username", moderationHandler.Unmute)
+        authorized.GET("/subreddit/:name/approved", moderationHandler.ListUsers(messages.UserListApproved))
+        authorized.POST("/subreddit/:name/approved", moderationHandler.Approve)
+        authorized.DELETE("/subreddit/:name/approved/:username", moderationHandler.Unapprove)
+        authorized.GET("/subreddit/:name/join-requests", moderationHandler.ListJoinRequests)
+        authorized.POST("/subreddit/:name/join-requests/:username/accept", moderationHandler.AcceptJoinRequest)
+        authorized.POST("/subreddit/:name/join-requests/:username/deny", moderationHandler.DenyJoinRequest)
+        authorized.GET("/subreddit/:name/modqueue", moderationHandler.ModQueue)
+        authorized.GET("/subreddit/:name/modlog", moderationHandler.ModLog)
+        authorized.GET("/subreddit/:name/automod", moderationHandler.GetAutoMod)
+        authorized.PUT("/subreddit/:name/automod", moderationHandler.SetAutoMod)
+        authorized.POST("/post/:postId/report", postHandler.Report)
+        authorized.POST("/post/:postId/approve", moderationHandler.ModeratePost(messages.ModActionApprove))
+        authorized.POST("/post/:postId/remove", moderationHandler.ModeratePost(messages.ModActionRemove))
+        authorized.POST("/post/:postId/ignore-reports", moderationHandler.ModeratePost(messages.ModActionIgnoreReports))
+        authorized.POST("/comment/:commentId/report", commentHandler.Report)
+        authorized.POST("/comment/:commentId/approve", moderationHandler.ModerateComment(messages.ModActionApprove))
+        authorized.POST("/comment/:commentId/remove", moderationHandler.ModerateComment(messages.ModActionRemove))
+        authorized.POST("/comment/:commentId/ignore-reports", moderationHandler.ModerateComment(messages.ModActionIgnoreReports))
     }
 
     return router
@@ -0,0 +1,66 @@
+// Package mail sends the emails the server needs, such as verification and
+// password reset links, through a pluggable Mailer. The implementations here
+// write messages out locally instead of talking to a mail server, so every
+// flow can be tried and tested without one.
+package mail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is one plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages. Send must be safe for concurrent use.
+type Mailer interface {
+	Send(message Message) error
+}
+
+// WriterMailer writes every message to a writer, one after another, in a
+// form readable by people and mbox-aware tools alike
+type WriterMailer struct {
+	mu     sync.Mutex
+	writer io.Writer
+	now    func() time.Time
+}
+
+// NewWriterMailer writes messages to writer, such as os.Stdout
+func NewWriterMailer(writer io.Writer) *WriterMailer {
+	return &WriterMailer{writer: writer, now: time.Now}
+}
+
+func (mailer *WriterMailer) Send(message Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	_, err := fmt.Fprintf(mailer.writer, "From reddit-clone %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		mailer.now().UTC().Format(time.ANSIC), message.To, message.Subject, message.Body)
+	return err
+}
+
+// FileMailer appends messages to a file, which keeps them across restarts
+type FileMailer struct {
+	*WriterMailer
+	file *os.File
+}
+
+// OpenFileMailer appends messages to the file at path, creating it if needed
+func OpenFileMailer(path string) (*FileMailer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileMailer{WriterMailer: NewWriterMailer(file), file: file}, nil
+}
+
+func (mailer *FileMailer) Close() error {
+	return mailer.file.Close()
+}
@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+
+	for _, subject := range []string{"First", "Second"} {
+		mailer, err := OpenFileMailer(path)
+		if err != nil {
+			t.Fatalf("OpenFileMailer() error = %v", err)
+		}
+		if err := mailer.Send(Message{To: "gopher@example.com", Subject: subject, Body: "Hello"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		mailer.Close()
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	text := string(contents)
+	if strings.Count(text, "To: gopher@example.com\n") != 2 || !strings.Contains(text, "Subject: First\n") || !strings.Contains(text, "Subject: Second\n") {
+		t.Errorf("outbox = %q, want both messages", text)
+	}
+}
@@ -1,19 +1,78 @@
 package main
 
 import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
 	"reddit/actors"
 	"reddit/api/handlers"
 	"reddit/api/routes"
+	"reddit/mail"
+	"reddit/storage"
+	"strings"
+	"syscall"
 
 	"github.com/asynkron/protoactor-go/actor"
 )
 
 func main() {
+	storageKind := flag.String("storage", "memory", "where to keep state: memory or file")
+	dataDir := flag.String("data", "data", "data directory for -storage=file")
+	configPath := flag.String("config", "", "JSON file with actor pool sizes and routing strategies")
+	admins := flag.String("admins", "", "comma-separated usernames who may suspend accounts")
+	mailTo := flag.String("mail", "stdout", "where to send emails: stdout or a file to append them to")
+	flag.Parse()
+
+	engineConfig, err := actors.LoadEngineConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Loading engine config: %v", err)
+	}
+
+	// Open the storage backend
+	var backend storage.Backend
+	switch *storageKind {
+	case "memory":
+		backend = storage.NewMemoryBackend()
+	case "file":
+		fileBackend, err := storage.OpenFileBackend(*dataDir)
+		if err != nil {
+			log.Fatalf("Opening data directory %s: %v", *dataDir, err)
+		}
+		backend = fileBackend
+	default:
+		log.Fatalf("Unknown storage %q, want memory or file", *storageKind)
+	}
+
+	store, err := actors.OpenStore(backend)
+	if err != nil {
+		log.Fatalf("Loading state: %v", err)
+	}
+	store.SetAdmins(strings.Split(*admins, ","))
+	if *mailTo != "stdout" {
+		mailer, err := mail.OpenFileMailer(*mailTo)
+		if err != nil {
+			log.Fatalf("Opening mail file %s: %v", *mailTo, err)
+		}
+		store.SetMailer(mailer)
+	}
+	// Fold the log into a snapshot on shutdown. Writes are already in the
+	// log, so skipping this only makes the next start replay a longer log.
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		if err := store.Close(); err != nil {
+			log.Printf("Closing storage: %v", err)
+		}
+		os.Exit(0)
+	}()
+
 	// Initialize actor system
 	system := actor.NewActorSystem()
 	
 	// Create engine actor with the system
-	engineProps := actor.PropsFromProducer(func() actor.Actor { return actors.NewEngineActor(system) })
+	engineProps := actor.PropsFromProducer(func() actor.Actor { return actors.NewEngineActor(system, store, engineConfig) })
 	enginePID := system.Root.Spawn(engineProps)
 
 	// Initialize handlers
@@ -21,9 +80,12 @@ func main() {
 	subredditHandler := handlers.NewSubredditHandler(system, enginePID)
 	postHandler := handlers.NewPostHandler(system, enginePID)
 	commentHandler := handlers.NewCommentHandler(system, enginePID)
+	messageHandler := handlers.NewMessageHandler(system, enginePID)
+	moderationHandler := handlers.NewModerationHandler(system, enginePID)
+	notificationHandler := handlers.NewNotificationHandler(system, enginePID)
 
 	// Setup router with system and enginePID
-	router := routes.SetupRouter(system, enginePID, userHandler, subredditHandler, postHandler, commentHandler)
+	router := routes.SetupRouter(system, enginePID, userHandler, subredditHandler, postHandler, commentHandler, messageHandler, moderationHandler, notificationHandler)
 
 	// Run the tests
 	//go runTests()
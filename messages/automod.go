@@ -0,0 +1,58 @@
+package messages
+
+import "github.com/asynkron/protoactor-go/actor"
+
+// What a matching AutoModerator rule does to the post or comment
+const (
+	AutoModRemove = "remove" // Take it down
+	AutoModFilter = "filter" // Take it down until a moderator approves it from the queue
+	AutoModReport = "report" // Report it for moderators to look at
+)
+
+// AutoModRule is one AutoModerator rule. It matches when every condition it
+// sets holds; the regular expressions ignore case.
+type AutoModRule struct {
+	Name string `json:"name,omitempty" yaml:"name"`
+	Type string `json:"type,omitempty" yaml:"type"` // "post", "comment" or empty for both
+
+	Title               string   `json:"title,omitempty" yaml:"title"`                             // Regexp on the post title
+	Body                string   `json:"body,omitempty" yaml:"body"`                               // Regexp on the post body or comment text
+	Domains             []string `json:"domains,omitempty" yaml:"domains"`                         // Links to any of these, subdomains included
+	Flair               string   `json:"flair,omitempty" yaml:"flair"`                             // Regexp on the post's flair
+	KarmaBelow          *int     `json:"karmaBelow,omitempty" yaml:"karmaBelow"`                   // Author's total karma is under this
+	AccountAgeBelowDays *int     `json:"accountAgeBelowDays,omitempty" yaml:"accountAgeBelowDays"` // Author registered fewer days ago
+
+	Action       string `json:"action,omitempty" yaml:"action"`             // "remove", "filter", "report" or empty
+	ActionReason string `json:"actionReason,omitempty" yaml:"actionReason"` // For the mod log or the report
+	SetFlair     string `json:"setFlair,omitempty" yaml:"setFlair"`         // Posts only
+	Reply        string `json:"reply,omitempty" yaml:"reply"`               // Posted as a reply by AutoModerator
+}
+
+// SetAutoModRules replaces a subreddit's rules. Rules is a YAML or JSON list
+// of AutoModRule; it needs the config permission.
+type SetAutoModRules struct {
+	SubredditName string
+	ModeratorId   string
+	Rules         string
+	ActorPID      *actor.PID
+}
+
+type SetAutoModRulesResponse struct {
+	Success  bool
+	Error    string
+	Count    int
+	ActorPID *actor.PID
+}
+
+// GetAutoModRules lists the rules to any moderator
+type GetAutoModRules struct {
+	SubredditName string
+	ModeratorId   string
+	ActorPID      *actor.PID
+}
+
+type GetAutoModRulesResponse struct {
+	Success bool
+	Error   string
+	Rules   []*AutoModRule
+}
@@ -7,6 +7,7 @@ type CreateComment struct {
     ParentId    string  // Empty for top-level comments, CommentId for replies
     Content     string
     AuthorId    string
+    Automated   bool    // Posted by AutoModerator, which skips the subreddit's checks and rules
     ActorPID    *actor.PID
 }
 
@@ -27,10 +28,27 @@ type Comment struct {
     ActorPID   *actor.PID
     Timestamp  int64
     VoteCount  int        // Add this field
+    More       *MoreReplies  // Replies left out of Replies, nil if none were
+    Removed    bool          // Taken down by a moderator; Content reads "[removed]"
 }
 
+// MoreReplies stands in for replies cut off by the depth or per-level limit.
+// ListCommentReplies with CommentId and After fetches them.
+type MoreReplies struct {
+    CommentId string  // Comment whose replies were cut off
+    Count     int     // Comments left out, counting their own replies
+    After     string  // Cursor past the replies already shown, empty if none were
+}
+
+// ListPostComments pages through a post's top-level comments with their
+// replies
 type ListPostComments struct {
     PostId   string
+    UserId   string  // Who is asking; private subreddits hide their comments
+    Sort     string  // "best" (default), "top", "new", "old", "controversial" or "qa"
+    Depth    int     // Levels of comments to include, 8 by default
+    Replies  int     // Replies shown under each comment, 10 by default
+    Page
     ActorPID *actor.PID
 }
 
@@ -38,12 +56,34 @@ type ListPostCommentsResponse struct {
     Success  bool
     Error    string
     Comments []*Comment  // Will contain nested structure
+    PageLinks
+}
+
+// ListCommentReplies expands a MoreReplies stub, listing a comment's replies
+// the same way ListPostComments lists top-level comments
+type ListCommentReplies struct {
+    CommentId string
+    UserId    string    // Who is asking
+    Sort      string
+    Depth     int
+    Replies   int
+    Page
+    PostId    string    // Filled in by the engine for routing
+    ActorPID  *actor.PID
+}
+
+type ListCommentRepliesResponse struct {
+    Success  bool
+    Error    string
+    Comments []*Comment
+    PageLinks
 }
 
 type EditComment struct {
     CommentId string
     Content   string
     AuthorId  string    // To verify ownership
+    PostId    string    // Filled in by the engine for routing
     ActorPID  *actor.PID
 }
 
@@ -55,7 +95,8 @@ type EditCommentResponse struct {
 
 type DeleteComment struct {
     CommentId string
-    AuthorId  string    // To verify ownership
+    AuthorId  string    // The author, or a moderator with the comments permission, who removes it instead
+    PostId    string    // Filled in by the engine for routing
     ActorPID  *actor.PID
 }
 
@@ -64,3 +105,17 @@ type DeleteCommentResponse struct {
     Error    string
     ActorPID *actor.PID
 }
+
+// AnonymizeComment credits the comment to "[deleted]" if Username wrote it,
+// as part of deleting their account
+type AnonymizeComment struct {
+    CommentId string
+    Username  string
+    PostId    string    // Filled in by the engine for routing
+    ActorPID  *actor.PID
+}
+
+type AnonymizeCommentResponse struct {
+    Success bool
+    Error   string
+}
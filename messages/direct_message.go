@@ -5,9 +5,11 @@ import "github.com/asynkron/protoactor-go/actor"
 // SendDirectMessage represents a request to send a DM
 type SendDirectMessage struct {
     FromUserID string
-    ToUserID   string
+    ToUserID   string    // May be left empty on replies to mean the other participant
     Content    string
-	ParentID   string
+	ParentID   string    // MessageID being replied to, empty to start a new thread
+	MessageID  string    // Assigned by the engine so it can route new threads
+	ThreadID   string    // Filled in by the engine for routing
 	ActorPID   *actor.PID
 }
 
@@ -21,18 +23,64 @@ type SendDirectMessageResponse struct {
 
 // GetUserMessages represents a request to get all DMs for a user
 type GetUserMessages struct {
-    UserID string
-    ActorPID *actor.PID
+    UserID     string
+    Folder     string    // "inbox", "sent", or empty for both
+    WithUserID string    // Only the conversation with this user, if set
+    ActorPID   *actor.PID
 }
 
 // GetUserMessagesResponse represents the response containing user's DMs
 type GetUserMessagesResponse struct {
-    Success   bool
-    Messages  []DirectMessage
-    Error     string
+    Success     bool
+    Messages    []DirectMessage
+    UnreadCount int
+    Error       string
+    ActorPID    *actor.PID
+}
+
+// GetMessageThread represents a request for a whole conversation thread
+type GetMessageThread struct {
+    MessageID string    // Any message in the thread
+    UserID    string    // Must be a participant
+    ThreadID  string    // Filled in by the engine for routing
     ActorPID  *actor.PID
 }
 
+// GetMessageThreadResponse holds the thread's messages, oldest first
+type GetMessageThreadResponse struct {
+    Success  bool
+    ThreadID string
+    Messages []DirectMessage
+    Error    string
+}
+
+// MarkMessageRead represents a request to mark a received DM read or unread
+type MarkMessageRead struct {
+    MessageID string
+    UserID    string    // Must be the recipient
+    Read      bool
+    ThreadID  string    // Filled in by the engine for routing
+    ActorPID  *actor.PID
+}
+
+type MarkMessageReadResponse struct {
+    Success bool
+    Error   string
+}
+
+// DeleteDirectMessage removes a DM from one participant's view only
+type DeleteDirectMessage struct {
+    MessageID string
+    UserID    string
+    ThreadID  string    // Filled in by the engine for routing
+    ActorPID  *actor.PID
+}
+
+type DeleteDirectMessageResponse struct {
+    Success bool
+    Error   string
+}
+
 // DirectMessage represents a single DM
 type DirectMessage struct {
     MessageID  string
@@ -41,6 +89,7 @@ type DirectMessage struct {
     Content    string
     Timestamp  int64
 	ParentID   string
+	ThreadID   string    // MessageID of the first message in the thread
+	Read       bool      // Whether the recipient has read it
 	ActorPID   *actor.PID
 }
-
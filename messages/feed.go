@@ -4,34 +4,33 @@ import "github.com/asynkron/protoactor-go/actor"
 
 type GetFeed struct {
     UserId   string
+    Sort     string    // "hot" (default), "new", "top", "controversial" or "rising"
+    Period   string    // "day" (default), "week" or "all"; limits top and controversial
+    Page
     ActorPID *actor.PID
 }
 
+// FeedResponse is one ranked list of posts across the user's subreddits
 type FeedResponse struct {
     Success bool
     Error   string
-    Feed    []*SubredditFeed
-}
-
-type SubredditFeed struct {
-    Name        string
-    Description string
-    Posts       []*PostFeed
+    Posts   []*PostFeed
+    PageLinks
 }
 
+// PostFeed is a post as it appears in a listing; its comments are fetched
+// separately, one page at a time
 type PostFeed struct {
     PostId        string
     Title         string
     Content       string
     AuthorId      string
     SubredditName string
-    Comments      []*CommentFeed
+    Flair         string
+    VoteCount     int
+    Slug          string
+    Timestamp     int64
+    EditedAt      int64
+    Removed       bool   // Taken down by a moderator; Content reads "[removed]"
+    CommentCount  int
 }
-
-type CommentFeed struct {
-    CommentId  string
-    Content    string
-    AuthorId   string
-    Replies    []*CommentFeed
-    VoteCount  int
-} 
\ No newline at end of file
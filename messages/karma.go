@@ -5,6 +5,7 @@ import "github.com/asynkron/protoactor-go/actor"
 type UpdateKarma struct {
     UserID string
     Change int
+    Type   string    // "post" or "comment"
 }
 
 type GetKarma struct {
@@ -13,8 +14,10 @@ type GetKarma struct {
 }
 
 type GetKarmaResponse struct {
-    Success bool
-    Karma   int
-    Error   string
-    ActorPID *actor.PID
+    Success      bool
+    Karma        int    // PostKarma + CommentKarma
+    PostKarma    int
+    CommentKarma int
+    Error        string
+    ActorPID     *actor.PID
 }
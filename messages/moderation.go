@@ -0,0 +1,229 @@
+package messages
+
+import "github.com/asynkron/protoactor-go/actor"
+
+// Moderator permissions. "all" grants every other one, including managing
+// the moderator list itself.
+const (
+	ModPermAll      = "all"
+	ModPermPosts    = "posts"    // Remove posts
+	ModPermComments = "comments" // Remove comments
+	ModPermUsers    = "users"    // Manage members
+	ModPermConfig   = "config"   // Edit the subreddit's settings
+)
+
+// InviteModerator offers Username a place on the moderator list. It takes
+// effect once they accept.
+type InviteModerator struct {
+	SubredditName string
+	ModeratorId   string // Who is inviting; needs "all"
+	Username      string
+	Permissions   []string
+	ActorPID      *actor.PID
+}
+
+type InviteModeratorResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// AcceptModeratorInvite makes Username a moderator with the permissions they
+// were invited with
+type AcceptModeratorInvite struct {
+	SubredditName string
+	Username      string
+	ActorPID      *actor.PID
+}
+
+type AcceptModeratorInviteResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// SetModeratorPermissions replaces a moderator's permissions
+type SetModeratorPermissions struct {
+	SubredditName string
+	ModeratorId   string // Who is changing them; needs "all"
+	Username      string
+	Permissions   []string
+	ActorPID      *actor.PID
+}
+
+type SetModeratorPermissionsResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// RemoveModerator takes Username off the moderator list, or withdraws their
+// invitation. Moderators may always remove themselves.
+type RemoveModerator struct {
+	SubredditName string
+	ModeratorId   string // Who is removing them; needs "all" unless it's themselves
+	Username      string
+	ActorPID      *actor.PID
+}
+
+type RemoveModeratorResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+type GetModerators struct {
+	SubredditName string
+	ActorPID      *actor.PID
+}
+
+type Moderator struct {
+	Username    string
+	Permissions []string
+	Pending     bool // Invited but not yet accepted
+}
+
+type GetModeratorsResponse struct {
+	Success    bool
+	Error      string
+	Moderators []*Moderator
+}
+
+// BanUser keeps Username from posting, commenting, voting or joining. All
+// of the user-management messages below need the users permission.
+type BanUser struct {
+	SubredditName string
+	ModeratorId   string
+	Username      string
+	Reason        string
+	Days          int // 0 for a permanent ban
+	ActorPID      *actor.PID
+}
+
+type BanUserResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+type UnbanUser struct {
+	SubredditName string
+	ModeratorId   string
+	Username      string
+	ActorPID      *actor.PID
+}
+
+type UnbanUserResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// MuteUser keeps Username from posting or commenting; they can still vote
+type MuteUser struct {
+	SubredditName string
+	ModeratorId   string
+	Username      string
+	Reason        string
+	Days          int // 0 until unmuted
+	ActorPID      *actor.PID
+}
+
+type MuteUserResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+type UnmuteUser struct {
+	SubredditName string
+	ModeratorId   string
+	Username      string
+	ActorPID      *actor.PID
+}
+
+type UnmuteUserResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// SetApprovedSubmitter adds Username to, or removes them from, the users
+// allowed to post in a restricted subreddit
+type SetApprovedSubmitter struct {
+	SubredditName string
+	ModeratorId   string
+	Username      string
+	Approved      bool
+	ActorPID      *actor.PID
+}
+
+type SetApprovedSubmitterResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// Lists GetSubredditUsers can return
+const (
+	UserListBanned   = "banned"
+	UserListMuted    = "muted"
+	UserListApproved = "approved"
+)
+
+// GetSubredditUsers lists the banned, muted or approved users of a
+// subreddit. Expired bans and mutes are left out.
+type GetSubredditUsers struct {
+	SubredditName string
+	ModeratorId   string
+	List          string
+	ActorPID      *actor.PID
+}
+
+type SubredditUser struct {
+	Username  string
+	Reason    string
+	By        string // Moderator who added them
+	At        int64
+	ExpiresAt int64 // 0 if it doesn't expire
+}
+
+type GetSubredditUsersResponse struct {
+	Success bool
+	Error   string
+	Users   []*SubredditUser
+}
+
+// GetJoinRequests lists the users waiting to join a private subreddit, oldest
+// first. It and RespondJoinRequest need the users permission.
+type GetJoinRequests struct {
+	SubredditName string
+	ModeratorId   string
+	ActorPID      *actor.PID
+}
+
+type JoinRequest struct {
+	Username string
+	At       int64
+}
+
+type GetJoinRequestsResponse struct {
+	Success  bool
+	Error    string
+	Requests []*JoinRequest
+}
+
+// RespondJoinRequest lets Username in, or turns them away
+type RespondJoinRequest struct {
+	SubredditName string
+	ModeratorId   string
+	Username      string
+	Accept        bool
+	ActorPID      *actor.PID
+}
+
+type RespondJoinRequestResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
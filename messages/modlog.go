@@ -0,0 +1,57 @@
+package messages
+
+import "github.com/asynkron/protoactor-go/actor"
+
+// Mod log actions
+const (
+	ModLogRemovePost           = "remove-post"
+	ModLogApprovePost          = "approve-post"
+	ModLogIgnorePostReports    = "ignore-post-reports"
+	ModLogRemoveComment        = "remove-comment"
+	ModLogApproveComment       = "approve-comment"
+	ModLogIgnoreCommentReports = "ignore-comment-reports"
+	ModLogBanUser              = "ban-user"
+	ModLogUnbanUser            = "unban-user"
+	ModLogMuteUser             = "mute-user"
+	ModLogUnmuteUser           = "unmute-user"
+	ModLogApproveSubmitter     = "approve-submitter"
+	ModLogRemoveSubmitter      = "remove-submitter"
+	ModLogAcceptJoinRequest    = "accept-join-request"
+	ModLogDenyJoinRequest      = "deny-join-request"
+	ModLogInviteModerator      = "invite-moderator"
+	ModLogAddModerator         = "add-moderator" // The invitation was accepted
+	ModLogSetPermissions       = "set-permissions"
+	ModLogRemoveModerator      = "remove-moderator"
+	ModLogEditSettings         = "edit-settings"
+	ModLogDeleteSubreddit      = "delete-subreddit"
+)
+
+// GetModLog pages through a subreddit's mod log, newest first. Only its
+// moderators may read it. Empty filters match everything.
+type GetModLog struct {
+	SubredditName string
+	UserId        string // Who is asking
+	Moderator     string // Only this moderator's actions
+	Action        string // Only this action
+	Since         int64  // Unix seconds, inclusive; 0 for no lower bound
+	Until         int64  // Unix seconds, exclusive; 0 for no upper bound
+	Page
+	ActorPID *actor.PID
+}
+
+type ModLogEntry struct {
+	Id         string
+	Moderator  string
+	Action     string
+	TargetUser string // The user acted on, or the author of the content
+	TargetId   string // PostId or CommentId, if the action was on content
+	Details    string // Reason, permissions or what changed
+	At         int64
+}
+
+type GetModLogResponse struct {
+	Success bool
+	Error   string
+	Entries []*ModLogEntry
+	PageLinks
+}
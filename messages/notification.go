@@ -0,0 +1,73 @@
+package messages
+
+import "github.com/asynkron/protoactor-go/actor"
+
+// Notification types, each of which users can turn off on their profile
+const (
+	NotifyPostReply    = "post-reply"    // A top-level comment on their post
+	NotifyCommentReply = "comment-reply" // A reply to their comment
+	NotifyMention      = "mention"       // u/username in a post or comment
+	NotifyMessage      = "message"       // A direct message
+)
+
+// NotificationTypes lists every type, in the order profiles show them
+var NotificationTypes = []string{NotifyPostReply, NotifyCommentReply, NotifyMention, NotifyMessage}
+
+// Notify tells a user something happened. Actors send it to the engine
+// without waiting; it's dropped if the user has that type turned off.
+type Notify struct {
+	Username      string // Who is told
+	Type          string
+	From          string // Who did it
+	SubredditName string // Empty for direct messages
+	PostId        string
+	CommentId     string
+	MessageId     string // For direct messages
+	Preview       string // The start of what was written
+}
+
+// Notification is one entry in a user's inbox
+type Notification struct {
+	NotificationId string
+	Type           string
+	From           string
+	SubredditName  string `json:",omitempty"`
+	PostId         string `json:",omitempty"`
+	CommentId      string `json:",omitempty"`
+	MessageId      string `json:",omitempty"`
+	Preview        string
+	Timestamp      int64
+	Read           bool
+}
+
+// GetNotifications pages through a user's notifications, newest first
+type GetNotifications struct {
+	Username   string
+	UnreadOnly bool
+	Page
+	ActorPID *actor.PID
+}
+
+type GetNotificationsResponse struct {
+	Success       bool
+	Error         string
+	Notifications []*Notification
+	UnreadCount   int
+	PageLinks
+}
+
+// MarkNotificationsRead marks the given notifications, or with All every
+// one of them, read or unread
+type MarkNotificationsRead struct {
+	Username        string
+	NotificationIds []string
+	All             bool
+	Read            bool
+	ActorPID        *actor.PID
+}
+
+type MarkNotificationsReadResponse struct {
+	Success bool
+	Error   string
+	Marked  int // How many changed
+}
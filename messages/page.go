@@ -0,0 +1,16 @@
+package messages
+
+// Page picks one page of a listing. After and Before are cursors taken from
+// an earlier response, at most one of them set; Limit defaults to 25.
+type Page struct {
+	After  string
+	Before string
+	Limit  int
+}
+
+// PageLinks holds the cursors for the pages either side of the one returned,
+// empty at either end of the listing
+type PageLinks struct {
+	Next string
+	Prev string
+}
@@ -9,6 +9,12 @@ type Post struct {
 	Content       string
 	AuthorId      string
 	SubredditName string
+	Flair         string // Optional label, which AutoModerator may also set
+	VoteCount     int    // Upvotes minus downvotes, filled in on reads
+	Slug          string // URL-friendly form of the title, filled in on reads
+	Timestamp     int64  // Unix seconds the post was created
+	EditedAt      int64  // Unix seconds of the last edit, 0 if never edited
+	Removed       bool   // Taken down by a moderator; Content reads "[removed]"
 	ActorPID      *actor.PID
 }
 
@@ -23,6 +29,7 @@ type PostResponse struct {
 // GetPost message for retrieving a post
 type GetPost struct {
 	PostId   string
+	UserId   string // Who is reading; private subreddits only show members
 	ActorPID *actor.PID
 }
 
@@ -36,6 +43,10 @@ type GetPostResponse struct {
 // ListSubredditPosts message for getting posts in a subreddit
 type ListSubredditPosts struct {
 	SubredditName string
+	Sort          string // Same orderings as the feed, hot by default
+	Period        string
+	UserId        string // Who is reading
+	Page
 	ActorPID      *actor.PID
 }
 
@@ -44,6 +55,7 @@ type ListSubredditPostsResponse struct {
 	Success bool
 	Error   string
 	Posts   []*Post
+	PageLinks
 }
 
 // EditPost message for editing a post
@@ -65,7 +77,8 @@ type EditPostResponse struct {
 // DeletePost message for deleting a post
 type DeletePost struct {
 	PostId   string
-	AuthorId string    // For verification
+	AuthorId string    // The author, or a moderator with the posts permission, who removes it instead
+	Cascade  bool      // Part of deleting the whole subreddit; skips the author check and archives the post
 	ActorPID *actor.PID
 }
 
@@ -77,8 +90,22 @@ type DeletePostResponse struct {
 }
 
 // For cascading deletion
+// AnonymizePost credits the post to "[deleted]" if Username wrote it, as
+// part of deleting their account
+type AnonymizePost struct {
+	PostId   string
+	Username string
+	ActorPID *actor.PID
+}
+
+type AnonymizePostResponse struct {
+	Success bool
+	Error   string
+}
+
 type DeletePostComments struct {
 	PostId   string
+	Archive  bool      // Keep a copy of every comment, as when the subreddit is deleted
 	ActorPID *actor.PID
 }
 
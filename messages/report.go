@@ -0,0 +1,120 @@
+package messages
+
+import "github.com/asynkron/protoactor-go/actor"
+
+// Report reasons, as accepted by ReportPost and ReportComment
+const (
+	ReportSpam           = "spam"
+	ReportHarassment     = "harassment"
+	ReportHate           = "hate"
+	ReportMisinformation = "misinformation"
+	ReportBreaksRules    = "breaks-rules"
+	ReportOther          = "other"
+)
+
+// ReportPost flags a post for the subreddit's moderators. Reporting again
+// replaces the user's earlier report.
+type ReportPost struct {
+	PostId     string
+	ReporterId string
+	Reason     string
+	Details    string // Optional free text
+	ActorPID   *actor.PID
+}
+
+type ReportPostResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+type ReportComment struct {
+	CommentId  string
+	PostId     string // Filled in by the engine for routing
+	ReporterId string
+	Reason     string
+	Details    string
+	ActorPID   *actor.PID
+}
+
+type ReportCommentResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// What a moderator can do with a queued post or comment
+const (
+	ModActionApprove       = "approve"        // Keep it, clearing its reports
+	ModActionRemove        = "remove"         // Replace it with "[removed]"
+	ModActionIgnoreReports = "ignore-reports" // Keep it out of the reported queue
+)
+
+// ModeratePost needs the posts permission in the post's subreddit
+type ModeratePost struct {
+	PostId      string
+	ModeratorId string
+	Action      string
+	ActorPID    *actor.PID
+}
+
+type ModeratePostResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// ModerateComment needs the comments permission
+type ModerateComment struct {
+	CommentId   string
+	PostId      string // Filled in by the engine for routing
+	ModeratorId string
+	Action      string
+	ActorPID    *actor.PID
+}
+
+type ModerateCommentResponse struct {
+	Success  bool
+	Error    string
+	ActorPID *actor.PID
+}
+
+// Queues GetModQueue can list
+const (
+	QueueReported    = "reported"    // Reported and neither removed nor ignored
+	QueueRemoved     = "removed"     // Removed by a moderator
+	QueueUnmoderated = "unmoderated" // Posts no moderator has approved or removed yet
+)
+
+// GetModQueue pages through one of a subreddit's queues, newest first. Posts
+// need the posts permission to show and comments the comments permission.
+type GetModQueue struct {
+	SubredditName string
+	ModeratorId   string
+	Queue         string // "reported" by default
+	Page
+	ActorPID *actor.PID
+}
+
+type ModQueueItem struct {
+	Type           string // "post" or "comment"
+	Id             string // PostId or CommentId
+	PostId         string
+	Title          string // Posts only
+	Content        string // As written, even if removed
+	AuthorId       string
+	Timestamp      int64
+	ReportCount    int
+	Reasons        map[string]int // reason -> reports
+	Removed        bool
+	Filtered       bool // Removed by AutoModerator, awaiting review
+	Approved       bool
+	ReportsIgnored bool
+}
+
+type GetModQueueResponse struct {
+	Success bool
+	Error   string
+	Items   []*ModQueueItem
+	PageLinks
+}
@@ -0,0 +1,101 @@
+package messages
+
+// Hash methods let the engine's consistent-hash routers send every message
+// about an entity to the one actor that owns it. Each returns the key the
+// owning pool is partitioned by: username, subreddit name, PostId or ThreadID.
+// Searches and autocomplete read every entity, so they hash on what is asked
+// for, which only matters if they are configured for consistent hashing.
+
+func (msg *RegisterUser) Hash() string   { return msg.Username }
+func (msg *LoginUser) Hash() string      { return msg.Username }
+func (msg *RefreshToken) Hash() string   { return msg.Username }
+func (msg *Logout) Hash() string         { return msg.Username }
+func (msg *ChangePassword) Hash() string { return msg.Username }
+func (msg *ValidateToken) Hash() string  { return msg.Username }
+func (msg *UpdateKarma) Hash() string    { return msg.UserID }
+func (msg *GetKarma) Hash() string       { return msg.UserID }
+func (msg *GetFeed) Hash() string        { return msg.UserId }
+func (msg *SearchUsers) Hash() string    { return msg.Query }
+func (msg *Autocomplete) Hash() string   { return msg.Prefix }
+
+func (msg *EditUserProfile) Hash() string      { return msg.UserId }
+func (msg *GetUserProfile) Hash() string       { return msg.Username }
+func (msg *GetUserPosts) Hash() string         { return msg.Username }
+func (msg *GetUserComments) Hash() string      { return msg.Username }
+func (msg *DeactivateAccount) Hash() string    { return msg.Username }
+func (msg *DeleteAccount) Hash() string        { return msg.Username }
+func (msg *SuspendUser) Hash() string          { return msg.Username }
+func (msg *UnsuspendUser) Hash() string        { return msg.Username }
+func (msg *VerifyEmail) Hash() string          { return msg.Username }
+func (msg *ResendVerification) Hash() string   { return msg.Username }
+func (msg *RequestPasswordReset) Hash() string { return msg.Username }
+func (msg *ResetPassword) Hash() string        { return msg.Username }
+
+func (msg *CreateSubreddit) Hash() string     { return msg.Name }
+func (msg *JoinSubreddit) Hash() string       { return msg.SubredditName }
+func (msg *LeaveSubreddit) Hash() string      { return msg.SubredditName }
+func (msg *GetSubredditMembers) Hash() string { return msg.SubredditName }
+func (msg *DeleteSubreddit) Hash() string     { return msg.Name }
+func (msg *DropSubredditUser) Hash() string   { return msg.SubredditName }
+func (msg *EditSubreddit) Hash() string       { return msg.Name }
+func (msg *GetSubreddit) Hash() string        { return msg.Name }
+func (msg *GetSubreddits) Hash() string       { return "" }
+func (msg *SearchSubreddits) Hash() string    { return msg.Query }
+
+func (msg *InviteModerator) Hash() string         { return msg.SubredditName }
+func (msg *AcceptModeratorInvite) Hash() string   { return msg.SubredditName }
+func (msg *SetModeratorPermissions) Hash() string { return msg.SubredditName }
+func (msg *RemoveModerator) Hash() string         { return msg.SubredditName }
+func (msg *GetModerators) Hash() string           { return msg.SubredditName }
+func (msg *BanUser) Hash() string                 { return msg.SubredditName }
+func (msg *UnbanUser) Hash() string               { return msg.SubredditName }
+func (msg *MuteUser) Hash() string                { return msg.SubredditName }
+func (msg *UnmuteUser) Hash() string              { return msg.SubredditName }
+func (msg *SetApprovedSubmitter) Hash() string    { return msg.SubredditName }
+func (msg *GetSubredditUsers) Hash() string       { return msg.SubredditName }
+func (msg *GetJoinRequests) Hash() string         { return msg.SubredditName }
+func (msg *RespondJoinRequest) Hash() string      { return msg.SubredditName }
+func (msg *GetModQueue) Hash() string             { return msg.SubredditName }
+func (msg *GetModLog) Hash() string               { return msg.SubredditName }
+func (msg *SetAutoModRules) Hash() string         { return msg.SubredditName }
+func (msg *GetAutoModRules) Hash() string         { return msg.SubredditName }
+
+func (msg *Post) Hash() string               { return msg.PostId }
+func (msg *GetPost) Hash() string            { return msg.PostId }
+func (msg *EditPost) Hash() string           { return msg.PostId }
+func (msg *DeletePost) Hash() string         { return msg.PostId }
+func (msg *ListSubredditPosts) Hash() string { return msg.SubredditName }
+func (msg *SearchPosts) Hash() string        { return msg.Query }
+func (msg *ReportPost) Hash() string         { return msg.PostId }
+func (msg *ModeratePost) Hash() string       { return msg.PostId }
+func (msg *AnonymizePost) Hash() string      { return msg.PostId }
+
+// Comments are partitioned by post, so a post's whole comment tree has one owner
+func (msg *CreateComment) Hash() string      { return msg.PostId }
+func (msg *ListPostComments) Hash() string   { return msg.PostId }
+func (msg *ListCommentReplies) Hash() string { return msg.PostId }
+func (msg *EditComment) Hash() string        { return msg.PostId }
+func (msg *DeleteComment) Hash() string      { return msg.PostId }
+func (msg *DeletePostComments) Hash() string { return msg.PostId }
+func (msg *ReportComment) Hash() string      { return msg.PostId }
+func (msg *ModerateComment) Hash() string    { return msg.PostId }
+func (msg *AnonymizeComment) Hash() string   { return msg.PostId }
+func (msg *SearchComments) Hash() string     { return msg.Query }
+
+// Votes go to the post pool or, for comments, the comment pool
+func (msg *Vote) Hash() string {
+	if msg.Type == "comment" {
+		return msg.PostId
+	}
+	return msg.TargetID
+}
+
+func (msg *SendDirectMessage) Hash() string   { return msg.ThreadID }
+func (msg *GetUserMessages) Hash() string     { return msg.UserID }
+func (msg *GetMessageThread) Hash() string    { return msg.ThreadID }
+func (msg *MarkMessageRead) Hash() string     { return msg.ThreadID }
+func (msg *DeleteDirectMessage) Hash() string { return msg.ThreadID }
+
+func (msg *Notify) Hash() string                { return msg.Username }
+func (msg *GetNotifications) Hash() string      { return msg.Username }
+func (msg *MarkNotificationsRead) Hash() string { return msg.Username }
@@ -1,11 +1,81 @@
 package messages
 
+// SearchPosts searches post titles and bodies; see search.Index.Parse for
+// the query syntax
 type SearchPosts struct {
-    Query string
+    Query  string
+    UserId string  // Who is searching; private subreddits only show members
+    Page
 }
 
 type SearchPostsResponse struct {
     Success bool
     Error   string
     Posts   []*PostFeed
-} 
\ No newline at end of file
+    PageLinks
+} 
+
+// SearchComments searches comment text, with the same syntax as posts
+type SearchComments struct {
+    Query  string
+    UserId string
+    Page
+}
+
+type SearchCommentsResponse struct {
+    Success  bool
+    Error    string
+    Comments []*Comment  // Without their replies
+    PageLinks
+}
+
+// SearchSubreddits searches subreddit names and descriptions
+type SearchSubreddits struct {
+    Query string
+    Page
+}
+
+type SubredditResult struct {
+    Name        string
+    Description string
+    MemberCount int
+}
+
+type SearchSubredditsResponse struct {
+    Success    bool
+    Error      string
+    Subreddits []*SubredditResult
+    PageLinks
+}
+
+// SearchUsers searches usernames
+type SearchUsers struct {
+    Query string
+    Page
+}
+
+type UserResult struct {
+    Username string
+    Karma    int
+}
+
+type SearchUsersResponse struct {
+    Success bool
+    Error   string
+    Users   []*UserResult
+    PageLinks
+}
+
+// Autocomplete completes a partly typed subreddit or user name, for
+// typeahead. Subreddits with more members come first.
+type Autocomplete struct {
+    Type   string  // "subreddit" or "user"
+    Prefix string
+    Limit  int     // 10 by default
+}
+
+type AutocompleteResponse struct {
+    Success bool
+    Error   string
+    Names   []string
+}
@@ -2,11 +2,19 @@ package messages
 
 import "github.com/asynkron/protoactor-go/actor"
 
+// Subreddit types, as accepted by CreateSubreddit and EditSubreddit
+const (
+	SubredditPublic     = "public"     // Anyone reads, posts and joins
+	SubredditRestricted = "restricted" // Anyone reads; only moderators and approved submitters post
+	SubredditPrivate    = "private"    // Only members read; joining takes a moderator's approval
+)
+
 // Subreddit related messages
 type CreateSubreddit struct {
 	Name        string
 	Description string
 	CreatorId   string
+	Type        string // "public" (default), "restricted" or "private"
 	ActorPID    *actor.PID
 }
 
@@ -29,6 +37,7 @@ type JoinSubredditResponse struct {
 	Success bool
 	Error   string
 	SubId   string
+	Pending bool  // Private subreddits queue the request for a moderator
 }
 
 type GetSubredditMembers struct {
@@ -75,11 +84,23 @@ type GetSubredditsResponse struct {
 	Error      string
 }
 
+// SubredditRule is one of the rules a subreddit lists for its users
+type SubredditRule struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
 type EditSubreddit struct {
-	Name        string
-	Description string
-	AuthorId    string    // Only moderators/creators can edit
-	ActorPID    *actor.PID
+	Name         string
+	Description  string          // Left as is if empty
+	Type         string          // Left as is if empty
+	Sidebar      *string         // Left as is if nil; empty clears it
+	BannerText   *string         // Left as is if nil; empty clears it
+	Rules        []SubredditRule // Left as is if nil; empty clears them
+	NSFW         *bool           // Left as is if nil
+	RequireFlair *bool           // Left as is if nil
+	AuthorId     string          // Only moderators/creators can edit
+	ActorPID     *actor.PID
 }
 
 type EditSubredditResponse struct {
@@ -88,9 +109,11 @@ type EditSubredditResponse struct {
 	ActorPID *actor.PID
 }
 
+// DeleteSubreddit archives the subreddit with its posts and comments. Sending
+// it again finishes a deletion that was interrupted.
 type DeleteSubreddit struct {
 	Name     string
-	AuthorId string    // Only creator can delete
+	AuthorId string    // Only creator can delete, or an admin once the creator's account is gone
 	ActorPID *actor.PID
 }
 
@@ -100,14 +123,43 @@ type DeleteSubredditResponse struct {
 	ActorPID *actor.PID
 }
 
-// For cascading deletion
-type DeleteSubredditPosts struct {
+// DropSubredditUser takes Username out of the subreddit's members,
+// moderators, approved submitters and join requests, as part of deleting
+// their account
+type DropSubredditUser struct {
 	SubredditName string
+	Username      string
 	ActorPID      *actor.PID
 }
 
-type DeleteSubredditPostsResponse struct {
-	Success  bool
-	Error    string
+type DropSubredditUserResponse struct {
+	Success bool
+	Error   string
+}
+
+type GetSubreddit struct {
+	Name     string
+	UserId   string
 	ActorPID *actor.PID
 }
+
+// SubredditInfo is what a subreddit tells about itself
+type SubredditInfo struct {
+	Name         string
+	Description  string
+	Sidebar      string
+	BannerText   string
+	Rules        []SubredditRule
+	Type         string
+	NSFW         bool
+	RequireFlair bool // Posts must carry a flair
+	CreatorId    string
+	Moderators   []string
+	MemberCount  int
+}
+
+type GetSubredditResponse struct {
+	Success   bool
+	Error     string
+	Subreddit *SubredditInfo
+}
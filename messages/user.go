@@ -6,6 +6,7 @@ import "github.com/asynkron/protoactor-go/actor"
 type RegisterUser struct {
 	Username string
 	Password string
+	Email    string // Optional; a verification link is sent to it
 }
 
 type RegisterUserResponse struct {
@@ -22,30 +23,85 @@ type LoginUser struct {
 }
 
 type LoginUserResponse struct {
+	Success      bool
+	Error        string
+	Token        string
+	RefreshToken string
+	ExpiresAt    int64 // Unix time the access token stops working
+	Reactivated  bool  // The account was deactivated until this login
+}
+
+// RefreshToken message to trade a refresh token for a new token pair
+type RefreshToken struct {
+	RefreshToken string
+	Username     string // Read from the token by the engine, for routing only
+	ActorPID     *actor.PID
+}
+
+// RefreshTokenResponse carries the new token pair; the old refresh token is spent
+type RefreshTokenResponse struct {
+	Success      bool
+	Error        string
+	Token        string
+	RefreshToken string
+	ExpiresAt    int64
+}
+
+// Logout message to revoke the tokens of one session, or of every session
+type Logout struct {
+	Username    string
+	SessionId   string
+	AllSessions bool
+	ActorPID    *actor.PID
+}
+
+// LogoutResponse is the response to a logout request
+type LogoutResponse struct {
+	Success bool
+	Error   string
+	Revoked int // Number of tokens revoked
+}
+
+// ChangePassword message to replace a user's password; requires the old one
+type ChangePassword struct {
+	Username    string
+	OldPassword string
+	NewPassword string
+	SessionId   string // The caller's login, which stays signed in
+	ActorPID    *actor.PID
+}
+
+// ChangePasswordResponse is the response to a password change request
+type ChangePasswordResponse struct {
 	Success bool
 	Error   string
-	Token   string
 }
 
 // ValidateToken message to validate a token
 type ValidateToken struct {
 	Token    string
+	Username string // Read from the token by the engine, for routing only
 	ActorPID *actor.PID
 }
 
 // ValidateTokenResponse is the response to a token validation request
 type ValidateTokenResponse struct {
-	Success  bool
-	Username string
-	Error    string
+	Success   bool
+	Username  string
+	SessionId string
+	Error     string
+	ErrorCode string // "invalid_token", "token_expired" or "token_revoked"
 }
 
+// EditUserProfile changes the fields that are set; an empty string clears one
 type EditUserProfile struct {
-	UserId      string
-	Email       string
-	DisplayName string
-	Bio         string
-	ActorPID    *actor.PID
+	UserId        string
+	Email         *string
+	DisplayName   *string
+	Bio           *string
+	AvatarURL     *string         // http(s) link to an image
+	Notifications map[string]bool // Notification type -> on; types left out stay as they are
+	ActorPID      *actor.PID
 }
 
 type EditUserProfileResponse struct {
@@ -53,3 +109,172 @@ type EditUserProfileResponse struct {
 	Error    string
 	ActorPID *actor.PID
 }
+
+type GetUserProfile struct {
+	Username string
+	ViewerId string // Only the user themself sees their email
+	ActorPID *actor.PID
+}
+
+// UserProfile is a user's public page
+type UserProfile struct {
+	Username      string
+	DisplayName   string
+	Bio           string
+	AvatarURL     string
+	Email         string          `json:",omitempty"`
+	EmailVerified bool            `json:",omitempty"` // Only shown with the email
+	Notifications map[string]bool `json:",omitempty"` // Type -> on; only shown to the user themself
+	CreatedAt     int64           // Unix seconds, 0 if they registered before profiles
+	PostKarma     int
+	CommentKarma  int
+	TotalKarma    int
+	Suspension    *Suspension `json:",omitempty"` // Only shown to admins
+}
+
+// Suspension is why and until when an admin suspended an account
+type Suspension struct {
+	Reason    string
+	By        string
+	At        int64
+	ExpiresAt int64 // 0 if it doesn't expire
+}
+
+type GetUserProfileResponse struct {
+	Success bool
+	Error   string
+	Profile *UserProfile
+}
+
+// GetUserPosts lists what a user posted, newest first by default, leaving
+// out removed posts and those in private subreddits the viewer can't see
+type GetUserPosts struct {
+	Username string
+	ViewerId string
+	Sort     string // "new" (default), "top" or "controversial"
+	Period   string // For top and controversial; "all" by default
+	Page
+	ActorPID *actor.PID
+}
+
+type GetUserPostsResponse struct {
+	Success bool
+	Error   string
+	Posts   []*Post
+	PageLinks
+}
+
+// GetUserComments lists what a user commented, the same way
+type GetUserComments struct {
+	Username string
+	ViewerId string
+	Sort     string // "new" (default), "top" or "controversial"
+	Page
+	ActorPID *actor.PID
+}
+
+type GetUserCommentsResponse struct {
+	Success  bool
+	Error    string
+	Comments []*Comment
+	PageLinks
+}
+
+// DeactivateAccount hides the user's profile and signs them out everywhere
+// until they log in again
+type DeactivateAccount struct {
+	Username string
+	ActorPID *actor.PID
+}
+
+type DeactivateAccountResponse struct {
+	Success bool
+	Error   string
+}
+
+// DeleteAccount removes the user for good. Their posts and comments stay,
+// credited to "[deleted]", and nobody can register the name again.
+type DeleteAccount struct {
+	Username string
+	Password string
+	ActorPID *actor.PID
+}
+
+type DeleteAccountResponse struct {
+	Success bool
+	Error   string
+}
+
+// SuspendUser keeps a user from logging in; only admins may send it
+type SuspendUser struct {
+	AdminId  string
+	Username string
+	Reason   string
+	Days     int // 0 lasts until lifted
+	ActorPID *actor.PID
+}
+
+type SuspendUserResponse struct {
+	Success bool
+	Error   string
+}
+
+type UnsuspendUser struct {
+	AdminId  string
+	Username string
+	ActorPID *actor.PID
+}
+
+type UnsuspendUserResponse struct {
+	Success bool
+	Error   string
+}
+
+// VerifyEmail confirms the user's email with the token mailed to it
+type VerifyEmail struct {
+	Token    string
+	Username string // Read from the token by the engine, for routing only
+	ActorPID *actor.PID
+}
+
+type VerifyEmailResponse struct {
+	Success bool
+	Error   string
+}
+
+// ResendVerification mails a new verification token, spending the old one
+type ResendVerification struct {
+	Username string
+	ActorPID *actor.PID
+}
+
+type ResendVerificationResponse struct {
+	Success bool
+	Error   string
+}
+
+// RequestPasswordReset mails a reset token to the user's verified email.
+// It succeeds whether or not there is one, so nobody learns who has an email.
+type RequestPasswordReset struct {
+	Username string
+	ActorPID *actor.PID
+}
+
+type RequestPasswordResetResponse struct {
+	Success bool
+	Error   string
+}
+
+// ResetPassword sets a new password with a reset token, which is spent, and
+// signs the user out everywhere
+type ResetPassword struct {
+	Token       string
+	NewPassword string
+	Username    string // Read from the token by the engine, for routing only
+	ActorPID    *actor.PID
+}
+
+type ResetPasswordResponse struct {
+	Success bool
+	Error   string
+}
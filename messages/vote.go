@@ -7,6 +7,7 @@ type Vote struct {
     TargetID  string
     IsUpvote  bool
     Type      string    // "post" or "comment"
+    PostId    string    // Post a voted comment belongs to; filled in by the engine for routing
     ActorPID  *actor.PID
 }
 
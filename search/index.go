@@ -0,0 +1,369 @@
+// Package search is an in-memory full-text index. Documents are indexed as
+// they change rather than scanned on every query, and matches are ranked
+// with BM25.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BM25 parameters, the usual defaults: k1 is how quickly repeating a term
+// stops counting, b how much a long field is penalized for its length
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Document is one thing to index. Fields hold the text to search, by field
+// name; Tags hold exact values a query can filter on, like subreddit:golang.
+type Document struct {
+	ID     string
+	Fields map[string]string
+	Tags   map[string]string
+	Time   int64   // Unix seconds, for after: and before:
+	Weight float64 // Popularity, to order Suggest's results
+}
+
+// Hit is one matching document
+type Hit struct {
+	ID    string
+	Score float64
+	Time  int64
+}
+
+// entry is what the index remembers about a document, enough to filter it
+// and to take it out again
+type entry struct {
+	tags    map[string]string // lowercase values
+	time    int64
+	weight  float64
+	lengths map[string]int // field -> terms in it
+	terms   []string       // distinct terms, for Remove
+}
+
+// Index maps terms to the documents and positions they occur at. It is safe
+// for concurrent use.
+type Index struct {
+	weights map[string]float64 // field -> boost for matches in it
+	tags    map[string]bool
+	names   bool // Fields hold names, not prose; see NewNameIndex
+
+	mu       sync.RWMutex
+	docs     map[string]*entry
+	postings map[string]map[string]map[string][]int // term -> doc ID -> field -> positions
+	lengths  map[string]int                         // field -> terms across all documents
+	sorted   []string                               // nameKey of every doc, sorted, for Suggest
+}
+
+// NewIndex creates an empty index searching the fields in weights, a match in
+// each counting for its weight, and filtering on the named tags
+func NewIndex(weights map[string]float64, tags ...string) *Index {
+	index := &Index{
+		weights:  weights,
+		tags:     make(map[string]bool, len(tags)),
+		docs:     make(map[string]*entry),
+		postings: make(map[string]map[string]map[string][]int),
+		lengths:  make(map[string]int),
+	}
+	for _, tag := range tags {
+		index.tags[tag] = true
+	}
+	return index
+}
+
+// NewNameIndex is NewIndex for fields holding names rather than prose, which
+// are neither stemmed nor stripped of stop words
+func NewNameIndex(weights map[string]float64, tags ...string) *Index {
+	index := NewIndex(weights, tags...)
+	index.names = true
+	return index
+}
+
+func (index *Index) isField(name string) bool {
+	_, known := index.weights[name]
+	return known
+}
+
+func (index *Index) isTag(name string) bool {
+	return index.tags[name]
+}
+
+// Add indexes a document, replacing any earlier version with the same ID
+func (index *Index) Add(doc Document) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.remove(doc.ID)
+
+	e := &entry{
+		tags:    make(map[string]string, len(doc.Tags)),
+		time:    doc.Time,
+		weight:  doc.Weight,
+		lengths: make(map[string]int, len(doc.Fields)),
+	}
+	for tag, value := range doc.Tags {
+		e.tags[tag] = strings.ToLower(value)
+	}
+
+	for field, text := range doc.Fields {
+		if !index.isField(field) {
+			continue
+		}
+		tokens := tokenize(text, index.names)
+		e.lengths[field] = len(tokens)
+		index.lengths[field] += len(tokens)
+		for _, t := range tokens {
+			docs, exists := index.postings[t.term]
+			if !exists {
+				docs = make(map[string]map[string][]int)
+				index.postings[t.term] = docs
+			}
+			fields, exists := docs[doc.ID]
+			if !exists {
+				fields = make(map[string][]int)
+				docs[doc.ID] = fields
+				e.terms = append(e.terms, t.term)
+			}
+			fields[field] = append(fields[field], t.position)
+		}
+	}
+	index.docs[doc.ID] = e
+
+	key := nameKey(doc.ID)
+	i := sort.SearchStrings(index.sorted, key)
+	index.sorted = append(index.sorted, "")
+	copy(index.sorted[i+1:], index.sorted[i:])
+	index.sorted[i] = key
+}
+
+// Remove drops a document from the index; unknown IDs are ignored
+func (index *Index) Remove(id string) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.remove(id)
+}
+
+func (index *Index) remove(id string) {
+	e, exists := index.docs[id]
+	if !exists {
+		return
+	}
+	for _, term := range e.terms {
+		delete(index.postings[term], id)
+		if len(index.postings[term]) == 0 {
+			delete(index.postings, term)
+		}
+	}
+	for field, length := range e.lengths {
+		index.lengths[field] -= length
+	}
+	delete(index.docs, id)
+
+	key := nameKey(id)
+	if i := sort.SearchStrings(index.sorted, key); i < len(index.sorted) && index.sorted[i] == key {
+		index.sorted = append(index.sorted[:i], index.sorted[i+1:]...)
+	}
+}
+
+// nameKey sorts IDs case-insensitively while keeping IDs that differ only
+// in case apart
+func nameKey(id string) string {
+	return strings.ToLower(id) + "\x00" + id
+}
+
+// Len is the number of documents indexed
+func (index *Index) Len() int {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	return len(index.docs)
+}
+
+// Suggest completes a typed prefix to document IDs, for names that are their
+// own IDs. The heaviest documents come first, then alphabetical order.
+func (index *Index) Suggest(prefix string, limit int) []string {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	var matches []string
+	for i := sort.SearchStrings(index.sorted, prefix); i < len(index.sorted) && strings.HasPrefix(index.sorted[i], prefix); i++ {
+		matches = append(matches, index.sorted[i][strings.IndexByte(index.sorted[i], 0)+1:])
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return index.docs[matches[i]].weight > index.docs[matches[j]].weight
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Search returns every document matching the query, best first. Ties, and
+// queries that are only filters, go newest first.
+func (index *Index) Search(query *Query) []Hit {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	// Score each clause; a document must match all of them
+	var scores map[string]float64
+	for _, c := range query.clauses {
+		matches := index.matchClause(c)
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for id, score := range scores {
+			if extra, matched := matches[id]; matched {
+				scores[id] = score + extra
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	if scores == nil {
+		scores = make(map[string]float64, len(index.docs))
+		for id := range index.docs {
+			scores[id] = 0
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		e := index.docs[id]
+		if query.matchesFilters(e) {
+			hits = append(hits, Hit{ID: id, Score: score, Time: e.time})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Time != hits[j].Time {
+			return hits[i].Time > hits[j].Time
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	return hits
+}
+
+func (query *Query) matchesFilters(e *entry) bool {
+	for tag, value := range query.tags {
+		if e.tags[tag] != value {
+			return false
+		}
+	}
+	if query.after != 0 && e.time < query.after {
+		return false
+	}
+	if query.before != 0 && e.time >= query.before {
+		return false
+	}
+	return true
+}
+
+// matchClause returns the documents matching one clause and their BM25 score
+// for it
+func (index *Index) matchClause(c clause) map[string]float64 {
+	matches := make(map[string]float64)
+
+	if c.prefix {
+		// Any term with one of the prefixes will do; the best one counts
+		for term := range index.postings {
+			if !hasAnyPrefix(term, c.terms) {
+				continue
+			}
+			for id, score := range index.scoreTerm(term, c.field, nil) {
+				matches[id] = math.Max(matches[id], score)
+			}
+		}
+		return matches
+	}
+
+	if len(c.terms) == 1 {
+		return index.scoreTerm(c.terms[0].term, c.field, nil)
+	}
+
+	// Documents with every term of the phrase in the same field...
+	fields := index.phraseFields(c)
+	for id, score := range index.scoreTerm(c.terms[0].term, c.field, fields) {
+		matches[id] = score
+	}
+	// ...scored for each of them
+	for _, t := range c.terms[1:] {
+		for id, score := range index.scoreTerm(t.term, c.field, fields) {
+			matches[id] += score
+		}
+	}
+	return matches
+}
+
+func hasAnyPrefix(term string, prefixes []token) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(term, prefix.term) {
+			return true
+		}
+	}
+	return false
+}
+
+// phraseFields finds where a phrase's terms occur at the right offsets from
+// each other: doc ID -> the fields they do so in
+func (index *Index) phraseFields(c clause) map[string]map[string]bool {
+	found := make(map[string]map[string]bool)
+	first := c.terms[0]
+	for id, fields := range index.postings[first.term] {
+		for field, positions := range fields {
+			if c.field != "" && field != c.field {
+				continue
+			}
+			for _, start := range positions {
+				if index.phraseAt(c.terms[1:], id, field, start) {
+					if found[id] == nil {
+						found[id] = make(map[string]bool)
+					}
+					found[id][field] = true
+					break
+				}
+			}
+		}
+	}
+	return found
+}
+
+func (index *Index) phraseAt(rest []token, id, field string, start int) bool {
+	for _, t := range rest {
+		positions := index.postings[t.term][id][field]
+		i := sort.SearchInts(positions, start+t.position)
+		if i == len(positions) || positions[i] != start+t.position {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreTerm is the BM25 score of term in each document containing it,
+// summed over fields by weight. onlyField and only narrow the fields counted.
+func (index *Index) scoreTerm(term, onlyField string, only map[string]map[string]bool) map[string]float64 {
+	docs := index.postings[term]
+	n := float64(len(index.docs))
+	idf := math.Log(1 + (n-float64(len(docs))+0.5)/(float64(len(docs))+0.5))
+
+	scores := make(map[string]float64)
+	for id, fields := range docs {
+		if only != nil && only[id] == nil {
+			continue
+		}
+		for field, positions := range fields {
+			if (onlyField != "" && field != onlyField) || (only != nil && !only[id][field]) {
+				continue
+			}
+			average := float64(index.lengths[field]) / n
+			tf := float64(len(positions))
+			norm := 1 - b + b*float64(index.docs[id].lengths[field])/average
+			scores[id] += index.weights[field] * idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+	return scores
+}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// clause is one part of a query a document has to match: a single term, a
+// prefix, or a phrase of terms at fixed offsets from each other
+type clause struct {
+	field  string // Only match in this field; empty for any
+	terms  []token
+	prefix bool // terms are prefixes rather than whole terms, any of which will do
+}
+
+// Query is a parsed search. Every clause has to match and every filter has
+// to hold.
+type Query struct {
+	clauses []clause
+	tags    map[string]string // tag -> lowercase value
+	after   int64             // Unix seconds, 0 for no lower bound
+	before  int64             // Unix seconds, 0 for no upper bound
+}
+
+// dateLayout is how after: and before: take their dates
+const dateLayout = "2006-01-02"
+
+// Parse reads a query in the index's syntax:
+//
+//	gopher              posts with the term, stemmed
+//	gopher*             posts with a term starting with gopher
+//	"state of the art"  the exact phrase
+//	title:gopher        the term, in the title only; also title:"a phrase"
+//	author:alice        posts whose author tag is alice
+//	after:2024-01-31    posted on or after that day, UTC
+//	before:2024-02-01   posted before that day, UTC
+//
+// Filters use the names the index was created with.
+func (index *Index) Parse(text string) (*Query, error) {
+	query := &Query{tags: make(map[string]string)}
+
+	parts, err := splitQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		name, value := "", part
+		if i := strings.Index(part, ":"); i > 0 && !strings.HasPrefix(part, `"`) {
+			name, value = strings.ToLower(part[:i]), part[i+1:]
+		}
+		if value == "" {
+			return nil, fmt.Errorf("Filter %s: needs a value", name)
+		}
+
+		switch {
+		case name == "":
+			query.addText(index, "", value)
+		case name == "after" || name == "before":
+			day, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: wants a date like 2024-01-31, got %q", name, value)
+			}
+			if name == "after" {
+				query.after = day.Unix()
+			} else {
+				query.before = day.Unix()
+			}
+		case index.isField(name):
+			query.addText(index, name, value)
+		case index.isTag(name):
+			query.tags[name] = strings.ToLower(strings.Trim(value, `"`))
+		default:
+			return nil, fmt.Errorf("Unknown filter %s:", name)
+		}
+	}
+
+	if len(query.clauses) == 0 && len(query.tags) == 0 && query.after == 0 && query.before == 0 {
+		return nil, fmt.Errorf("Search query has nothing to search for")
+	}
+	return query, nil
+}
+
+// addText adds a plain, prefix or quoted value as a clause. A value that is
+// several words, quoted or not, like "e-mail", has to match as a phrase.
+func (query *Query) addText(index *Index, field, value string) {
+	if strings.HasSuffix(value, "*") && !strings.HasPrefix(value, `"`) {
+		words := words(strings.TrimSuffix(value, "*"))
+		if len(words) == 1 {
+			// The index holds stemmed terms, so "stories*" has to look for
+			// "stori" as well
+			prefixes := []token{{term: words[0]}}
+			if stemmed := stem(words[0]); !index.names && stemmed != words[0] {
+				prefixes = append(prefixes, token{term: stemmed})
+			}
+			query.clauses = append(query.clauses, clause{
+				field:  field,
+				terms:  prefixes,
+				prefix: true,
+			})
+			return
+		}
+	}
+
+	// Positions are relative to the phrase's first term
+	terms := tokenize(strings.Trim(value, `"*`), index.names)
+	if len(terms) == 0 {
+		return
+	}
+	first := terms[0].position
+	for i := range terms {
+		terms[i].position -= first
+	}
+	query.clauses = append(query.clauses, clause{field: field, terms: terms})
+}
+
+// splitQuery breaks a query on spaces, keeping quoted phrases whole
+func splitQuery(text string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("Unclosed quote in search query")
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts, nil
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"posts":    "post",
+		"posted":   "post",
+		"posting":  "post",
+		"caresses": "caress",
+		"ponies":   "poni",
+		"hopping":  "hop",
+		"hoped":    "hope",
+		"agreed":   "agree",
+		"happy":    "happi",
+		"go":       "go",
+		"ünicode":  "ünicode",
+	}
+	for word, want := range tests {
+		if got := stem(word); got != want {
+			t.Errorf("stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex(map[string]float64{"title": 2, "content": 1}, "subreddit", "author")
+	day := func(date string) int64 {
+		parsed, _ := time.Parse(dateLayout, date)
+		return parsed.Unix() + 3600
+	}
+	index.Add(Document{ID: "intro", Time: day("2024-01-10"),
+		Fields: map[string]string{"title": "Getting started with Go", "content": "Goroutines and channels for beginners"},
+		Tags:   map[string]string{"subreddit": "golang", "author": "Alice"}})
+	index.Add(Document{ID: "art", Time: day("2024-02-10"),
+		Fields: map[string]string{"title": "Pixel art", "content": "The state of the art in pixel shading"},
+		Tags:   map[string]string{"subreddit": "gamedev", "author": "bob"}})
+	index.Add(Document{ID: "channels", Time: day("2024-03-10"),
+		Fields: map[string]string{"title": "Buffered channels", "content": "When channel buffers help"},
+		Tags:   map[string]string{"subreddit": "golang", "author": "bob"}})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"channels", []string{"channels", "intro"}},
+		{"title:channels", []string{"channels"}},
+		{`"state of the art"`, []string{"art"}},
+		{`"art of the state"`, nil},
+		{"gorout*", []string{"intro"}},
+		{"beginners*", []string{"intro"}},
+		{"title:buffered*", []string{"channels"}},
+		{"shading*", []string{"art"}},
+		{"channel author:ALICE", []string{"intro"}},
+		{"subreddit:golang", []string{"channels", "intro"}},
+		{"subreddit:golang after:2024-02-01", []string{"channels"}},
+		{"before:2024-02-11 after:2024-02-10", []string{"art"}},
+		{"pixel channels", nil},
+	}
+	for _, tt := range tests {
+		query, err := index.Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.query, err)
+		}
+		hits := index.Search(query)
+		if len(hits) != len(tt.want) {
+			t.Errorf("Search(%q) = %+v, want %v", tt.query, hits, tt.want)
+			continue
+		}
+		for i, hit := range hits {
+			if hit.ID != tt.want[i] {
+				t.Errorf("Search(%q) = %+v, want %v", tt.query, hits, tt.want)
+				break
+			}
+		}
+	}
+
+	index.Remove("channels")
+	query, _ := index.Parse("channels")
+	if hits := index.Search(query); len(hits) != 1 || hits[0].ID != "intro" {
+		t.Errorf("Search after Remove = %+v, want only intro", hits)
+	}
+
+	for _, bad := range []string{`"unclosed`, "flair:cute", "after:yesterday", "the"} {
+		if _, err := index.Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestIndexSuggest(t *testing.T) {
+	index := NewIndex(map[string]float64{"name": 1})
+	index.Add(Document{ID: "golang", Weight: 10})
+	index.Add(Document{ID: "GoDot", Weight: 50})
+	index.Add(Document{ID: "gaming", Weight: 99})
+	index.Add(Document{ID: "gophers"})
+
+	got := index.Suggest("go", 2)
+	if len(got) != 2 || got[0] != "GoDot" || got[1] != "golang" {
+		t.Errorf("Suggest(go) = %v, want [GoDot golang]", got)
+	}
+
+	index.Remove("GoDot")
+	if got := index.Suggest("GOD", 5); len(got) != 0 {
+		t.Errorf("Suggest after Remove = %v, want none", got)
+	}
+}
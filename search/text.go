@@ -0,0 +1,167 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are too common to tell documents apart, so they're left out of
+// the index. They still take up a position, so phrases line up.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "no": true,
+	"not": true, "of": true, "on": true, "or": true, "so": true, "such": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "was": true,
+	"were": true, "will": true, "with": true,
+}
+
+// token is one indexed term and the word position it came from
+type token struct {
+	term     string
+	position int
+}
+
+// words splits text into lowercase runs of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// tokenize turns text into the terms the index stores: words without stop
+// words, stemmed. Names are taken as they are, since a user called "the" or
+// "running" means exactly that.
+func tokenize(text string, names bool) []token {
+	var tokens []token
+	for position, word := range words(text) {
+		if names {
+			tokens = append(tokens, token{term: word, position: position})
+			continue
+		}
+		if stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, token{term: stem(word), position: position})
+	}
+	return tokens
+}
+
+// stem strips English inflections so "posts", "posted" and "posting" all
+// index as "post". It is step 1 of the Porter stemmer: plurals, -ed, -ing
+// and a trailing y; derivational suffixes are left alone.
+func stem(word string) string {
+	if len(word) <= 2 || !isASCII(word) {
+		return word
+	}
+	w := []byte(word)
+
+	// Step 1a: plurals
+	switch {
+	case hasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case hasSuffix(w, "ies"):
+		w = w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+	case hasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	// Step 1b: past tense and gerunds
+	switch {
+	case hasSuffix(w, "eed"):
+		if measure(w[:len(w)-3]) > 0 {
+			w = w[:len(w)-1]
+		}
+	case hasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		w = tidyStem(w[:len(w)-2])
+	case hasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		w = tidyStem(w[:len(w)-3])
+	}
+
+	// Step 1c: happy -> happi, so it meets "happiness" halfway
+	if hasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+	return string(w)
+}
+
+// tidyStem repairs a stem after -ed or -ing came off: hopp -> hop,
+// hop -> hope, conflat -> conflate
+func tidyStem(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+		return append(w, 'e')
+	case endsDoubleConsonant(w) && !hasSuffix(w, "l") && !hasSuffix(w, "s") && !hasSuffix(w, "z"):
+		return w[:len(w)-1]
+	case measure(w) == 1 && endsCVC(w):
+		return append(w, 'e')
+	}
+	return w
+}
+
+func isASCII(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) >= len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+// isConsonant follows Porter: y is a consonant only before a vowel or at the start
+func isConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+	return true
+}
+
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// measure counts the vowel-consonant sequences in w, Porter's m
+func measure(w []byte) int {
+	m := 0
+	inVowels := false
+	for i := range w {
+		if isConsonant(w, i) {
+			if inVowels {
+				m++
+			}
+			inVowels = false
+		} else {
+			inVowels = true
+		}
+	}
+	return m
+}
+
+func endsDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC is consonant-vowel-consonant where the last isn't w, x or y
+func endsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
@@ -99,7 +99,7 @@ func (sc *SimulationController) StartSimulation() error {
     // Create engine actors
     for i := 0; i < sc.numEngines; i++ {
         engineProps := actor.PropsFromProducer(func() actor.Actor {
-            return actors.NewEngineActor()
+            return actors.NewEngineActor(sc.system, actors.NewMemoryStore(), actors.DefaultEngineConfig())
         })
         enginePID := sc.system.Root.Spawn(engineProps)
         sc.enginePIDs = append(sc.enginePIDs, enginePID)
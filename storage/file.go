@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	logFile      = "log.jsonl"
+
+	// compactEvery is how many log records accumulate before the log is
+	// folded into a fresh snapshot
+	compactEvery = 10000
+
+	// compactMinBytes is how large the log may grow before it is folded in
+	// early for outgrowing the snapshot
+	compactMinBytes = 1 << 20
+)
+
+// logRecord is one line of the append-only log
+type logRecord struct {
+	Table  string          `json:"table"`
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Delete bool            `json:"delete,omitempty"`
+}
+
+// FileBackend stores records in a directory as a JSON snapshot plus an
+// append-only log of changes made since. Every change is written to the log
+// before it is acknowledged; on open the log is replayed over the snapshot.
+// The two are folded into a new snapshot every compactEvery records, or
+// sooner once the log is larger than the snapshot.
+//
+// The log is not synced after each write, so a crash of the process loses
+// nothing but a crash of the machine may lose the latest changes.
+type FileBackend struct {
+	dir          string
+	mu           sync.Mutex
+	data         map[string]map[string]json.RawMessage // table -> key -> latest value
+	log          *os.File
+	pending      int   // records in the log since the last snapshot
+	logSize      int64 // bytes in the log
+	snapshotSize int64 // bytes in the last snapshot
+}
+
+// OpenFileBackend opens or creates a data directory
+func OpenFileBackend(dir string) (*FileBackend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: creating %s: %w", dir, err)
+	}
+
+	backend := &FileBackend{
+		dir:  dir,
+		data: make(map[string]map[string]json.RawMessage),
+	}
+	if err := backend.readSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := backend.replayLog(); err != nil {
+		return nil, err
+	}
+
+	// Start every run from a clean snapshot and an empty log
+	if err := backend.compact(); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+func (b *FileBackend) Load(table string, fn func(key string, value []byte) error) error {
+	b.mu.Lock()
+	rows := make(map[string]json.RawMessage, len(b.data[table]))
+	for key, value := range b.data[table] {
+		rows[key] = value
+	}
+	b.mu.Unlock()
+
+	for key, value := range rows {
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *FileBackend) Save(table, key string, value []byte) error {
+	return b.append(logRecord{Table: table, Key: key, Value: value})
+}
+
+func (b *FileBackend) Delete(table, key string) error {
+	return b.append(logRecord{Table: table, Key: key, Delete: true})
+}
+
+// Close folds the log into the snapshot and releases the log file
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.log == nil {
+		return nil
+	}
+	if err := b.compactLocked(); err != nil {
+		return err
+	}
+	err := b.log.Close()
+	b.log = nil
+	return err
+}
+
+func (b *FileBackend) append(record logRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.log == nil {
+		return errors.New("storage: backend is closed")
+	}
+	if _, err := b.log.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("storage: writing log: %w", err)
+	}
+	b.apply(record)
+
+	b.pending++
+	b.logSize += int64(len(line)) + 1
+	if b.pending >= compactEvery || b.logSize >= compactMinBytes && b.logSize > b.snapshotSize {
+		return b.compactLocked()
+	}
+	return nil
+}
+
+func (b *FileBackend) apply(record logRecord) {
+	rows, exists := b.data[record.Table]
+	if !exists {
+		rows = make(map[string]json.RawMessage)
+		b.data[record.Table] = rows
+	}
+	if record.Delete {
+		delete(rows, record.Key)
+	} else {
+		rows[record.Key] = record.Value
+	}
+}
+
+func (b *FileBackend) readSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(b.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("storage: reading snapshot: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &b.data); err != nil {
+		return fmt.Errorf("storage: decoding snapshot: %w", err)
+	}
+	return nil
+}
+
+func (b *FileBackend) replayLog() error {
+	file, err := os.Open(filepath.Join(b.dir, logFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("storage: opening log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A partial last line means we crashed mid-write; that change
+			// was never acknowledged, so dropping it is safe
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("storage: reading log: %w", err)
+		}
+
+		var record logRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("storage: decoding log: %w", err)
+		}
+		b.apply(record)
+	}
+}
+
+func (b *FileBackend) compact() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.compactLocked()
+}
+
+// compactLocked writes the current data as the new snapshot and starts an
+// empty log. The snapshot is renamed into place, so a crash leaves either the
+// old snapshot and full log or the new snapshot; replaying the old log over
+// the new snapshot is harmless because records hold whole values.
+func (b *FileBackend) compactLocked() error {
+	data, err := json.Marshal(b.data)
+	if err != nil {
+		return fmt.Errorf("storage: encoding snapshot: %w", err)
+	}
+
+	tmpPath := filepath.Join(b.dir, snapshotFile+".tmp")
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("storage: writing snapshot: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("storage: writing snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("storage: writing snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("storage: writing snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(b.dir, snapshotFile)); err != nil {
+		return fmt.Errorf("storage: writing snapshot: %w", err)
+	}
+
+	if b.log != nil {
+		b.log.Close()
+	}
+	b.log, err = os.OpenFile(filepath.Join(b.dir, logFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("storage: opening log: %w", err)
+	}
+	b.pending = 0
+	b.logSize = 0
+	b.snapshotSize = int64(len(data))
+	return nil
+}
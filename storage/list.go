@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// List is a keyed collection of ordered lists, such as the IDs of a user's
+// posts. Each member is written to the backend as its own record, keyed
+// "key/sequence", so appending to or removing from a long list writes one
+// record rather than the whole list.
+//
+// Like Table, Get hands out the stored slice; treat it as read-only.
+type List[T any] struct {
+	name    string
+	backend Backend
+	mu      sync.RWMutex
+	lists   map[string][]T
+	seqs    map[string][]uint64 // sequence number of each member, in step with lists
+}
+
+// NewList opens the named list table, loading whatever the backend already
+// holds
+func NewList[T any](backend Backend, name string) (*List[T], error) {
+	list := &List[T]{
+		name:    name,
+		backend: backend,
+		lists:   make(map[string][]T),
+		seqs:    make(map[string][]uint64),
+	}
+
+	type member struct {
+		seq   uint64
+		value T
+	}
+	members := make(map[string][]member)
+	legacy := make(map[string][]T)
+	err := backend.Load(name, func(key string, value []byte) error {
+		parent, seq, ok := splitMemberKey(key)
+		if !ok {
+			// Stored as a whole list before members had their own records
+			var values []T
+			if err := json.Unmarshal(value, &values); err != nil {
+				return fmt.Errorf("storage: decoding %s/%s: %w", name, key, err)
+			}
+			legacy[key] = values
+			return nil
+		}
+		var row T
+		if err := json.Unmarshal(value, &row); err != nil {
+			return fmt.Errorf("storage: decoding %s/%s: %w", name, key, err)
+		}
+		members[parent] = append(members[parent], member{seq: seq, value: row})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for parent, rows := range members {
+		sort.Slice(rows, func(i, j int) bool { return rows[i].seq < rows[j].seq })
+		for _, row := range rows {
+			list.lists[parent] = append(list.lists[parent], row.value)
+			list.seqs[parent] = append(list.seqs[parent], row.seq)
+		}
+	}
+	for key, values := range legacy {
+		if err := list.Append(key, values...); err != nil {
+			return nil, err
+		}
+		if err := backend.Delete(name, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// splitMemberKey splits a member's record key into the list key and the
+// member's sequence number
+func splitMemberKey(key string) (string, uint64, bool) {
+	slash := strings.LastIndexByte(key, '/')
+	if slash < 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(key[slash+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return key[:slash], seq, true
+}
+
+func memberKey(key string, seq uint64) string {
+	return key + "/" + strconv.FormatUint(seq, 10)
+}
+
+// Get returns the list stored under key, oldest first
+func (l *List[T]) Get(key string) ([]T, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	values, exists := l.lists[key]
+	return values, exists
+}
+
+// Has reports whether key holds a list
+func (l *List[T]) Has(key string) bool {
+	_, exists := l.Get(key)
+	return exists
+}
+
+// Append adds values to the end of the list under key
+func (l *List[T]) Append(key string, values ...T) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seqs := l.seqs[key]
+	next := uint64(0)
+	if len(seqs) > 0 {
+		next = seqs[len(seqs)-1] + 1
+	}
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("storage: encoding %s/%s: %w", l.name, key, err)
+		}
+		if err := l.backend.Save(l.name, memberKey(key, next), data); err != nil {
+			return err
+		}
+		l.lists[key] = append(l.lists[key], value)
+		l.seqs[key] = append(l.seqs[key], next)
+		next++
+	}
+	return nil
+}
+
+// Remove drops every member of the list under key that drop matches,
+// dropping the key once the list is empty
+func (l *List[T]) Remove(key string, drop func(value T) bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	values := l.lists[key]
+	return l.keep(key, func(i int) bool { return !drop(values[i]) })
+}
+
+// Trim drops the oldest members of the list under key so at most max are
+// left, and returns the dropped members
+func (l *List[T]) Trim(key string, max int) ([]T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	values := l.lists[key]
+	if len(values) <= max {
+		return nil, nil
+	}
+	dropped := values[:len(values)-max]
+	err := l.keep(key, func(i int) bool { return i >= len(dropped) })
+	return dropped, err
+}
+
+// Delete removes the whole list under key
+func (l *List[T]) Delete(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.keep(key, func(i int) bool { return false })
+}
+
+// Range calls fn for each list until fn returns false. fn must not call back
+// into the same table.
+func (l *List[T]) Range(fn func(key string, values []T) bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for key, values := range l.lists {
+		if !fn(key, values) {
+			return
+		}
+	}
+}
+
+// Len returns the number of lists
+func (l *List[T]) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.lists)
+}
+
+// keep deletes the records of the members keep rejects, by index, and
+// replaces the list with a copy of the rest. Readers may still hold the old
+// slice, so it is never edited in place.
+func (l *List[T]) keep(key string, keep func(i int) bool) error {
+	values, seqs := l.lists[key], l.seqs[key]
+	keptValues := make([]T, 0, len(values))
+	keptSeqs := make([]uint64, 0, len(seqs))
+	var err error
+	for i := range values {
+		// Once a delete fails, keep the remaining members so memory and the
+		// backend still agree
+		if !keep(i) && err == nil {
+			if err = l.backend.Delete(l.name, memberKey(key, seqs[i])); err == nil {
+				continue
+			}
+		}
+		keptValues = append(keptValues, values[i])
+		keptSeqs = append(keptSeqs, seqs[i])
+	}
+
+	if len(keptValues) == 0 {
+		delete(l.lists, key)
+		delete(l.seqs, key)
+	} else {
+		l.lists[key] = keptValues
+		l.seqs[key] = keptSeqs
+	}
+	return err
+}
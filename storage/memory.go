@@ -0,0 +1,25 @@
+package storage
+
+// MemoryBackend keeps nothing beyond what the tables already hold in memory;
+// state is gone when the process exits.
+type MemoryBackend struct{}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+func (b *MemoryBackend) Load(table string, fn func(key string, value []byte) error) error {
+	return nil
+}
+
+func (b *MemoryBackend) Save(table, key string, value []byte) error {
+	return nil
+}
+
+func (b *MemoryBackend) Delete(table, key string) error {
+	return nil
+}
+
+func (b *MemoryBackend) Close() error {
+	return nil
+}
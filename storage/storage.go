@@ -0,0 +1,149 @@
+// Package storage keeps the actors' state in keyed tables backed by a
+// pluggable Backend, so the same actor code can run purely in memory or
+// persist everything to disk and pick it back up after a restart.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Backend persists raw table records. Tables hold the decoded values in
+// memory and write every change through to the backend.
+type Backend interface {
+	// Load calls fn for every record stored in table
+	Load(table string, fn func(key string, value []byte) error) error
+	// Save stores the record, replacing any previous value
+	Save(table, key string, value []byte) error
+	// Delete removes the record; deleting a missing record is not an error
+	Delete(table, key string) error
+	Close() error
+}
+
+// Table is a keyed collection of values of one type. It is safe for
+// concurrent use; values are encoded as JSON when written to the backend.
+//
+// Values are often pointers, and Get hands out the stored pointer itself. Call
+// Put after changing one so the change reaches the backend; callers that
+// share values across goroutines should Put a changed copy instead of
+// mutating the stored value.
+type Table[T any] struct {
+	name    string
+	backend Backend
+	mu      sync.RWMutex
+	rows    map[string]T
+}
+
+// NewTable opens the named table, loading whatever the backend already holds
+func NewTable[T any](backend Backend, name string) (*Table[T], error) {
+	table := &Table[T]{
+		name:    name,
+		backend: backend,
+		rows:    make(map[string]T),
+	}
+
+	err := backend.Load(name, func(key string, value []byte) error {
+		var row T
+		if err := json.Unmarshal(value, &row); err != nil {
+			return fmt.Errorf("storage: decoding %s/%s: %w", name, key, err)
+		}
+		table.rows[key] = row
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// Get returns the value stored under key
+func (t *Table[T]) Get(key string) (T, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	row, exists := t.rows[key]
+	return row, exists
+}
+
+// Has reports whether key is present
+func (t *Table[T]) Has(key string) bool {
+	_, exists := t.Get(key)
+	return exists
+}
+
+// Put stores value under key
+func (t *Table[T]) Put(key string, value T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.put(key, value)
+}
+
+// Delete removes key
+func (t *Table[T]) Delete(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.delete(key)
+}
+
+// Update atomically replaces the value under key with the result of fn.
+// fn gets the current value and whether it exists, and returns the new value
+// and whether to keep it; returning false deletes the key.
+func (t *Table[T]) Update(key string, fn func(value T, exists bool) (T, bool)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, exists := t.rows[key]
+	next, keep := fn(current, exists)
+	if !keep {
+		if !exists {
+			return nil
+		}
+		return t.delete(key)
+	}
+	return t.put(key, next)
+}
+
+// Range calls fn for each row until fn returns false. fn must not call back
+// into the same table.
+func (t *Table[T]) Range(fn func(key string, value T) bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for key, row := range t.rows {
+		if !fn(key, row) {
+			return
+		}
+	}
+}
+
+// Len returns the number of rows
+func (t *Table[T]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.rows)
+}
+
+func (t *Table[T]) put(key string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("storage: encoding %s/%s: %w", t.name, key, err)
+	}
+	if err := t.backend.Save(t.name, key, data); err != nil {
+		return err
+	}
+	t.rows[key] = value
+	return nil
+}
+
+func (t *Table[T]) delete(key string) error {
+	if err := t.backend.Delete(t.name, key); err != nil {
+		return err
+	}
+	delete(t.rows, key)
+	return nil
+}
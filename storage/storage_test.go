@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testRecord struct {
+	Name  string
+	Votes map[string]bool
+}
+
+func TestFileBackendSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	backend, err := OpenFileBackend(dir)
+	if err != nil {
+		t.Fatalf("OpenFileBackend() error = %v", err)
+	}
+	records, err := NewTable[*testRecord](backend, "records")
+	if err != nil {
+		t.Fatalf("NewTable() error = %v", err)
+	}
+
+	records.Put("a", &testRecord{Name: "first", Votes: map[string]bool{"alice": true}})
+	records.Put("b", &testRecord{Name: "second"})
+	records.Delete("b")
+	records.Update("a", func(value *testRecord, exists bool) (*testRecord, bool) {
+		value.Votes["bob"] = false
+		return value, true
+	})
+
+	// Reopen without Close, as after a crash, so only the log has the changes
+	reopened, err := OpenFileBackend(dir)
+	if err != nil {
+		t.Fatalf("OpenFileBackend() reopen error = %v", err)
+	}
+	defer reopened.Close()
+
+	records, err = NewTable[*testRecord](reopened, "records")
+	if err != nil {
+		t.Fatalf("NewTable() after reopen error = %v", err)
+	}
+
+	if records.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", records.Len())
+	}
+	got, exists := records.Get("a")
+	if !exists || got.Name != "first" || len(got.Votes) != 2 || got.Votes["bob"] {
+		t.Errorf("Get(a) = %+v, %v, want first with two votes", got, exists)
+	}
+	if records.Has("b") {
+		t.Errorf("Has(b) = true after Delete")
+	}
+}
+
+func TestFileBackendIgnoresTornLogTail(t *testing.T) {
+	dir := t.TempDir()
+
+	log := `{"table":"records","key":"a","value":{"Name":"kept"}}` + "\n" +
+		`{"table":"records","key":"b","value":{"Na`
+	if err := os.WriteFile(filepath.Join(dir, logFile), []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := OpenFileBackend(dir)
+	if err != nil {
+		t.Fatalf("OpenFileBackend() error = %v", err)
+	}
+	defer backend.Close()
+
+	records, err := NewTable[testRecord](backend, "records")
+	if err != nil {
+		t.Fatalf("NewTable() error = %v", err)
+	}
+	if got, _ := records.Get("a"); got.Name != "kept" || records.Has("b") {
+		t.Errorf("got a=%+v, has b=%v; want only the complete record", got, records.Has("b"))
+	}
+}
+
+func TestListSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	// A list saved whole, as before members had their own records
+	snapshot := `{"ids":{"old":["x","y"]}}`
+	if err := os.WriteFile(filepath.Join(dir, snapshotFile), []byte(snapshot), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := OpenFileBackend(dir)
+	if err != nil {
+		t.Fatalf("OpenFileBackend() error = %v", err)
+	}
+	ids, err := NewList[string](backend, "ids")
+	if err != nil {
+		t.Fatalf("NewList() error = %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		ids.Append("a", fmt.Sprint(i))
+	}
+	ids.Remove("a", func(id string) bool { return id == "3" })
+	if dropped, _ := ids.Trim("a", 10); len(dropped) != 9 || dropped[0] != "0" {
+		t.Errorf("Trim() dropped %v, want 0 to 9 but 3", dropped)
+	}
+	ids.Append("old", "z")
+	ids.Append("gone", "1")
+	ids.Delete("gone")
+
+	// One more member adds one short record, however long the list
+	before, _ := os.Stat(filepath.Join(dir, logFile))
+	ids.Append("a", "20")
+	after, _ := os.Stat(filepath.Join(dir, logFile))
+	if grew := after.Size() - before.Size(); grew > 100 {
+		t.Errorf("Append() grew the log by %d bytes, want one short record", grew)
+	}
+
+	// Reopen without Close, as after a crash, so only the log has the changes
+	reopened, err := OpenFileBackend(dir)
+	if err != nil {
+		t.Fatalf("OpenFileBackend() reopen error = %v", err)
+	}
+	defer reopened.Close()
+
+	ids, err = NewList[string](reopened, "ids")
+	if err != nil {
+		t.Fatalf("NewList() after reopen error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "a", want: "[10 11 12 13 14 15 16 17 18 19 20]"},
+		{key: "old", want: "[x y z]"},
+		{key: "gone", want: "[]"},
+	}
+	for _, tt := range tests {
+		if got, _ := ids.Get(tt.key); fmt.Sprint(got) != tt.want {
+			t.Errorf("Get(%s) = %v, want %s", tt.key, got, tt.want)
+		}
+	}
+	if ids.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", ids.Len())
+	}
+}
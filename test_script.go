@@ -137,24 +137,27 @@ func runTests() {
 	// 3. Content Creation
 	fmt.Println("\n=== 3. Creating Posts ===")
 	// Programming posts
-	techie.makeRequest("POST", "/post", map[string]string{
+	resp, _ = techie.makeRequest("POST", "/post", map[string]string{
 		"title": "Go vs Python Performance",
 		"content": "Comparing Go and Python for backend: Go wins in concurrent tasks",
 		"subredditName": "programming",
 	}, true)
+	goVsPythonId := resp["postId"].(string)
 
-	gamer.makeRequest("POST", "/post", map[string]string{
+	resp, _ = gamer.makeRequest("POST", "/post", map[string]string{
 		"title": "Game Development in Go",
 		"content": "Using Go for game server development",
 		"subredditName": "programming",
 	}, true)
+	gameDevId := resp["postId"].(string)
 
 	// Gaming posts
-	gamer.makeRequest("POST", "/post", map[string]string{
+	resp, _ = gamer.makeRequest("POST", "/post", map[string]string{
 		"title": "Best Gaming Setups 2024",
 		"content": "Top gaming PC builds and peripherals",
 		"subredditName": "gaming",
 	}, true)
+	gamingSetupsId := resp["postId"].(string)
 
 	techie.makeRequest("POST", "/post", map[string]string{
 		"title": "Gaming PC Build Guide",
@@ -179,20 +182,20 @@ func runTests() {
 	fmt.Println("\n=== 4. Adding Comments and Votes ===")
 	// Comments on programming posts
 	resp, _ = gamer.makeRequest("POST", "/comment", map[string]string{
-		"postId": "post_programming_Go vs Python Performance",
+		"postId": goVsPythonId,
 		"content": "Go's concurrency is amazing!",
 	}, true)
 	comment1Id := resp["commentId"].(string)
 
 	resp, _ = moviebuff.makeRequest("POST", "/comment", map[string]string{
-		"postId": "post_programming_Game Development in Go",
+		"postId": gameDevId,
 		"content": "Great for game servers!",
 	}, true)
 	comment2Id := resp["commentId"].(string)
 
 	// Comments on gaming posts
 	resp, _ = techie.makeRequest("POST", "/comment", map[string]string{
-		"postId": "post_gaming_Best Gaming Setups 2024",
+		"postId": gamingSetupsId,
 		"content": "RTX 4090 is overkill for most games",
 	}, true)
 	comment3Id := resp["commentId"].(string)
@@ -250,7 +253,7 @@ func runTests() {
 	
 	// Edit post
 	fmt.Println("\nEditing post...")
-	resp, err := techie.makeRequest("PATCH", "/post/post_programming_Go vs Python Performance", map[string]string{
+	resp, err := techie.makeRequest("PATCH", "/post/"+goVsPythonId, map[string]string{
 		"content": "Updated: Go significantly outperforms Python in concurrent tasks",
 	}, true)
 	if err != nil {